@@ -52,6 +52,7 @@ func main() {
 		&models.Story{},
 		&models.Genre{},
 		&models.Chapter{},
+		&models.Volume{},
 		&models.BookMark{},
 		&models.ReadingHistory{},
 		&models.Comment{},
@@ -64,11 +65,41 @@ func main() {
 		&models.CommentLike{},
 		&models.CommentReport{},
 		&models.StoryRating{},
+		&models.StoryTrendingScore{},
+		&models.StoryRanking{},
+		&models.StorySimilarity{},
+		&models.Person{},
+		&models.StoryPerson{},
+		&models.Tag{},
+		&models.StoryTag{},
+		&models.Group{},
+		&models.GroupMember{},
+		&models.Role{},
+		&models.ChapterRevision{},
+		&models.StoryTagVote{},
+		&models.StoryRelation{},
+		&models.StorySlugHistory{},
+		&models.SiteViewDaily{},
+		&models.StoryViewDaily{},
+		&models.ChapterViewDaily{},
+		&models.ReferrerViewDaily{},
+		&models.StoryChapterFunnel{},
+		&models.StoryReaderProgress{},
 	); err != nil {
 		log.Fatal("Không thể migrate database:", err)
 	}
 	log.Println("Database Đã Migrate Thành Công")
 
+	// Full-text search index cho stories (unaccent + tsvector trigger)
+	if err := database.SetupStorySearch(db); err != nil {
+		log.Fatal("Không thể setup full-text search:", err)
+	}
+
+	// Số chapter unique theo truyện + sắp xếp theo ordering
+	if err := database.SetupChapterOrdering(db); err != nil {
+		log.Fatal("Không thể chuẩn hóa thứ tự chapter:", err)
+	}
+
 	// One-time migration: Generate tag_name for existing users
 	var usersWithoutTagName []models.User
 	if err := db.Where("tag_name IS NULL OR tag_name = ''").Find(&usersWithoutTagName).Error; err == nil && len(usersWithoutTagName) > 0 {
@@ -98,6 +129,18 @@ func main() {
 	userSettingsRepo := repositories.NewUserSettingsRepository(db)
 	commentReportRepo := repositories.NewCommentReportRepository(db)
 	storyRatingRepo := repositories.NewStoryRatingRepository(db)
+	searchRepo := repositories.NewSearchRepository(db)
+	trendingRepo := repositories.NewTrendingRepository(db)
+	rankingRepo := repositories.NewRankingRepository(db)
+	recommendationRepo := repositories.NewRecommendationRepository(db)
+	personRepo := repositories.NewPersonRepository(db)
+	tagRepo := repositories.NewTagRepository(db)
+	storyRelationRepo := repositories.NewStoryRelationRepository(db)
+	analyticsRepo := repositories.NewAnalyticsRepository(db)
+	groupRepo := repositories.NewGroupRepository(db)
+	roleRepo := repositories.NewRoleRepository(db)
+	chapterRevisionRepo := repositories.NewChapterRevisionRepository(db)
+	volumeRepo := repositories.NewVolumeRepository(db)
 
 	// Init Centrifugo client
 	centrifugoClient := centrifugo.NewClient(
@@ -127,7 +170,7 @@ func main() {
 	}()
 
 	// Initialize services - Khởi tạo service
-	authService := services.NewAuthService(userRepo, refreshTokenRepo, cfg) // Cập nhật với refreshTokenRepo
+	authService := services.NewAuthService(userRepo, refreshTokenRepo, roleRepo, cfg) // Cập nhật với refreshTokenRepo
 
 	// Initialize upload service (optional - requires Cloudinary config)
 	var uploadHandler *handlers.UploadHandler
@@ -141,14 +184,32 @@ func main() {
 	}
 
 	// Pass uploadService to storyService for old cover image deletion
-	storyService := services.NewStoryService(storyRepo, genreRepo, storyViewRepo, uploadService)
+	storyService := services.NewStoryService(storyRepo, genreRepo, storyViewRepo, trendingRepo, storyRelationRepo, uploadService)
 	genreService := services.NewGenreService(genreRepo)
-	chapterService := services.NewChapterService(chapterRepo, storyRepo)
+	chapterService := services.NewChapterService(chapterRepo, storyRepo, storyViewRepo, chapterRevisionRepo, volumeRepo, readingHistoryRepo)
 	bookmarkService := services.NewBookmarkService(bookmarkRepo, storyRepo)
 	commentService := services.NewCommentService(commentRepo, storyRepo, chapterRepo)
 	notificationService := services.NewNotificationService(notificationRepo, centrifugoClient)
 	commentReportService := services.NewCommentReportService(commentReportRepo)
 	storyRatingService := services.NewStoryRatingService(storyRatingRepo, storyRepo)
+	searchService := services.NewSearchService(searchRepo)
+	rankingService := services.NewRankingService(rankingRepo)
+	recommendationService := services.NewRecommendationService(recommendationRepo, storyRepo, trendingRepo)
+	personService := services.NewPersonService(personRepo, storyRepo)
+	tagService := services.NewTagService(tagRepo, storyRepo)
+	analyticsService := services.NewAnalyticsService(analyticsRepo, storyRepo, chapterRepo)
+	groupService := services.NewGroupService(groupRepo, storyRepo, userRepo, chapterService)
+	roleService := services.NewRoleService(roleRepo)
+	volumeService := services.NewVolumeService(volumeRepo, storyRepo, uploadService)
+	exportService := services.NewExportService(storyRepo, chapterRepo, volumeRepo)
+	importService := services.NewImportService(storyRepo, genreRepo, storyService, chapterService, uploadService)
+
+	// Tạo các role mặc định (admin, reader, moderator, uploader) nếu chưa có
+	if created, err := roleService.SeedDefaultRoles(); err != nil {
+		log.Fatal("Không thể tạo role mặc định:", err)
+	} else if created > 0 {
+		log.Printf("✅ Created %d default role(s)", created)
+	}
 
 	// Start background job for scheduled chapter publishing
 	go func() {
@@ -171,6 +232,91 @@ func main() {
 		}
 	}()
 
+	// Start background job for trending scores (/stories/hot)
+	go func() {
+		ticker := time.NewTicker(15 * time.Minute)
+		defer ticker.Stop()
+
+		// Run once at startup
+		if err := storyService.RefreshTrendingScores(); err != nil {
+			log.Printf("❌ Failed to refresh trending scores: %v", err)
+		}
+
+		for range ticker.C {
+			if err := storyService.RefreshTrendingScores(); err != nil {
+				log.Printf("❌ Failed to refresh trending scores: %v", err)
+			}
+		}
+	}()
+
+	// Start background job for ranking snapshots (/rankings)
+	go func() {
+		ticker := time.NewTicker(1 * time.Hour)
+		defer ticker.Stop()
+
+		// Run once at startup
+		if err := rankingService.SnapshotRankings(); err != nil {
+			log.Printf("❌ Failed to snapshot rankings: %v", err)
+		}
+
+		for range ticker.C {
+			if err := rankingService.SnapshotRankings(); err != nil {
+				log.Printf("❌ Failed to snapshot rankings: %v", err)
+			}
+		}
+	}()
+
+	// Start background job for similar stories (/stories/:slug/similar)
+	go func() {
+		ticker := time.NewTicker(6 * time.Hour)
+		defer ticker.Stop()
+
+		// Run once at startup
+		if err := recommendationService.RefreshSimilarStories(); err != nil {
+			log.Printf("❌ Failed to refresh similar stories: %v", err)
+		}
+
+		for range ticker.C {
+			if err := recommendationService.RefreshSimilarStories(); err != nil {
+				log.Printf("❌ Failed to refresh similar stories: %v", err)
+			}
+		}
+	}()
+
+	// Start background job for view analytics rollup (raw story_views -> bảng daily, xóa raw quá hạn)
+	go func() {
+		ticker := time.NewTicker(1 * time.Hour)
+		defer ticker.Stop()
+
+		// Run once at startup (tổng hợp lại toàn bộ raw còn giữ)
+		if err := analyticsService.RollupViews(true); err != nil {
+			log.Printf("❌ Failed to roll up view analytics: %v", err)
+		}
+
+		for range ticker.C {
+			if err := analyticsService.RollupViews(false); err != nil {
+				log.Printf("❌ Failed to roll up view analytics: %v", err)
+			}
+		}
+	}()
+
+	// Start background job for reader drop-off funnels (/admin/stories/:id/funnel)
+	go func() {
+		ticker := time.NewTicker(6 * time.Hour)
+		defer ticker.Stop()
+
+		// Run once at startup
+		if err := analyticsService.RefreshChapterFunnels(); err != nil {
+			log.Printf("❌ Failed to refresh chapter funnels: %v", err)
+		}
+
+		for range ticker.C {
+			if err := analyticsService.RefreshChapterFunnels(); err != nil {
+				log.Printf("❌ Failed to refresh chapter funnels: %v", err)
+			}
+		}
+	}()
+
 	// Initialize handlers - Khởi tạo handler
 	h := &routes.Handlers{
 		Auth:           handlers.NewAuthHandler(authService, uploadService, cfg),
@@ -182,11 +328,22 @@ func main() {
 		Notification:   handlers.NewNotificationHandler(notificationService),
 		Upload:         uploadHandler,
 		CSRF:           handlers.NewCSRFHandler(cfg),
-		User:           handlers.NewUserHandler(userRepo),
+		User:           handlers.NewUserHandler(userRepo, roleService),
 		ReadingHistory: handlers.NewReadingHistoryHandler(readingHistoryRepo),
 		UserSettings:   handlers.NewUserSettingsHandler(services.NewUserSettingsService(userSettingsRepo)),
 		Centrifugo:     handlers.NewCentrifugoHandler(centrifugoClient),
 		StoryRating:    handlers.NewStoryRatingHandler(storyRatingService),
+		Search:         handlers.NewSearchHandler(searchService),
+		Ranking:        handlers.NewRankingHandler(rankingService),
+		Recommendation: handlers.NewRecommendationHandler(recommendationService),
+		Person:         handlers.NewPersonHandler(personService),
+		Tag:            handlers.NewTagHandler(tagService),
+		Analytics:      handlers.NewAnalyticsHandler(analyticsService),
+		Group:          handlers.NewGroupHandler(groupService),
+		Role:           handlers.NewRoleHandler(roleService),
+		Volume:         handlers.NewVolumeHandler(volumeService),
+		Export:         handlers.NewExportHandler(exportService),
+		Import:         handlers.NewImportHandler(importService),
 	}
 
 	// Setup Gin router - Setup router cho Gin
@@ -0,0 +1,61 @@
+package database
+
+import (
+	"fmt"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// chapterOrderingStatements - Chuẩn hóa thứ tự/số chapter và thêm ràng buộc unique (idempotent)
+var chapterOrderingStatements = []string{
+	// Chapter cũ chưa có ordering: sắp theo số chapter
+	// Chỉ chạy một lần (trước khi có index ordering), sau đó ordering luôn > 0 do service cấp
+	`DO $$
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM pg_indexes WHERE indexname = 'idx_chapters_story_ordering') THEN
+			UPDATE chapters SET ordering = chapter_number WHERE ordering = 0;
+		END IF;
+	END
+	$$`,
+
+	// Số chapter bị trùng (do xóa rồi tạo lại): giữ chapter cũ nhất, các chapter sau nhận số mới ở cuối
+	// Ordering giữ nguyên nên vị trí đọc không đổi
+	`WITH ranked AS (
+		SELECT id, story_id, created_at,
+			ROW_NUMBER() OVER (PARTITION BY story_id, chapter_number ORDER BY created_at, id) AS rn
+		FROM chapters WHERE deleted_at IS NULL
+	), dupes AS (
+		SELECT id, story_id, ROW_NUMBER() OVER (PARTITION BY story_id ORDER BY created_at, id) AS k
+		FROM ranked WHERE rn > 1
+	), maxes AS (
+		SELECT story_id, MAX(chapter_number) AS max_number FROM chapters GROUP BY story_id
+	)
+	UPDATE chapters c SET chapter_number = m.max_number + d.k
+	FROM dupes d JOIN maxes m ON m.story_id = d.story_id
+	WHERE c.id = d.id`,
+
+	`CREATE UNIQUE INDEX IF NOT EXISTS idx_chapters_story_number ON chapters (story_id, chapter_number) WHERE deleted_at IS NULL`,
+	`CREATE INDEX IF NOT EXISTS idx_chapters_story_ordering ON chapters (story_id, ordering)`,
+
+	// total_chapters từng bị lệch do tăng/giảm thủ công
+	`UPDATE stories s SET total_chapters = c.total
+	FROM (
+		SELECT st.id, COUNT(ch.id) AS total
+		FROM stories st LEFT JOIN chapters ch ON ch.story_id = st.id AND ch.deleted_at IS NULL
+		GROUP BY st.id
+	) c
+	WHERE s.id = c.id AND s.total_chapters <> c.total`,
+}
+
+// SetupChapterOrdering - Backfill ordering, gỡ số chapter trùng, tạo unique index theo truyện
+// Chạy sau AutoMigrate, an toàn khi chạy lại nhiều lần
+func SetupChapterOrdering(db *gorm.DB) error {
+	for _, stmt := range chapterOrderingStatements {
+		if err := db.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("setup chapter ordering: %w", err)
+		}
+	}
+	log.Println("Thứ tự chapter đã được chuẩn hóa")
+	return nil
+}
@@ -0,0 +1,82 @@
+package database
+
+import (
+	"fmt"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// SearchConfig - Tên text search configuration dùng cho tìm kiếm truyện
+// "simple" + unaccent: không stem (tiếng Việt không có stemmer), bỏ dấu để "tham tu" khớp "Thám Tử"
+const SearchConfig = "vietnamese_unaccent"
+
+// storySearchStatements - Các câu lệnh tạo full-text search index cho stories (idempotent)
+var storySearchStatements = []string{
+	`CREATE EXTENSION IF NOT EXISTS unaccent`,
+
+	`DO $$
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM pg_ts_config WHERE cfgname = '` + SearchConfig + `') THEN
+			CREATE TEXT SEARCH CONFIGURATION ` + SearchConfig + ` (COPY = simple);
+			ALTER TEXT SEARCH CONFIGURATION ` + SearchConfig + `
+				ALTER MAPPING FOR hword, hword_part, word WITH unaccent, simple;
+		END IF;
+	END
+	$$`,
+
+	`ALTER TABLE stories ADD COLUMN IF NOT EXISTS search_vector tsvector`,
+
+	// Weight: A = tên truyện/tên gốc/tên phụ, B = tác giả/họa sĩ/dịch giả, C = mô tả
+	`CREATE OR REPLACE FUNCTION stories_search_vector_update() RETURNS trigger AS $$
+	BEGIN
+		NEW.search_vector :=
+			setweight(to_tsvector('` + SearchConfig + `', coalesce(NEW.title, '')), 'A') ||
+			setweight(to_tsvector('` + SearchConfig + `', coalesce(NEW.original_title, '')), 'A') ||
+			setweight(to_tsvector('` + SearchConfig + `', coalesce(
+				CASE WHEN jsonb_typeof(NEW.alt_titles) = 'array'
+					THEN (SELECT string_agg(t, ' ') FROM jsonb_array_elements_text(NEW.alt_titles) AS t)
+				END, '')), 'A') ||
+			setweight(to_tsvector('` + SearchConfig + `', coalesce(NEW.author_name, '')), 'B') ||
+			setweight(to_tsvector('` + SearchConfig + `', coalesce(NEW.artist_name, '')), 'B') ||
+			setweight(to_tsvector('` + SearchConfig + `', coalesce(NEW.translator, '')), 'B') ||
+			setweight(to_tsvector('` + SearchConfig + `', coalesce(NEW.description, '')), 'C');
+		RETURN NEW;
+	END
+	$$ LANGUAGE plpgsql`,
+
+	`DROP TRIGGER IF EXISTS stories_search_vector_trigger ON stories`,
+
+	`CREATE TRIGGER stories_search_vector_trigger
+		BEFORE INSERT OR UPDATE OF title, original_title, alt_titles, author_name, artist_name, translator, description
+		ON stories FOR EACH ROW EXECUTE FUNCTION stories_search_vector_update()`,
+
+	// Backfill cho các truyện đã có trước khi thêm trigger
+	`UPDATE stories SET title = title WHERE search_vector IS NULL`,
+
+	`CREATE INDEX IF NOT EXISTS idx_stories_search_vector ON stories USING GIN (search_vector)`,
+
+	// Trigram + prefix index cho gợi ý tìm kiếm (search-as-you-type)
+	// unaccent() không IMMUTABLE nên cần wrapper để dùng trong index expression
+	`CREATE EXTENSION IF NOT EXISTS pg_trgm`,
+
+	`CREATE OR REPLACE FUNCTION f_unaccent(text) RETURNS text AS $$
+		SELECT public.unaccent('public.unaccent', $1)
+	$$ LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT`,
+
+	`CREATE INDEX IF NOT EXISTS idx_stories_title_trgm ON stories USING GIN (f_unaccent(lower(title)) gin_trgm_ops)`,
+	`CREATE INDEX IF NOT EXISTS idx_stories_author_trgm ON stories USING GIN (f_unaccent(lower(author_name)) gin_trgm_ops)`,
+	`CREATE INDEX IF NOT EXISTS idx_genres_name_trgm ON genres USING GIN (f_unaccent(lower(name)) gin_trgm_ops)`,
+}
+
+// SetupStorySearch - Tạo extension, config, trigger và GIN index cho tìm kiếm truyện và gợi ý
+// Chạy sau AutoMigrate, an toàn khi chạy lại nhiều lần
+func SetupStorySearch(db *gorm.DB) error {
+	for _, stmt := range storySearchStatements {
+		if err := db.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("setup story search: %w", err)
+		}
+	}
+	log.Println("Full-text search cho stories đã sẵn sàng")
+	return nil
+}
@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+
+	"nekozanedex/internal/services"
+	"nekozanedex/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type AnalyticsHandler struct {
+	analyticsService services.AnalyticsService
+}
+
+func NewAnalyticsHandler(analyticsService services.AnalyticsService) *AnalyticsHandler {
+	return &AnalyticsHandler{analyticsService: analyticsService}
+}
+
+// parseAnalyticsRange - Đọc from/to (YYYY-MM-DD), trả false nếu đã ghi response lỗi
+func (h *AnalyticsHandler) parseAnalyticsRange(c *gin.Context) (time.Time, time.Time, bool) {
+	var from, to *time.Time
+	for key, target := range map[string]**time.Time{"from": &from, "to": &to} {
+		value := c.Query(key)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.ParseInLocation("2006-01-02", value, time.Local)
+		if err != nil {
+			response.BadRequest(c, "Định dạng ngày không hợp lệ (YYYY-MM-DD)")
+			return time.Time{}, time.Time{}, false
+		}
+		*target = &parsed
+	}
+
+	start, end, err := h.analyticsService.ResolveRange(from, to)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return time.Time{}, time.Time{}, false
+	}
+	return start, end, true
+}
+
+// GetOverview godoc
+// @Summary Tổng quan lượt xem toàn site (Admin)
+// @Tags Admin Analytics
+// @Produce json
+// @Param from query string false "Từ ngày (YYYY-MM-DD), mặc định 30 ngày trước"
+// @Param to query string false "Đến ngày (YYYY-MM-DD), mặc định hôm nay"
+// @Success 200 {object} response.Response
+// @Router /api/admin/analytics/overview [get]
+func (h *AnalyticsHandler) GetOverview(c *gin.Context) {
+	from, to, ok := h.parseAnalyticsRange(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.analyticsService.GetOverview(from, to)
+	if err != nil {
+		response.InternalServerError(c, "Không thể lấy thống kê")
+		return
+	}
+
+	response.Oke(c, report)
+}
+
+// GetStoryAnalytics godoc
+// @Summary Lượt xem theo ngày của truyện (Admin)
+// @Tags Admin Analytics
+// @Produce json
+// @Param id path string true "Story ID"
+// @Param from query string false "Từ ngày (YYYY-MM-DD)"
+// @Param to query string false "Đến ngày (YYYY-MM-DD)"
+// @Success 200 {object} response.Response
+// @Router /api/admin/analytics/stories/{id} [get]
+func (h *AnalyticsHandler) GetStoryAnalytics(c *gin.Context) {
+	storyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "ID truyện không hợp lệ")
+		return
+	}
+	from, to, ok := h.parseAnalyticsRange(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.analyticsService.GetStoryAnalytics(storyID, from, to)
+	if err != nil {
+		response.NotFound(c, err.Error())
+		return
+	}
+
+	response.Oke(c, report)
+}
+
+// GetChapterAnalytics godoc
+// @Summary Lượt đọc theo ngày của chapter (Admin)
+// @Tags Admin Analytics
+// @Produce json
+// @Param id path string true "Chapter ID"
+// @Param from query string false "Từ ngày (YYYY-MM-DD)"
+// @Param to query string false "Đến ngày (YYYY-MM-DD)"
+// @Success 200 {object} response.Response
+// @Router /api/admin/analytics/chapters/{id} [get]
+func (h *AnalyticsHandler) GetChapterAnalytics(c *gin.Context) {
+	chapterID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "ID chapter không hợp lệ")
+		return
+	}
+	from, to, ok := h.parseAnalyticsRange(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.analyticsService.GetChapterAnalytics(chapterID, from, to)
+	if err != nil {
+		response.NotFound(c, err.Error())
+		return
+	}
+
+	response.Oke(c, report)
+}
+
+// GetTopReferrers godoc
+// @Summary Nguồn truy cập nhiều nhất (Admin)
+// @Tags Admin Analytics
+// @Produce json
+// @Param story_id query string false "Lọc theo truyện"
+// @Param from query string false "Từ ngày (YYYY-MM-DD)"
+// @Param to query string false "Đến ngày (YYYY-MM-DD)"
+// @Param limit query int false "Số nguồn" default(20)
+// @Success 200 {object} response.Response
+// @Router /api/admin/analytics/referrers [get]
+func (h *AnalyticsHandler) GetTopReferrers(c *gin.Context) {
+	var storyID *uuid.UUID
+	if idStr := c.Query("story_id"); idStr != "" {
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			response.BadRequest(c, "ID truyện không hợp lệ")
+			return
+		}
+		storyID = &id
+	}
+	from, to, ok := h.parseAnalyticsRange(c)
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	referrers, err := h.analyticsService.GetTopReferrers(storyID, from, to, limit)
+	if err != nil {
+		response.InternalServerError(c, "Không thể lấy nguồn truy cập")
+		return
+	}
+
+	response.Oke(c, referrers)
+}
+
+// GetTopStories godoc
+// @Summary Truyện nhiều lượt xem nhất trong khoảng (Admin)
+// @Tags Admin Analytics
+// @Produce json
+// @Param from query string false "Từ ngày (YYYY-MM-DD)"
+// @Param to query string false "Đến ngày (YYYY-MM-DD)"
+// @Param limit query int false "Số truyện" default(20)
+// @Success 200 {object} response.Response
+// @Router /api/admin/analytics/top-stories [get]
+func (h *AnalyticsHandler) GetTopStories(c *gin.Context) {
+	from, to, ok := h.parseAnalyticsRange(c)
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	stories, err := h.analyticsService.GetTopStories(from, to, limit)
+	if err != nil {
+		response.InternalServerError(c, "Không thể lấy thống kê truyện")
+		return
+	}
+
+	response.Oke(c, stories)
+}
+
+// GetStoryFunnel godoc
+// @Summary Funnel bỏ đọc theo chapter của truyện (Admin)
+// @Description Số người đọc đã đọc tới từng chapter (tính sẵn định kỳ), kèm retention và các chapter bỏ đọc nhiều nhất
+// @Tags Admin Analytics
+// @Produce json
+// @Param id path string true "Story ID"
+// @Success 200 {object} response.Response
+// @Router /api/admin/stories/{id}/funnel [get]
+func (h *AnalyticsHandler) GetStoryFunnel(c *gin.Context) {
+	storyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "ID truyện không hợp lệ")
+		return
+	}
+
+	funnel, err := h.analyticsService.GetStoryFunnel(storyID)
+	if err != nil {
+		response.NotFound(c, err.Error())
+		return
+	}
+
+	response.Oke(c, funnel)
+}
@@ -116,10 +116,11 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	response.Oke(c, gin.H{
 		"access_token": tokenPair.AccessToken,
 		"user": gin.H{
-			"id":       user.ID,
-			"email":    user.Email,
-			"username": user.Username,
-			"role":     user.Role,
+			"id":          user.ID,
+			"email":       user.Email,
+			"username":    user.Username,
+			"role":        user.Role,
+			"permissions": h.authService.GetPermissions(user.Role),
 		},
 	})
 }
@@ -181,12 +182,13 @@ func (h *AuthHandler) GetProfile(c *gin.Context) {
 	}
 
 	response.Oke(c, gin.H{
-		"id":         user.ID,
-		"email":      user.Email,
-		"username":   user.Username,
-		"role":       user.Role,
-		"avatar_url": user.AvatarURL,
-		"created_at": user.CreatedAt,
+		"id":          user.ID,
+		"email":       user.Email,
+		"username":    user.Username,
+		"role":        user.Role,
+		"permissions": c.GetStringSlice("permissions"), // Quyền trong access token hiện tại
+		"avatar_url":  user.AvatarURL,
+		"created_at":  user.CreatedAt,
 	})
 }
 
@@ -2,10 +2,12 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"strconv"
 	"time"
 
 	"nekozanedex/internal/models"
+	"nekozanedex/internal/repositories"
 	"nekozanedex/internal/services"
 	"nekozanedex/pkg/response"
 
@@ -20,24 +22,85 @@ type ChapterHandler struct {
 func NewChapterHandler(chapterService services.ChapterService) *ChapterHandler {
 	return &ChapterHandler{chapterService: chapterService}
 }
+
 type CreateChapterRequest struct {
-	Title        string   `json:"title" binding:"required"`
-	ChapterLabel *string  `json:"chapter_label"` 
-	ChapterType  string   `json:"chapter_type"`  
-	Ordering     *float64 `json:"ordering"`      
-	Content      string   `json:"content"`       
-	Images       []string `json:"images"`        
+	Title          string               `json:"title" binding:"required"`
+	ChapterLabel   *string              `json:"chapter_label"`
+	ChapterType    string               `json:"chapter_type"`
+	Ordering       *float64             `json:"ordering"`
+	Content        string               `json:"content"`
+	ContentFormat  string               `json:"content_format"` // plain (mặc định), markdown, html; bắt buộc khi sửa nội dung
+	Notes          []models.ChapterNote `json:"notes"`          // Ghi chú gắn bằng [^id] trong nội dung, bỏ trống để giữ nguyên
+	Images         []string             `json:"images"`
+	AfterChapterID *string              `json:"after_chapter_id"` // Chèn ngay sau chapter này (chỉ khi tạo mới)
+	VolumeID       *string              `json:"volume_id"`        // Tập chứa chapter, bỏ trống để giữ nguyên
+}
+
+// MoveChapterRequest - Di chuyển chapter, chỉ gửi một trong hai
+type MoveChapterRequest struct {
+	BeforeID *string `json:"before_id"`
+	AfterID  *string `json:"after_id"`
+}
+
+// toChapter - Chuyển request thành model, ảnh được lưu dạng JSON
+func (req CreateChapterRequest) toChapter() (*models.Chapter, error) {
+	var imagesJSON []byte
+	if len(req.Images) > 0 {
+		var err error
+		imagesJSON, err = json.Marshal(req.Images)
+		if err != nil {
+			return nil, errors.New("Không thể xử lý danh sách ảnh")
+		}
+	}
+	volumeID, err := parseOptionalUUID(req.VolumeID)
+	if err != nil {
+		return nil, errors.New("volume_id không hợp lệ")
+	}
+	var notesJSON []byte
+	if req.Notes != nil {
+		if notesJSON, err = json.Marshal(req.Notes); err != nil {
+			return nil, errors.New("Không thể xử lý danh sách ghi chú")
+		}
+	}
+
+	chapter := &models.Chapter{
+		Title:         req.Title,
+		ChapterLabel:  req.ChapterLabel,
+		ChapterType:   req.ChapterType,
+		Content:       req.Content,
+		ContentFormat: req.ContentFormat,
+		Notes:         notesJSON,
+		Images:        imagesJSON,
+		PageCount:     len(req.Images),
+		VolumeID:      volumeID,
+	}
+	if req.Ordering != nil {
+		chapter.Ordering = *req.Ordering
+	}
+	return chapter, nil
+}
+
+// currentUserID - User đang đăng nhập (người sửa chapter), nil nếu không có
+func currentUserID(c *gin.Context) *uuid.UUID {
+	if uid, exists := c.Get("user_id"); exists {
+		if id, ok := uid.(uuid.UUID); ok {
+			return &id
+		}
+	}
+	return nil
 }
 
 type ScheduleChapterRequest struct {
-	ScheduledAt string `json:"scheduled_at" binding:"required"` 
+	ScheduledAt string `json:"scheduled_at" binding:"required"`
 }
 
 type BulkImportRequest struct {
 	Chapters []struct {
-		Title   string   `json:"title" binding:"required"`
-		Content string   `json:"content"`
-		Images  []string `json:"images"`
+		Title         string               `json:"title" binding:"required"`
+		Content       string               `json:"content"`
+		ContentFormat string               `json:"content_format"`
+		Notes         []models.ChapterNote `json:"notes"`
+		Images        []string             `json:"images"`
 	} `json:"chapters" binding:"required,min=1"`
 }
 
@@ -59,15 +122,48 @@ func (h *ChapterHandler) GetChapterByNumber(c *gin.Context) {
 		return
 	}
 
-	chapter, err := h.chapterService.GetChapterByNumber(storySlug, chapterNumber)
+	chapter, err := h.chapterService.GetChapterByNumber(storySlug, chapterNumber, showMatureContent(c))
 	if err != nil {
+		if respondContentWarning(c, err) {
+			return
+		}
 		response.NotFound(c, err.Error())
 		return
 	}
 
+	v := viewerFromContext(c)
+	_ = h.chapterService.RecordChapterView(chapter, v.UserID, v.IP, v.Referrer)
+
 	response.Oke(c, chapter)
 }
 
+// GetChapterNavigation godoc
+// @Summary Chapter trước/sau theo thứ tự đọc, kèm tiến độ đọc nếu đã đăng nhập
+// @Tags Chapters
+// @Produce json
+// @Param slug path string true "Story Slug"
+// @Param number path int true "Chapter Number"
+// @Success 200 {object} response.Response
+// @Router /api/stories/{slug}/chapters/{number}/navigation [get]
+func (h *ChapterHandler) GetChapterNavigation(c *gin.Context) {
+	chapterNumber, err := strconv.Atoi(c.Param("number"))
+	if err != nil {
+		response.BadRequest(c, "Số chapter không hợp lệ")
+		return
+	}
+
+	nav, err := h.chapterService.GetChapterNavigation(c.Param("slug"), chapterNumber, currentUserID(c), showMatureContent(c))
+	if err != nil {
+		if respondContentWarning(c, err) {
+			return
+		}
+		response.NotFound(c, err.Error())
+		return
+	}
+
+	response.Oke(c, nav)
+}
+
 // GetChaptersByStory godoc
 // @Summary Lấy danh sách chapters của truyện (có phân trang)
 // @Tags Chapters
@@ -75,17 +171,53 @@ func (h *ChapterHandler) GetChapterByNumber(c *gin.Context) {
 // @Param slug path string true "Story Slug"
 // @Param page query int false "Page number (default: 1)"
 // @Param limit query int false "Items per page (default: 100, max: 100)"
+// @Param cursor query string false "Cursor trang sau (có tham số này thì bỏ qua page)"
+// @Param group_by query string false "volume: trả toàn bộ chapters nhóm theo tập"
 // @Success 200 {object} response.Response
 // @Router /api/stories/{slug}/chapters [get]
 func (h *ChapterHandler) GetChaptersByStory(c *gin.Context) {
 	storySlug := c.Param("slug")
-	
+
+	if c.Query("group_by") == "volume" {
+		volumes, err := h.chapterService.GetChaptersByVolume(storySlug, showMatureContent(c))
+		if err != nil {
+			if respondContentWarning(c, err) {
+				return
+			}
+			response.NotFound(c, err.Error())
+			return
+		}
+		response.Oke(c, gin.H{"volumes": volumes})
+		return
+	}
+
 	// Parse pagination params
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
 
-	chapters, total, err := h.chapterService.GetChaptersByStoryPaginated(storySlug, page, limit)
+	if cursor, ok := c.GetQuery("cursor"); ok {
+		limit = cursorLimit(limit, 100)
+		chapters, next, err := h.chapterService.GetChaptersByStoryCursor(storySlug, cursor, limit, showMatureContent(c))
+		if err != nil {
+			if errors.Is(err, repositories.ErrInvalidCursor) {
+				response.BadRequest(c, err.Error())
+				return
+			}
+			if respondContentWarning(c, err) {
+				return
+			}
+			response.NotFound(c, err.Error())
+			return
+		}
+		response.CursorPaginatedResponse(c, chapters, limit, next)
+		return
+	}
+
+	chapters, total, err := h.chapterService.GetChaptersByStoryPaginated(storySlug, page, limit, showMatureContent(c))
 	if err != nil {
+		if respondContentWarning(c, err) {
+			return
+		}
 		response.NotFound(c, err.Error())
 		return
 	}
@@ -154,25 +286,24 @@ func (h *ChapterHandler) CreateChapter(c *gin.Context) {
 		return
 	}
 
-	var imagesJSON []byte
-	if len(req.Images) > 0 {
-		imagesJSON, err = json.Marshal(req.Images)
+	chapter, err := req.toChapter()
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	if req.AfterChapterID != nil {
+		afterID, err := uuid.Parse(*req.AfterChapterID)
 		if err != nil {
-			response.BadRequest(c, "Không thể xử lý danh sách ảnh")
+			response.BadRequest(c, "after_chapter_id không hợp lệ")
 			return
 		}
-	}
-
-	chapter := &models.Chapter{
-		Title:        req.Title,
-		ChapterLabel: req.ChapterLabel,
-		ChapterType:  req.ChapterType,
-		Content:      req.Content,
-		Images:       imagesJSON,
-		PageCount:    len(req.Images),
-	}
-	if req.Ordering != nil {
-		chapter.Ordering = *req.Ordering
+		if err := h.chapterService.InsertChapterAfter(storyID, afterID, chapter); err != nil {
+			response.BadRequest(c, err.Error())
+			return
+		}
+		response.Created(c, chapter)
+		return
 	}
 
 	if err := h.chapterService.CreateChapter(storyID, chapter); err != nil {
@@ -207,28 +338,13 @@ func (h *ChapterHandler) UpdateChapter(c *gin.Context) {
 		return
 	}
 
-	var imagesJSON []byte
-	if len(req.Images) > 0 {
-		imagesJSON, err = json.Marshal(req.Images)
-		if err != nil {
-			response.BadRequest(c, "Không thể xử lý danh sách ảnh")
-			return
-		}
-	}
-
-	chapter := &models.Chapter{
-		Title:        req.Title,
-		ChapterLabel: req.ChapterLabel,
-		ChapterType:  req.ChapterType,
-		Content:      req.Content,
-		Images:       imagesJSON,
-		PageCount:    len(req.Images),
-	}
-	if req.Ordering != nil {
-		chapter.Ordering = *req.Ordering
+	chapter, err := req.toChapter()
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
 	}
 
-	if err := h.chapterService.UpdateChapter(id, chapter); err != nil {
+	if err := h.chapterService.UpdateChapter(id, chapter, currentUserID(c)); err != nil {
 		response.BadRequest(c, err.Error())
 		return
 	}
@@ -348,15 +464,20 @@ func (h *ChapterHandler) BulkImportChapters(c *gin.Context) {
 
 	chapters := make([]models.Chapter, len(req.Chapters))
 	for i, ch := range req.Chapters {
-		var imagesJSON []byte
+		var imagesJSON, notesJSON []byte
 		if len(ch.Images) > 0 {
 			imagesJSON, _ = json.Marshal(ch.Images)
 		}
+		if ch.Notes != nil {
+			notesJSON, _ = json.Marshal(ch.Notes)
+		}
 		chapters[i] = models.Chapter{
-			Title:     ch.Title,
-			Content:   ch.Content,
-			Images:    imagesJSON,
-			PageCount: len(ch.Images),
+			Title:         ch.Title,
+			Content:       ch.Content,
+			ContentFormat: ch.ContentFormat,
+			Notes:         notesJSON,
+			Images:        imagesJSON,
+			PageCount:     len(ch.Images),
 		}
 	}
 
@@ -395,3 +516,174 @@ func (h *ChapterHandler) GetChapterByID(c *gin.Context) {
 
 	response.Oke(c, chapter)
 }
+
+// MoveChapter godoc
+// @Summary Di chuyển chapter tới trước/sau chapter khác (Admin)
+// @Tags Admin - Chapters
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Chapter ID"
+// @Param body body MoveChapterRequest true "before_id hoặc after_id"
+// @Success 200 {object} response.Response
+// @Router /api/admin/chapters/{id}/move [post]
+func (h *ChapterHandler) MoveChapter(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "ID không hợp lệ")
+		return
+	}
+
+	var req MoveChapterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Dữ liệu không hợp lệ")
+		return
+	}
+
+	beforeID, err := parseOptionalUUID(req.BeforeID)
+	if err != nil {
+		response.BadRequest(c, "before_id không hợp lệ")
+		return
+	}
+	afterID, err := parseOptionalUUID(req.AfterID)
+	if err != nil {
+		response.BadRequest(c, "after_id không hợp lệ")
+		return
+	}
+
+	chapter, err := h.chapterService.MoveChapter(id, beforeID, afterID)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	response.Oke(c, chapter)
+}
+
+// parseOptionalUUID - nil hoặc chuỗi rỗng -> nil
+func parseOptionalUUID(value *string) (*uuid.UUID, error) {
+	if value == nil || *value == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(*value)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+// GetChapterRevisions godoc
+// @Summary Lịch sử revision của chapter (Admin)
+// @Tags Admin - Chapters
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Chapter ID"
+// @Success 200 {object} response.Response
+// @Router /api/admin/chapters/{id}/revisions [get]
+func (h *ChapterHandler) GetChapterRevisions(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "ID không hợp lệ")
+		return
+	}
+
+	revisions, err := h.chapterService.GetRevisions(id)
+	if err != nil {
+		response.NotFound(c, err.Error())
+		return
+	}
+
+	response.Oke(c, revisions)
+}
+
+// GetChapterRevision godoc
+// @Summary Nội dung một revision của chapter (Admin)
+// @Tags Admin - Chapters
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Chapter ID"
+// @Param number path int true "Revision Number"
+// @Success 200 {object} response.Response
+// @Router /api/admin/chapters/{id}/revisions/{number} [get]
+func (h *ChapterHandler) GetChapterRevision(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "ID không hợp lệ")
+		return
+	}
+	number, err := strconv.Atoi(c.Param("number"))
+	if err != nil {
+		response.BadRequest(c, "Số revision không hợp lệ")
+		return
+	}
+
+	revision, err := h.chapterService.GetRevision(id, number)
+	if err != nil {
+		response.NotFound(c, err.Error())
+		return
+	}
+
+	response.Oke(c, revision)
+}
+
+// DiffChapterRevisions godoc
+// @Summary Diff theo dòng giữa hai revision (Admin)
+// @Tags Admin - Chapters
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Chapter ID"
+// @Param from query int true "Revision cũ"
+// @Param to query int true "Revision mới"
+// @Success 200 {object} response.Response
+// @Router /api/admin/chapters/{id}/revisions/diff [get]
+func (h *ChapterHandler) DiffChapterRevisions(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "ID không hợp lệ")
+		return
+	}
+	from, errFrom := strconv.Atoi(c.Query("from"))
+	to, errTo := strconv.Atoi(c.Query("to"))
+	if errFrom != nil || errTo != nil {
+		response.BadRequest(c, "Cần tham số from và to là số revision")
+		return
+	}
+
+	diff, err := h.chapterService.DiffRevisions(id, from, to)
+	if err != nil {
+		response.NotFound(c, err.Error())
+		return
+	}
+
+	response.Oke(c, diff)
+}
+
+// RestoreChapterRevision godoc
+// @Summary Khôi phục revision cũ thành revision mới (Admin)
+// @Tags Admin - Chapters
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Chapter ID"
+// @Param number path int true "Revision Number"
+// @Success 200 {object} response.Response
+// @Router /api/admin/chapters/{id}/revisions/{number}/restore [post]
+func (h *ChapterHandler) RestoreChapterRevision(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "ID không hợp lệ")
+		return
+	}
+	number, err := strconv.Atoi(c.Param("number"))
+	if err != nil {
+		response.BadRequest(c, "Số revision không hợp lệ")
+		return
+	}
+
+	revision, err := h.chapterService.RestoreRevision(id, number, currentUserID(c))
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	response.Oke(c, revision)
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"nekozanedex/internal/centrifugo"
+	"nekozanedex/internal/middleware"
 	"nekozanedex/internal/models"
 	"nekozanedex/internal/repositories"
 	"nekozanedex/internal/services"
@@ -94,6 +95,7 @@ func parseTagNames(content string) []string {
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Items per page" default(20)
 // @Param sort query string false "Sort by: newest, oldest, top" default(newest)
+// @Param cursor query string false "Cursor trang sau (có tham số này thì bỏ qua page)"
 // @Success 200 {object} response.Pagination
 // @Router /api/stories/{storyId}/comments [get]
 func (h *CommentHandler) GetCommentsByStory(c *gin.Context) {
@@ -108,8 +110,25 @@ func (h *CommentHandler) GetCommentsByStory(c *gin.Context) {
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 	sortBy := c.DefaultQuery("sort", "newest")
 
-	comments, total, err := h.commentService.GetCommentsByStory(storyID, page, limit, sortBy)
+	if cursor, ok := c.GetQuery("cursor"); ok {
+		limit = cursorLimit(limit, 20)
+		comments, next, err := h.commentService.GetCommentsByStoryCursor(storyID, cursor, limit, sortBy, showMatureContent(c))
+		if err != nil {
+			if respondContentWarning(c, err) {
+				return
+			}
+			respondListError(c, err, "Không thể lấy comments")
+			return
+		}
+		response.CursorPaginatedResponse(c, h.enrichCommentsWithLikeStatus(c, comments), limit, next)
+		return
+	}
+
+	comments, total, err := h.commentService.GetCommentsByStory(storyID, page, limit, sortBy, showMatureContent(c))
 	if err != nil {
+		if respondContentWarning(c, err) {
+			return
+		}
 		response.InternalServerError(c, "Không thể lấy comments")
 		return
 	}
@@ -126,6 +145,7 @@ func (h *CommentHandler) GetCommentsByStory(c *gin.Context) {
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Items per page" default(20)
 // @Param sort query string false "Sort by: newest, oldest, top" default(newest)
+// @Param cursor query string false "Cursor trang sau (có tham số này thì bỏ qua page)"
 // @Success 200 {object} response.Pagination
 // @Router /api/chapters/{chapterId}/comments [get]
 func (h *CommentHandler) GetCommentsByChapter(c *gin.Context) {
@@ -140,8 +160,25 @@ func (h *CommentHandler) GetCommentsByChapter(c *gin.Context) {
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 	sortBy := c.DefaultQuery("sort", "newest")
 
-	comments, total, err := h.commentService.GetCommentsByChapter(chapterID, page, limit, sortBy)
+	if cursor, ok := c.GetQuery("cursor"); ok {
+		limit = cursorLimit(limit, 20)
+		comments, next, err := h.commentService.GetCommentsByChapterCursor(chapterID, cursor, limit, sortBy, showMatureContent(c))
+		if err != nil {
+			if respondContentWarning(c, err) {
+				return
+			}
+			respondListError(c, err, "Không thể lấy comments")
+			return
+		}
+		response.CursorPaginatedResponse(c, h.enrichCommentsWithLikeStatus(c, comments), limit, next)
+		return
+	}
+
+	comments, total, err := h.commentService.GetCommentsByChapter(chapterID, page, limit, sortBy, showMatureContent(c))
 	if err != nil {
+		if respondContentWarning(c, err) {
+			return
+		}
 		response.InternalServerError(c, "Không thể lấy comments")
 		return
 	}
@@ -188,8 +225,11 @@ func (h *CommentHandler) CreateComment(c *gin.Context) {
 		}
 	}
 
-	comment, err := h.commentService.CreateComment(userID.(uuid.UUID), storyID, chapterID, req.Content)
+	comment, err := h.commentService.CreateComment(userID.(uuid.UUID), storyID, chapterID, req.Content, showMatureContent(c))
 	if err != nil {
+		if respondContentWarning(c, err) {
+			return
+		}
 		response.BadRequest(c, err.Error())
 		return
 	}
@@ -246,8 +286,11 @@ func (h *CommentHandler) ReplyComment(c *gin.Context) {
 		return
 	}
 
-	reply, err := h.commentService.ReplyComment(userID.(uuid.UUID), parentID, req.Content)
+	reply, err := h.commentService.ReplyComment(userID.(uuid.UUID), parentID, req.Content, showMatureContent(c))
 	if err != nil {
+		if respondContentWarning(c, err) {
+			return
+		}
 		response.BadRequest(c, err.Error())
 		return
 	}
@@ -378,8 +421,8 @@ func (h *CommentHandler) DeleteComment(c *gin.Context) {
 		return
 	}
 
-	role, _ := c.Get("role")
-	isAdmin := role == "admin"
+	// Moderator được xóa comment của người khác
+	canModerate := middleware.HasPermission(c, models.PermCommentsModerate)
 
 	commentIDStr := c.Param("commentId")
 	commentID, err := uuid.Parse(commentIDStr)
@@ -388,7 +431,7 @@ func (h *CommentHandler) DeleteComment(c *gin.Context) {
 		return
 	}
 
-	if err := h.commentService.DeleteComment(userID.(uuid.UUID), commentID, isAdmin); err != nil {
+	if err := h.commentService.DeleteComment(userID.(uuid.UUID), commentID, canModerate); err != nil {
 		response.BadRequest(c, err.Error())
 		return
 	}
@@ -533,7 +576,7 @@ func (h *CommentHandler) ToggleLike(c *gin.Context) {
 }
 
 // TogglePin godoc
-// @Summary Ghim/Bỏ ghim bình luận (Admin only)
+// @Summary Ghim/Bỏ ghim bình luận (Moderator: comments.moderate)
 // @Tags Comments
 // @Security BearerAuth
 // @Produce json
@@ -541,9 +584,7 @@ func (h *CommentHandler) ToggleLike(c *gin.Context) {
 // @Success 200 {object} response.Response
 // @Router /api/comments/{commentId}/pin [post]
 func (h *CommentHandler) TogglePin(c *gin.Context) {
-	// Check admin permission
-	role, exists := c.Get("role")
-	if !exists || role.(string) != "admin" {
+	if !middleware.HasPermission(c, models.PermCommentsModerate) {
 		response.Forbidden(c, "Bạn không có quyền thực hiện hành động này")
 		return
 	}
@@ -613,7 +654,7 @@ func (h *CommentHandler) ReportComment(c *gin.Context) {
 }
 
 // GetReports godoc
-// @Summary Lấy danh sách báo cáo (Admin only)
+// @Summary Lấy danh sách báo cáo (Moderator: comments.moderate)
 // @Tags Admin Comments
 // @Security BearerAuth
 // @Produce json
@@ -623,9 +664,7 @@ func (h *CommentHandler) ReportComment(c *gin.Context) {
 // @Success 200 {object} response.Pagination
 // @Router /api/admin/comments/reports [get]
 func (h *CommentHandler) GetReports(c *gin.Context) {
-	// Check admin role
-	role, _ := c.Get("role")
-	if role != "admin" {
+	if !middleware.HasPermission(c, models.PermCommentsModerate) {
 		response.Forbidden(c, "Bạn không có quyền thực hiện hành động này")
 		return
 	}
@@ -644,7 +683,7 @@ func (h *CommentHandler) GetReports(c *gin.Context) {
 }
 
 // ResolveReport godoc
-// @Summary Xử lý báo cáo (Admin only)
+// @Summary Xử lý báo cáo (Moderator: comments.moderate)
 // @Tags Admin Comments
 // @Security BearerAuth
 // @Accept json
@@ -654,9 +693,7 @@ func (h *CommentHandler) GetReports(c *gin.Context) {
 // @Success 200 {object} response.Response
 // @Router /api/admin/comments/reports/{reportId} [put]
 func (h *CommentHandler) ResolveReport(c *gin.Context) {
-	// Check admin role
-	role, _ := c.Get("role")
-	if role != "admin" {
+	if !middleware.HasPermission(c, models.PermCommentsModerate) {
 		response.Forbidden(c, "Bạn không có quyền thực hiện hành động này")
 		return
 	}
@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"errors"
+
+	"nekozanedex/internal/services"
+	"nekozanedex/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// showMatureContent - Người xem đã bật hiển thị nội dung người lớn (do MatureContentMiddleware gắn)
+func showMatureContent(c *gin.Context) bool {
+	return c.GetBool("show_mature")
+}
+
+// respondContentWarning - Trả 403 CONTENT_WARNING nếu err là ContentWarningError
+func respondContentWarning(c *gin.Context, err error) bool {
+	var warning *services.ContentWarningError
+	if !errors.As(err, &warning) {
+		return false
+	}
+	response.ContentWarning(c, warning.Error(), warning)
+	return true
+}
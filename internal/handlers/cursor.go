@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"errors"
+
+	"nekozanedex/internal/repositories"
+	"nekozanedex/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// cursorLimit - Giới hạn số phần tử mỗi trang ở chế độ cursor
+func cursorLimit(limit, fallback int) int {
+	if limit < 1 || limit > 100 {
+		return fallback
+	}
+	return limit
+}
+
+// respondListError - Cursor sai trả 400, lỗi khác trả 500 với message cho trước
+func respondListError(c *gin.Context, err error, message string) {
+	if errors.Is(err, repositories.ErrInvalidCursor) {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	response.InternalServerError(c, message)
+}
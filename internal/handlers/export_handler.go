@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"nekozanedex/internal/services"
+	"nekozanedex/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ExportHandler struct {
+	exportService services.ExportService
+}
+
+func NewExportHandler(exportService services.ExportService) *ExportHandler {
+	return &ExportHandler{exportService: exportService}
+}
+
+// ExportEPUB godoc
+// @Summary Tải truyện chữ dạng EPUB 3 (chỉ chapter đã publish)
+// @Description Giới hạn 30 lượt xuất/giờ cho mỗi user (chưa đăng nhập thì theo IP).
+// @Tags Export
+// @Produce application/epub+zip
+// @Param slug path string true "Story Slug"
+// @Param from query int false "Số chapter bắt đầu"
+// @Param to query int false "Số chapter kết thúc"
+// @Success 200 {file} file
+// @Success 304 "Không đổi so với If-None-Match"
+// @Failure 429 {object} response.Response "Vượt giới hạn lượt xuất"
+// @Router /api/stories/{slug}/export.epub [get]
+func (h *ExportHandler) ExportEPUB(c *gin.Context) {
+	from, ok := rangeQuery(c, "from")
+	if !ok {
+		return
+	}
+	to, ok := rangeQuery(c, "to")
+	if !ok {
+		return
+	}
+
+	file, err := h.exportService.ExportEPUB(c.Param("slug"), from, to, showMatureContent(c))
+	if err != nil {
+		if respondContentWarning(c, err) {
+			return
+		}
+		response.NotFound(c, err.Error())
+		return
+	}
+
+	serveExportFile(c, file)
+}
+
+// DownloadChapterCBZ godoc
+// @Summary Tải chapter truyện tranh dạng CBZ (kèm ComicInfo.xml) để đọc offline
+// @Description Giới hạn 20 lượt tải/giờ cho mỗi user.
+// @Tags Export
+// @Security BearerAuth
+// @Produce application/vnd.comicbook+zip
+// @Param slug path string true "Story Slug"
+// @Param number path int true "Chapter Number"
+// @Success 200 {file} file
+// @Failure 429 {object} response.Response
+// @Router /api/stories/{slug}/chapters/{number}/download.cbz [get]
+func (h *ExportHandler) DownloadChapterCBZ(c *gin.Context) {
+	number, err := strconv.Atoi(c.Param("number"))
+	if err != nil || number <= 0 {
+		response.BadRequest(c, "Số chapter không hợp lệ")
+		return
+	}
+
+	download, err := h.exportService.PrepareChapterCBZ(c.Param("slug"), number, showMatureContent(c))
+	h.streamCBZ(c, download, err)
+}
+
+// DownloadStoryCBZ godoc
+// @Summary Tải nhiều chapter truyện tranh: file ZIP gồm mỗi chapter một CBZ (tối đa 10 chapter)
+// @Description Giới hạn 20 lượt tải/giờ cho mỗi user, dùng chung với tải từng chapter.
+// @Tags Export
+// @Security BearerAuth
+// @Produce application/zip
+// @Param slug path string true "Story Slug"
+// @Param from query int false "Số chapter bắt đầu"
+// @Param to query int false "Số chapter kết thúc"
+// @Success 200 {file} file
+// @Failure 429 {object} response.Response
+// @Router /api/stories/{slug}/download.cbz [get]
+func (h *ExportHandler) DownloadStoryCBZ(c *gin.Context) {
+	from, ok := rangeQuery(c, "from")
+	if !ok {
+		return
+	}
+	to, ok := rangeQuery(c, "to")
+	if !ok {
+		return
+	}
+
+	download, err := h.exportService.PrepareStoryCBZ(c.Param("slug"), from, to, showMatureContent(c))
+	h.streamCBZ(c, download, err)
+}
+
+// streamCBZ - Ghi CBZ trực tiếp vào response, lỗi sau khi đã gửi header chỉ có thể log lại
+func (h *ExportHandler) streamCBZ(c *gin.Context, download *services.CBZDownload, err error) {
+	if err != nil {
+		if respondContentWarning(c, err) {
+			return
+		}
+		if errors.Is(err, services.ErrTooManyChapters) {
+			response.BadRequest(c, err.Error())
+			return
+		}
+		response.NotFound(c, err.Error())
+		return
+	}
+
+	c.Header("Content-Type", download.ContentType)
+	c.Header("Content-Disposition", `attachment; filename="`+download.Filename+`"`)
+	c.Header("Cache-Control", "private, no-store")
+	c.Status(http.StatusOK)
+
+	if err := h.exportService.WriteCBZ(c.Request.Context(), c.Writer, download); err != nil {
+		log.Printf("[ExportHandler] download %s failed: %v", download.Filename, err)
+		c.Abort()
+	}
+}
+
+// rangeQuery - Số chapter trong query, bỏ trống = 0 (không giới hạn)
+func rangeQuery(c *gin.Context, key string) (int, bool) {
+	value := c.Query(key)
+	if value == "" {
+		return 0, true
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 1 {
+		response.BadRequest(c, key+" không hợp lệ")
+		return 0, false
+	}
+	return number, true
+}
+
+// serveExportFile - Trả file đính kèm, 304 nếu client đã có bản cùng ETag
+func serveExportFile(c *gin.Context, file *services.ExportFile) {
+	c.Header("ETag", file.ETag)
+	c.Header("Last-Modified", file.ModTime.UTC().Format(http.TimeFormat))
+	c.Header("Cache-Control", "private, max-age=0, must-revalidate")
+	if match := c.GetHeader("If-None-Match"); match != "" && match == file.ETag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+file.Filename+`"`)
+	c.Data(http.StatusOK, file.ContentType, file.Data)
+}
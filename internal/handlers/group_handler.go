@@ -0,0 +1,559 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"nekozanedex/internal/services"
+	"nekozanedex/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type GroupHandler struct {
+	groupService services.GroupService
+}
+
+func NewGroupHandler(groupService services.GroupService) *GroupHandler {
+	return &GroupHandler{groupService: groupService}
+}
+
+// GroupRequest - DTO cho tạo/cập nhật nhóm dịch
+type GroupRequest struct {
+	Name        string  `json:"name" binding:"max=100"`
+	Description *string `json:"description"`
+	AvatarURL   *string `json:"avatar_url"`
+	Website     *string `json:"website" binding:"omitempty,max=255"`
+}
+
+// CreateGroupRequest - DTO cho admin tạo nhóm, owner là thành viên đầu tiên
+type CreateGroupRequest struct {
+	GroupRequest
+	OwnerID string `json:"owner_id" binding:"required"`
+}
+
+// GroupMemberRequest - DTO cho thêm thành viên / đổi role
+type GroupMemberRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// AssignStoryGroupRequest - DTO cho giao truyện cho nhóm, group_id rỗng để gỡ
+type AssignStoryGroupRequest struct {
+	GroupID *string `json:"group_id"`
+}
+
+func (req GroupRequest) toInput() services.GroupInput {
+	return services.GroupInput{
+		Name:        req.Name,
+		Description: req.Description,
+		AvatarURL:   req.AvatarURL,
+		Website:     req.Website,
+	}
+}
+
+// groupActor - Lấy user đang đăng nhập, trả false nếu chưa đăng nhập
+func groupActor(c *gin.Context) (uuid.UUID, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Unauthorized(c, "Chưa đăng nhập")
+		return uuid.Nil, false
+	}
+	return userID.(uuid.UUID), true
+}
+
+// respondGroupError - Không đủ quyền trong nhóm -> 403, còn lại 400
+func respondGroupError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrGroupForbidden) {
+		response.Forbidden(c, err.Error())
+		return
+	}
+	response.BadRequest(c, err.Error())
+}
+
+// ============ PUBLIC ENDPOINTS ============
+
+// GetGroups godoc
+// @Summary Danh sách nhóm dịch
+// @Tags Groups
+// @Produce json
+// @Param search query string false "Tên nhóm"
+// @Param page query int false "Page" default(1)
+// @Param limit query int false "Limit" default(20)
+// @Success 200 {object} response.Response
+// @Router /api/groups [get]
+func (h *GroupHandler) GetGroups(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	groups, total, err := h.groupService.GetGroups(c.Query("search"), page, limit)
+	if err != nil {
+		response.InternalServerError(c, "Không thể lấy danh sách nhóm dịch")
+		return
+	}
+	response.PaginatedResponse(c, groups, page, limit, total)
+}
+
+// GetGroupBySlug godoc
+// @Summary Trang nhóm dịch: thông tin, thành viên, truyện phụ trách
+// @Tags Groups
+// @Produce json
+// @Param slug path string true "Group Slug"
+// @Success 200 {object} response.Response
+// @Router /api/groups/{slug} [get]
+func (h *GroupHandler) GetGroupBySlug(c *gin.Context) {
+	profile, err := h.groupService.GetGroupProfile(c.Param("slug"), showMatureContent(c))
+	if err != nil {
+		response.NotFound(c, err.Error())
+		return
+	}
+	response.Oke(c, profile)
+}
+
+// GetGroupReleases godoc
+// @Summary Chapter nhóm dịch đã phát hành, mới nhất trước
+// @Tags Groups
+// @Produce json
+// @Param slug path string true "Group Slug"
+// @Param page query int false "Page" default(1)
+// @Param limit query int false "Limit" default(20)
+// @Success 200 {object} response.Response
+// @Router /api/groups/{slug}/releases [get]
+func (h *GroupHandler) GetGroupReleases(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	chapters, total, err := h.groupService.GetGroupReleases(c.Param("slug"), page, limit, showMatureContent(c))
+	if err != nil {
+		response.NotFound(c, err.Error())
+		return
+	}
+	response.PaginatedResponse(c, chapters, page, limit, total)
+}
+
+// ============ MEMBER ENDPOINTS ============
+
+// GetMyGroups godoc
+// @Summary Các nhóm dịch tôi đang tham gia kèm role
+// @Tags Groups
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} response.Response
+// @Router /api/groups/mine [get]
+func (h *GroupHandler) GetMyGroups(c *gin.Context) {
+	actorID, ok := groupActor(c)
+	if !ok {
+		return
+	}
+
+	groups, err := h.groupService.GetMyGroups(actorID)
+	if err != nil {
+		response.InternalServerError(c, "Không thể lấy danh sách nhóm dịch")
+		return
+	}
+	response.Oke(c, groups)
+}
+
+// UpdateGroup godoc
+// @Summary Cập nhật thông tin nhóm (Owner)
+// @Tags Groups
+// @Security BearerAuth
+// @Param slug path string true "Group Slug"
+// @Param request body GroupRequest true "Group data"
+// @Success 200 {object} response.Response
+// @Router /api/groups/{slug} [put]
+func (h *GroupHandler) UpdateGroup(c *gin.Context) {
+	actorID, ok := groupActor(c)
+	if !ok {
+		return
+	}
+
+	var req GroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Dữ liệu không hợp lệ: "+err.Error())
+		return
+	}
+
+	group, err := h.groupService.UpdateGroup(c.Param("slug"), actorID, req.toInput())
+	if err != nil {
+		respondGroupError(c, err)
+		return
+	}
+	response.Oke(c, group)
+}
+
+// SetGroupMember godoc
+// @Summary Thêm thành viên hoặc đổi role (Owner)
+// @Tags Groups
+// @Security BearerAuth
+// @Param slug path string true "Group Slug"
+// @Param userId path string true "User ID"
+// @Param request body GroupMemberRequest true "Role: owner, editor, uploader"
+// @Success 200 {object} response.Response
+// @Router /api/groups/{slug}/members/{userId} [put]
+func (h *GroupHandler) SetGroupMember(c *gin.Context) {
+	actorID, ok := groupActor(c)
+	if !ok {
+		return
+	}
+
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		response.BadRequest(c, "User ID không hợp lệ")
+		return
+	}
+
+	var req GroupMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Dữ liệu không hợp lệ")
+		return
+	}
+
+	member, err := h.groupService.SetMember(c.Param("slug"), actorID, userID, req.Role)
+	if err != nil {
+		respondGroupError(c, err)
+		return
+	}
+	response.Oke(c, member)
+}
+
+// RemoveGroupMember godoc
+// @Summary Xóa thành viên (Owner) hoặc tự rời nhóm
+// @Tags Groups
+// @Security BearerAuth
+// @Param slug path string true "Group Slug"
+// @Param userId path string true "User ID"
+// @Success 200 {object} response.Response
+// @Router /api/groups/{slug}/members/{userId} [delete]
+func (h *GroupHandler) RemoveGroupMember(c *gin.Context) {
+	actorID, ok := groupActor(c)
+	if !ok {
+		return
+	}
+
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		response.BadRequest(c, "User ID không hợp lệ")
+		return
+	}
+
+	if err := h.groupService.RemoveMember(c.Param("slug"), actorID, userID); err != nil {
+		respondGroupError(c, err)
+		return
+	}
+	response.Oke(c, gin.H{"message": "Đã xóa thành viên"})
+}
+
+// GetGroupStoryChapters godoc
+// @Summary Tất cả chapter (kể cả nháp) của truyện nhóm phụ trách (Member)
+// @Tags Groups
+// @Security BearerAuth
+// @Param slug path string true "Group Slug"
+// @Param storyId path string true "Story ID"
+// @Success 200 {object} response.Response
+// @Router /api/groups/{slug}/stories/{storyId}/chapters [get]
+func (h *GroupHandler) GetGroupStoryChapters(c *gin.Context) {
+	actorID, ok := groupActor(c)
+	if !ok {
+		return
+	}
+
+	storyID, err := uuid.Parse(c.Param("storyId"))
+	if err != nil {
+		response.BadRequest(c, "Story ID không hợp lệ")
+		return
+	}
+
+	chapters, err := h.groupService.GetStoryChapters(c.Param("slug"), actorID, storyID)
+	if err != nil {
+		respondGroupError(c, err)
+		return
+	}
+	response.Oke(c, chapters)
+}
+
+// CreateGroupChapter godoc
+// @Summary Tạo chapter cho truyện nhóm phụ trách (Uploader trở lên)
+// @Tags Groups
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param slug path string true "Group Slug"
+// @Param storyId path string true "Story ID"
+// @Param body body CreateChapterRequest true "Chapter Info"
+// @Success 201 {object} response.Response
+// @Router /api/groups/{slug}/stories/{storyId}/chapters [post]
+func (h *GroupHandler) CreateGroupChapter(c *gin.Context) {
+	actorID, ok := groupActor(c)
+	if !ok {
+		return
+	}
+
+	storyID, err := uuid.Parse(c.Param("storyId"))
+	if err != nil {
+		response.BadRequest(c, "Story ID không hợp lệ")
+		return
+	}
+
+	var req CreateChapterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Dữ liệu không hợp lệ")
+		return
+	}
+
+	if req.Content == "" && len(req.Images) == 0 {
+		response.BadRequest(c, "Cần có nội dung hoặc danh sách ảnh")
+		return
+	}
+
+	chapter, err := req.toChapter()
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	if err := h.groupService.CreateChapter(c.Param("slug"), actorID, storyID, chapter); err != nil {
+		respondGroupError(c, err)
+		return
+	}
+	response.Created(c, chapter)
+}
+
+// UpdateGroupChapter godoc
+// @Summary Cập nhật chapter của truyện nhóm phụ trách (Uploader trở lên)
+// @Tags Groups
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param slug path string true "Group Slug"
+// @Param chapterId path string true "Chapter ID"
+// @Param body body CreateChapterRequest true "Chapter Info"
+// @Success 200 {object} response.Response
+// @Router /api/groups/{slug}/chapters/{chapterId} [put]
+func (h *GroupHandler) UpdateGroupChapter(c *gin.Context) {
+	actorID, ok := groupActor(c)
+	if !ok {
+		return
+	}
+
+	chapterID, err := uuid.Parse(c.Param("chapterId"))
+	if err != nil {
+		response.BadRequest(c, "Chapter ID không hợp lệ")
+		return
+	}
+
+	var req CreateChapterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Dữ liệu không hợp lệ")
+		return
+	}
+
+	chapter, err := req.toChapter()
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	if err := h.groupService.UpdateChapter(c.Param("slug"), actorID, chapterID, chapter); err != nil {
+		respondGroupError(c, err)
+		return
+	}
+	response.Oke(c, gin.H{"message": "Cập nhật thành công"})
+}
+
+// DeleteGroupChapter godoc
+// @Summary Xóa chapter của truyện nhóm phụ trách (Editor trở lên)
+// @Tags Groups
+// @Security BearerAuth
+// @Param slug path string true "Group Slug"
+// @Param chapterId path string true "Chapter ID"
+// @Success 200 {object} response.Response
+// @Router /api/groups/{slug}/chapters/{chapterId} [delete]
+func (h *GroupHandler) DeleteGroupChapter(c *gin.Context) {
+	actorID, ok := groupActor(c)
+	if !ok {
+		return
+	}
+
+	chapterID, err := uuid.Parse(c.Param("chapterId"))
+	if err != nil {
+		response.BadRequest(c, "Chapter ID không hợp lệ")
+		return
+	}
+
+	if err := h.groupService.DeleteChapter(c.Param("slug"), actorID, chapterID); err != nil {
+		respondGroupError(c, err)
+		return
+	}
+	response.Oke(c, gin.H{"message": "Xóa thành công"})
+}
+
+// PublishGroupChapter godoc
+// @Summary Xuất bản chapter của truyện nhóm phụ trách (Editor trở lên)
+// @Tags Groups
+// @Security BearerAuth
+// @Param slug path string true "Group Slug"
+// @Param chapterId path string true "Chapter ID"
+// @Success 200 {object} response.Response
+// @Router /api/groups/{slug}/chapters/{chapterId}/publish [post]
+func (h *GroupHandler) PublishGroupChapter(c *gin.Context) {
+	actorID, ok := groupActor(c)
+	if !ok {
+		return
+	}
+
+	chapterID, err := uuid.Parse(c.Param("chapterId"))
+	if err != nil {
+		response.BadRequest(c, "Chapter ID không hợp lệ")
+		return
+	}
+
+	if err := h.groupService.PublishChapter(c.Param("slug"), actorID, chapterID); err != nil {
+		respondGroupError(c, err)
+		return
+	}
+	response.Oke(c, gin.H{"message": "Xuất bản thành công"})
+}
+
+// ScheduleGroupChapter godoc
+// @Summary Hẹn giờ xuất bản chapter của truyện nhóm phụ trách (Editor trở lên)
+// @Tags Groups
+// @Security BearerAuth
+// @Accept json
+// @Param slug path string true "Group Slug"
+// @Param chapterId path string true "Chapter ID"
+// @Param body body ScheduleChapterRequest true "Schedule Info"
+// @Success 200 {object} response.Response
+// @Router /api/groups/{slug}/chapters/{chapterId}/schedule [post]
+func (h *GroupHandler) ScheduleGroupChapter(c *gin.Context) {
+	actorID, ok := groupActor(c)
+	if !ok {
+		return
+	}
+
+	chapterID, err := uuid.Parse(c.Param("chapterId"))
+	if err != nil {
+		response.BadRequest(c, "Chapter ID không hợp lệ")
+		return
+	}
+
+	var req ScheduleChapterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Dữ liệu không hợp lệ")
+		return
+	}
+
+	scheduledAt, err := time.Parse(time.RFC3339, req.ScheduledAt)
+	if err != nil {
+		response.BadRequest(c, "Định dạng thời gian không hợp lệ (sử dụng RFC3339)")
+		return
+	}
+
+	if err := h.groupService.ScheduleChapter(c.Param("slug"), actorID, chapterID, scheduledAt); err != nil {
+		respondGroupError(c, err)
+		return
+	}
+	response.Oke(c, gin.H{"message": "Đã hẹn giờ xuất bản"})
+}
+
+// ============ ADMIN ENDPOINTS ============
+
+// CreateGroup godoc
+// @Summary Tạo nhóm dịch (Admin)
+// @Tags Admin Groups
+// @Security BearerAuth
+// @Param request body CreateGroupRequest true "Group data + owner"
+// @Success 201 {object} response.Response
+// @Router /api/admin/groups [post]
+func (h *GroupHandler) CreateGroup(c *gin.Context) {
+	var req CreateGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Dữ liệu không hợp lệ: "+err.Error())
+		return
+	}
+
+	ownerID, err := uuid.Parse(req.OwnerID)
+	if err != nil {
+		response.BadRequest(c, "Owner ID không hợp lệ")
+		return
+	}
+
+	group, err := h.groupService.CreateGroup(ownerID, req.toInput())
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	response.Created(c, group)
+}
+
+// DeleteGroup godoc
+// @Summary Xóa nhóm dịch (Admin)
+// @Tags Admin Groups
+// @Security BearerAuth
+// @Param id path string true "Group ID"
+// @Success 200 {object} response.Response
+// @Router /api/admin/groups/{id} [delete]
+func (h *GroupHandler) DeleteGroup(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "ID không hợp lệ")
+		return
+	}
+
+	if err := h.groupService.DeleteGroup(id); err != nil {
+		response.NotFound(c, err.Error())
+		return
+	}
+	response.Oke(c, gin.H{"message": "Xóa thành công"})
+}
+
+// AssignStoryGroup godoc
+// @Summary Giao truyện cho nhóm dịch (Admin), group_id null để gỡ
+// @Tags Admin Groups
+// @Security BearerAuth
+// @Param id path string true "Story ID"
+// @Param request body AssignStoryGroupRequest true "Group ID"
+// @Success 200 {object} response.Response
+// @Router /api/admin/stories/{id}/group [put]
+func (h *GroupHandler) AssignStoryGroup(c *gin.Context) {
+	storyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Story ID không hợp lệ")
+		return
+	}
+
+	var req AssignStoryGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Dữ liệu không hợp lệ")
+		return
+	}
+
+	var groupID *uuid.UUID
+	if req.GroupID != nil && *req.GroupID != "" {
+		id, err := uuid.Parse(*req.GroupID)
+		if err != nil {
+			response.BadRequest(c, "Group ID không hợp lệ")
+			return
+		}
+		groupID = &id
+	}
+
+	if err := h.groupService.AssignStory(storyID, groupID); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	response.Oke(c, gin.H{"message": "Cập nhật thành công"})
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"nekozanedex/internal/middleware"
+	"nekozanedex/internal/models"
+	"nekozanedex/internal/services"
+	"nekozanedex/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// importMaxSize - Giới hạn file import EPUB (CBZ/ZIP: services.ArchiveMaxUploadSize)
+const importMaxSize = 50 * 1024 * 1024
+
+type ImportHandler struct {
+	importService services.ImportService
+}
+
+func NewImportHandler(importService services.ImportService) *ImportHandler {
+	return &ImportHandler{importService: importService}
+}
+
+// ImportEPUBStory godoc
+// @Summary Tạo truyện (nháp) và chapters từ EPUB, mặc định chỉ xem trước (Admin)
+// @Tags Admin - Import
+// @Security BearerAuth
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "EPUB file"
+// @Param dry_run formData bool false "Chỉ xem trước (mặc định true)"
+// @Success 200 {object} response.Response
+// @Router /api/admin/import/epub [post]
+func (h *ImportHandler) ImportEPUBStory(c *gin.Context) {
+	h.importEPUB(c, nil)
+}
+
+// ImportEPUBChapters godoc
+// @Summary Thêm chapters (nháp) từ EPUB vào truyện có sẵn, mặc định chỉ xem trước (Admin)
+// @Tags Admin - Import
+// @Security BearerAuth
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "Story ID"
+// @Param file formData file true "EPUB file"
+// @Param dry_run formData bool false "Chỉ xem trước (mặc định true)"
+// @Success 200 {object} response.Response
+// @Router /api/admin/stories/{id}/chapters/import/epub [post]
+func (h *ImportHandler) ImportEPUBChapters(c *gin.Context) {
+	storyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Story ID không hợp lệ")
+		return
+	}
+	h.importEPUB(c, &storyID)
+}
+
+func (h *ImportHandler) importEPUB(c *gin.Context, storyID *uuid.UUID) {
+	dryRun, err := strconv.ParseBool(c.DefaultPostForm("dry_run", "true"))
+	if err != nil {
+		response.BadRequest(c, "dry_run không hợp lệ")
+		return
+	}
+
+	data, ok := readImportFile(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.importService.ImportEPUB(data, storyID, dryRun)
+	if err != nil {
+		if errors.Is(err, services.ErrImportFailed) {
+			response.InternalServerError(c, err.Error())
+			return
+		}
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if dryRun {
+		response.Oke(c, result)
+		return
+	}
+	response.Created(c, result)
+}
+
+// ImportArchiveChapters godoc
+// @Summary Tạo chapters (nháp) từ CBZ/ZIP, chạy nền và trả về job để theo dõi tiến độ (Admin)
+// @Description Một CBZ/ZIP là một chapter; ZIP có nhiều thư mục hoặc nhiều file .cbz bên trong thì mỗi thư mục/file là một chapter.
+// @Description Trang được sắp theo tên tự nhiên (2.jpg trước 10.jpg).
+// @Tags Admin - Import
+// @Security BearerAuth
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "Story ID"
+// @Param file formData file true "CBZ/ZIP file"
+// @Param title formData string false "Tiêu đề chapter (archive một chapter, mặc định là tên file)"
+// @Success 202 {object} response.Response
+// @Failure 429 {object} response.Response "Đang có quá nhiều job import"
+// @Router /api/admin/stories/{id}/chapters/import/archive [post]
+func (h *ImportHandler) ImportArchiveChapters(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Unauthorized(c, "Chưa đăng nhập")
+		return
+	}
+	storyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Story ID không hợp lệ")
+		return
+	}
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		response.BadRequest(c, "Không tìm thấy file")
+		return
+	}
+	defer file.Close()
+	if header.Size > services.ArchiveMaxUploadSize {
+		response.BadRequest(c, fmt.Sprintf("File quá lớn (tối đa %dMB)", services.ArchiveMaxUploadSize/(1024*1024)))
+		return
+	}
+
+	// Service chép file ra file tạm riêng: file multipart bị xóa khi request kết thúc
+	job, err := h.importService.StartArchiveImport(storyID, userID.(uuid.UUID), file, header.Filename, c.PostForm("title"))
+	if err != nil {
+		if errors.Is(err, services.ErrImportBusy) {
+			response.TooManyRequests(c, err.Error())
+			return
+		}
+		response.BadRequest(c, err.Error())
+		return
+	}
+	response.Accepted(c, job)
+}
+
+// GetImportJob godoc
+// @Summary Tiến độ job import CBZ/ZIP (Admin)
+// @Tags Admin - Import
+// @Security BearerAuth
+// @Produce json
+// @Param jobId path string true "Job ID"
+// @Success 200 {object} response.Response
+// @Router /api/admin/import/jobs/{jobId} [get]
+func (h *ImportHandler) GetImportJob(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Unauthorized(c, "Chưa đăng nhập")
+		return
+	}
+	jobID, err := uuid.Parse(c.Param("jobId"))
+	if err != nil {
+		response.BadRequest(c, "Job ID không hợp lệ")
+		return
+	}
+
+	// Chỉ người tạo job hoặc admin toàn quyền xem được tiến độ
+	job, err := h.importService.GetImportJob(jobID, userID.(uuid.UUID), middleware.HasPermission(c, models.PermAll))
+	if err != nil {
+		response.NotFound(c, err.Error())
+		return
+	}
+	response.Oke(c, job)
+}
+
+// readImportFile - Đọc file EPUB multipart "file" vào bộ nhớ (file zip cần đọc ngẫu nhiên)
+func readImportFile(c *gin.Context) ([]byte, bool) {
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		response.BadRequest(c, "Không tìm thấy file")
+		return nil, false
+	}
+	defer file.Close()
+
+	if header.Size > importMaxSize {
+		response.BadRequest(c, "File quá lớn (tối đa 50MB)")
+		return nil, false
+	}
+	data, err := io.ReadAll(io.LimitReader(file, importMaxSize+1))
+	if err != nil || len(data) > importMaxSize {
+		response.BadRequest(c, "Không đọc được file")
+		return nil, false
+	}
+	return data, true
+}
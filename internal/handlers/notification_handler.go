@@ -25,6 +25,7 @@ func NewNotificationHandler(notificationService services.NotificationService) *N
 // @Produce json
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Items per page" default(20)
+// @Param cursor query string false "Cursor trang sau (có tham số này thì bỏ qua page)"
 // @Success 200 {object} response.Pagination
 // @Router /api/notifications [get]
 func (h *NotificationHandler) GetMyNotifications(c *gin.Context) {
@@ -37,6 +38,17 @@ func (h *NotificationHandler) GetMyNotifications(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 
+	if cursor, ok := c.GetQuery("cursor"); ok {
+		limit = cursorLimit(limit, 20)
+		notifications, next, err := h.notificationService.GetUserNotificationsByCursor(userID.(uuid.UUID), cursor, limit)
+		if err != nil {
+			respondListError(c, err, "Không thể lấy thông báo")
+			return
+		}
+		response.CursorPaginatedResponse(c, notifications, limit, next)
+		return
+	}
+
 	notifications, total, err := h.notificationService.GetUserNotifications(userID.(uuid.UUID), page, limit)
 	if err != nil {
 		response.InternalServerError(c, "Không thể lấy thông báo")
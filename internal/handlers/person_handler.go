@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"strconv"
+
+	"nekozanedex/internal/services"
+	"nekozanedex/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type PersonHandler struct {
+	personService services.PersonService
+}
+
+func NewPersonHandler(personService services.PersonService) *PersonHandler {
+	return &PersonHandler{personService: personService}
+}
+
+// PersonRequest - DTO cho tạo/cập nhật tác giả, họa sĩ, dịch giả
+type PersonRequest struct {
+	Name      string   `json:"name" binding:"required,min=1,max=100"`
+	Aliases   []string `json:"aliases"`
+	Bio       *string  `json:"bio"`
+	AvatarURL *string  `json:"avatar_url"`
+}
+
+// MergePersonRequest - DTO cho gộp hai người trùng nhau
+type MergePersonRequest struct {
+	SourceID string `json:"source_id" binding:"required"`
+}
+
+// SetStoryPeopleRequest - DTO cho gán người tham gia truyện
+type SetStoryPeopleRequest struct {
+	People []services.StoryPersonInput `json:"people" binding:"dive"`
+}
+
+// GetPersonBySlug godoc
+// @Summary Trang tác giả/họa sĩ/dịch giả kèm danh sách tác phẩm
+// @Tags People
+// @Produce json
+// @Param slug path string true "Person Slug"
+// @Success 200 {object} response.Response
+// @Router /api/people/{slug} [get]
+func (h *PersonHandler) GetPersonBySlug(c *gin.Context) {
+	profile, err := h.personService.GetPersonProfile(c.Param("slug"), showMatureContent(c))
+	if err != nil {
+		response.NotFound(c, err.Error())
+		return
+	}
+	response.Oke(c, profile)
+}
+
+// ============ ADMIN ENDPOINTS ============
+
+// GetAllPeopleAdmin godoc
+// @Summary Danh sách tác giả/họa sĩ/dịch giả (Admin)
+// @Tags Admin People
+// @Security BearerAuth
+// @Param search query string false "Tên"
+// @Param page query int false "Page" default(1)
+// @Param limit query int false "Limit" default(20)
+// @Success 200 {object} response.Response
+// @Router /api/admin/people [get]
+func (h *PersonHandler) GetAllPeopleAdmin(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	people, total, err := h.personService.GetAllPeople(c.Query("search"), page, limit)
+	if err != nil {
+		response.InternalServerError(c, "Không thể lấy danh sách tác giả")
+		return
+	}
+	response.PaginatedResponse(c, people, page, limit, total)
+}
+
+// GetPersonByID godoc
+// @Summary Lấy tác giả theo ID (Admin)
+// @Tags Admin People
+// @Security BearerAuth
+// @Param id path string true "Person ID"
+// @Success 200 {object} response.Response
+// @Router /api/admin/people/{id} [get]
+func (h *PersonHandler) GetPersonByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "ID không hợp lệ")
+		return
+	}
+
+	person, err := h.personService.GetPersonByID(id)
+	if err != nil {
+		response.NotFound(c, err.Error())
+		return
+	}
+	response.Oke(c, person)
+}
+
+// CreatePerson godoc
+// @Summary Tạo tác giả/họa sĩ/dịch giả (Admin)
+// @Tags Admin People
+// @Security BearerAuth
+// @Param request body PersonRequest true "Person data"
+// @Success 201 {object} response.Response
+// @Router /api/admin/people [post]
+func (h *PersonHandler) CreatePerson(c *gin.Context) {
+	var req PersonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Dữ liệu không hợp lệ: "+err.Error())
+		return
+	}
+
+	person, err := h.personService.CreatePerson(req.Name, req.Aliases, req.Bio, req.AvatarURL)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	response.Created(c, person)
+}
+
+// UpdatePerson godoc
+// @Summary Cập nhật tác giả/họa sĩ/dịch giả (Admin)
+// @Tags Admin People
+// @Security BearerAuth
+// @Param id path string true "Person ID"
+// @Param request body PersonRequest true "Person data"
+// @Success 200 {object} response.Response
+// @Router /api/admin/people/{id} [put]
+func (h *PersonHandler) UpdatePerson(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "ID không hợp lệ")
+		return
+	}
+
+	var req PersonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Dữ liệu không hợp lệ: "+err.Error())
+		return
+	}
+
+	person, err := h.personService.UpdatePerson(id, req.Name, req.Aliases, req.Bio, req.AvatarURL)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	response.Oke(c, person)
+}
+
+// DeletePerson godoc
+// @Summary Xóa tác giả/họa sĩ/dịch giả (Admin)
+// @Tags Admin People
+// @Security BearerAuth
+// @Param id path string true "Person ID"
+// @Success 200 {object} response.Response
+// @Router /api/admin/people/{id} [delete]
+func (h *PersonHandler) DeletePerson(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "ID không hợp lệ")
+		return
+	}
+
+	if err := h.personService.DeletePerson(id); err != nil {
+		response.InternalServerError(c, "Không thể xóa tác giả: "+err.Error())
+		return
+	}
+	response.Oke(c, gin.H{"message": "Đã xóa tác giả"})
+}
+
+// MergePerson godoc
+// @Summary Gộp tác giả trùng vào tác giả này (Admin)
+// @Tags Admin People
+// @Security BearerAuth
+// @Param id path string true "Target Person ID"
+// @Param request body MergePersonRequest true "Source person"
+// @Success 200 {object} response.Response
+// @Router /api/admin/people/{id}/merge [post]
+func (h *PersonHandler) MergePerson(c *gin.Context) {
+	targetID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "ID không hợp lệ")
+		return
+	}
+
+	var req MergePersonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Dữ liệu không hợp lệ: "+err.Error())
+		return
+	}
+	sourceID, err := uuid.Parse(req.SourceID)
+	if err != nil {
+		response.BadRequest(c, "Source ID không hợp lệ")
+		return
+	}
+
+	person, err := h.personService.MergePeople(targetID, sourceID)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	response.Oke(c, person)
+}
+
+// MigrateStoryCredits godoc
+// @Summary Chuyển author/artist/translator dạng chuỗi của truyện sang tác giả liên kết (Admin)
+// @Tags Admin People
+// @Security BearerAuth
+// @Success 200 {object} response.Response
+// @Router /api/admin/people/migrate [post]
+func (h *PersonHandler) MigrateStoryCredits(c *gin.Context) {
+	result, err := h.personService.MigrateStoryCredits()
+	if err != nil {
+		response.InternalServerError(c, "Migrate thất bại: "+err.Error())
+		return
+	}
+	response.Oke(c, result)
+}
+
+// SetStoryPeople godoc
+// @Summary Gán tác giả/họa sĩ/dịch giả cho truyện (Admin)
+// @Tags Admin People
+// @Security BearerAuth
+// @Param id path string true "Story ID"
+// @Param request body SetStoryPeopleRequest true "People"
+// @Success 200 {object} response.Response
+// @Router /api/admin/stories/{id}/people [put]
+func (h *PersonHandler) SetStoryPeople(c *gin.Context) {
+	storyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Story ID không hợp lệ")
+		return
+	}
+
+	var req SetStoryPeopleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Dữ liệu không hợp lệ: "+err.Error())
+		return
+	}
+
+	if err := h.personService.SetStoryPeople(storyID, req.People); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	response.Oke(c, gin.H{"message": "Đã cập nhật tác giả cho truyện"})
+}
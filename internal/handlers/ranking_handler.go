@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+
+	"nekozanedex/internal/models"
+	"nekozanedex/internal/services"
+	"nekozanedex/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RankingHandler struct {
+	rankingService services.RankingService
+}
+
+func NewRankingHandler(rankingService services.RankingService) *RankingHandler {
+	return &RankingHandler{rankingService: rankingService}
+}
+
+// GetRankings godoc
+// @Summary Lấy bảng xếp hạng truyện
+// @Tags Rankings
+// @Produce json
+// @Param type path string true "Type: views, rating, bookmarks"
+// @Param period path string true "Period: daily, weekly, monthly, all_time"
+// @Param date query string false "Ngày snapshot (YYYY-MM-DD), mặc định là mới nhất"
+// @Param limit query int false "Number of stories" default(50)
+// @Success 200 {object} response.Response
+// @Router /api/rankings/{type}/{period} [get]
+func (h *RankingHandler) GetRankings(c *gin.Context) {
+	rankingType := c.Param("type")
+	period := c.Param("period")
+	if !models.IsValidRanking(rankingType, period) {
+		response.BadRequest(c, "Loại (views, rating, bookmarks) hoặc chu kỳ (daily, weekly, monthly, all_time) không hợp lệ")
+		return
+	}
+
+	var date *time.Time
+	if dateStr := c.Query("date"); dateStr != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", dateStr, time.Local)
+		if err != nil {
+			response.BadRequest(c, "Định dạng ngày không hợp lệ (YYYY-MM-DD)")
+			return
+		}
+		date = &parsed
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+	rankings, err := h.rankingService.GetRankings(rankingType, period, date, limit, showMatureContent(c))
+	if err != nil {
+		response.InternalServerError(c, "Không thể lấy bảng xếp hạng")
+		return
+	}
+
+	response.Oke(c, rankings)
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"strconv"
+
+	"nekozanedex/internal/services"
+	"nekozanedex/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type RecommendationHandler struct {
+	recommendationService services.RecommendationService
+}
+
+func NewRecommendationHandler(recommendationService services.RecommendationService) *RecommendationHandler {
+	return &RecommendationHandler{recommendationService: recommendationService}
+}
+
+// GetSimilarStories godoc
+// @Summary Lấy truyện tương tự (thể loại + người đọc chung)
+// @Tags Stories
+// @Produce json
+// @Param slug path string true "Story Slug"
+// @Param limit query int false "Number of stories" default(10)
+// @Success 200 {object} response.Response
+// @Router /api/stories/{slug}/similar [get]
+func (h *RecommendationHandler) GetSimilarStories(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if limit < 1 || limit > 20 {
+		limit = 10
+	}
+
+	stories, err := h.recommendationService.GetSimilarStories(c.Param("slug"), limit, showMatureContent(c))
+	if err != nil {
+		if respondContentWarning(c, err) {
+			return
+		}
+		response.NotFound(c, err.Error())
+		return
+	}
+
+	response.Oke(c, stories)
+}
+
+// GetRecommendations godoc
+// @Summary Gợi ý truyện cho người đọc (khách nhận trending)
+// @Tags Stories
+// @Produce json
+// @Param limit query int false "Number of stories" default(20)
+// @Success 200 {object} response.Response
+// @Router /api/recommendations [get]
+func (h *RecommendationHandler) GetRecommendations(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > 50 {
+		limit = 20
+	}
+
+	var userID *uuid.UUID
+	if id, exists := c.Get("user_id"); exists {
+		uid := id.(uuid.UUID)
+		userID = &uid
+	}
+
+	stories, err := h.recommendationService.GetRecommendations(userID, limit, showMatureContent(c))
+	if err != nil {
+		response.InternalServerError(c, "Không thể lấy gợi ý")
+		return
+	}
+
+	response.Oke(c, stories)
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"errors"
+
+	"nekozanedex/internal/middleware"
+	"nekozanedex/internal/models"
+	"nekozanedex/internal/services"
+	"nekozanedex/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type RoleHandler struct {
+	roleService services.RoleService
+}
+
+func NewRoleHandler(roleService services.RoleService) *RoleHandler {
+	return &RoleHandler{roleService: roleService}
+}
+
+// CreateRoleRequest - DTO cho tạo role
+type CreateRoleRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	Description *string  `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// UpdateRoleDefinitionRequest - DTO cho cập nhật role, bỏ trống permissions để giữ nguyên
+type UpdateRoleDefinitionRequest struct {
+	Description *string  `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// GetRoles godoc
+// @Summary Danh sách role kèm quyền (Admin)
+// @Tags Admin Roles
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} response.Response
+// @Router /api/admin/roles [get]
+func (h *RoleHandler) GetRoles(c *gin.Context) {
+	roles, err := h.roleService.GetRoles()
+	if err != nil {
+		response.InternalServerError(c, "Không thể lấy danh sách role")
+		return
+	}
+	response.Oke(c, roles)
+}
+
+// GetPermissions godoc
+// @Summary Danh sách quyền có thể gán cho role (Admin)
+// @Tags Admin Roles
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} response.Response
+// @Router /api/admin/roles/permissions [get]
+func (h *RoleHandler) GetPermissions(c *gin.Context) {
+	response.Oke(c, models.Permissions)
+}
+
+// CreateRole godoc
+// @Summary Tạo role mới (Admin)
+// @Tags Admin Roles
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param body body CreateRoleRequest true "Role info"
+// @Success 201 {object} response.Response
+// @Router /api/admin/roles [post]
+func (h *RoleHandler) CreateRole(c *gin.Context) {
+	var req CreateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Dữ liệu không hợp lệ")
+		return
+	}
+
+	role, err := h.roleService.CreateRole(req.Name, req.Description, req.Permissions, middleware.Permissions(c))
+	if err != nil {
+		respondRoleError(c, err)
+		return
+	}
+	response.Created(c, role)
+}
+
+// UpdateRole godoc
+// @Summary Cập nhật mô tả/quyền của role (Admin)
+// @Tags Admin Roles
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Role ID"
+// @Param body body UpdateRoleDefinitionRequest true "Role info"
+// @Success 200 {object} response.Response
+// @Router /api/admin/roles/{id} [put]
+func (h *RoleHandler) UpdateRole(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "ID không hợp lệ")
+		return
+	}
+
+	var req UpdateRoleDefinitionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Dữ liệu không hợp lệ")
+		return
+	}
+
+	role, err := h.roleService.UpdateRole(id, req.Description, req.Permissions, middleware.Permissions(c))
+	if err != nil {
+		respondRoleError(c, err)
+		return
+	}
+	response.Oke(c, role)
+}
+
+// DeleteRole godoc
+// @Summary Xóa role không phải hệ thống và không còn người dùng (Admin)
+// @Tags Admin Roles
+// @Security BearerAuth
+// @Param id path string true "Role ID"
+// @Success 200 {object} response.Response
+// @Router /api/admin/roles/{id} [delete]
+func (h *RoleHandler) DeleteRole(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "ID không hợp lệ")
+		return
+	}
+
+	if err := h.roleService.DeleteRole(id); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	response.Oke(c, gin.H{"message": "Xóa thành công"})
+}
+
+// respondRoleError - 403 khi vượt quyền, còn lại 400
+func respondRoleError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrPermissionEscalation) {
+		response.Forbidden(c, err.Error())
+		return
+	}
+	response.BadRequest(c, err.Error())
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"nekozanedex/internal/services"
+	"nekozanedex/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SearchHandler struct {
+	searchService services.SearchService
+}
+
+func NewSearchHandler(searchService services.SearchService) *SearchHandler {
+	return &SearchHandler{searchService: searchService}
+}
+
+// Suggest godoc
+// @Summary Gợi ý tìm kiếm (truyện, tác giả, thể loại) khi đang gõ
+// @Tags Search
+// @Produce json
+// @Param q query string true "Từ khóa"
+// @Success 200 {object} response.Response
+// @Router /api/search/suggest [get]
+func (h *SearchHandler) Suggest(c *gin.Context) {
+	showMature := showMatureContent(c)
+	suggestions, err := h.searchService.Suggest(c.Query("q"), showMature)
+	if err != nil {
+		response.InternalServerError(c, "Không thể lấy gợi ý tìm kiếm")
+		return
+	}
+
+	// Kết quả có truyện mature phụ thuộc người xem, không cho cache dùng chung
+	if showMature {
+		c.Header("Cache-Control", "private, max-age=60")
+	} else {
+		c.Header("Cache-Control", "public, max-age=60")
+	}
+	response.Oke(c, suggestions)
+}
@@ -36,6 +36,7 @@ type CreateStoryRequest struct {
 	ReleaseYear   *int     `json:"release_year"`
 	EndYear       *int     `json:"end_year"`
 	Status        string   `json:"status"`
+	AgeRating     string   `json:"age_rating"` // everyone, teen, mature, adult
 	IsPublished   bool     `json:"is_published"`
 	GenreIDs      []string `json:"genre_ids"`
 }
@@ -48,12 +49,24 @@ type CreateStoryRequest struct {
 // @Produce json
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Items per page" default(20)
+// @Param cursor query string false "Cursor trang sau (có tham số này thì bỏ qua page, meta trả next_cursor)"
 // @Success 200 {object} response.Pagination
 // @Router /api/stories [get]
 func (h *StoryHandler) GetStories(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 
+	if cursor, ok := c.GetQuery("cursor"); ok {
+		limit = cursorLimit(limit, 20)
+		stories, next, err := h.storyService.GetAllStoriesByCursor(cursor, limit, showMatureContent(c))
+		if err != nil {
+			respondListError(c, err, "Không thể lấy danh sách truyện")
+			return
+		}
+		response.CursorPaginatedResponse(c, stories, limit, next)
+		return
+	}
+
 	if page < 1 {
 		page = 1
 	}
@@ -61,7 +74,7 @@ func (h *StoryHandler) GetStories(c *gin.Context) {
 		limit = 20
 	}
 
-	stories, total, err := h.storyService.GetAllStories(page, limit)
+	stories, total, err := h.storyService.GetAllStories(page, limit, showMatureContent(c))
 	if err != nil {
 		response.InternalServerError(c, "Không thể lấy danh sách truyện")
 		return
@@ -76,27 +89,29 @@ func (h *StoryHandler) GetStories(c *gin.Context) {
 // @Produce json
 // @Param slug path string true "Story Slug"
 // @Success 200 {object} response.Response
+// @Success 301 {object} response.Response "Slug cũ, data.slug là slug hiện tại"
 // @Router /api/stories/{slug} [get]
 func (h *StoryHandler) GetStoryBySlug(c *gin.Context) {
 	slug := c.Param("slug")
 
-	story, err := h.storyService.GetStoryBySlug(slug)
+	story, err := h.storyService.GetStoryBySlug(slug, showMatureContent(c))
 	if err != nil {
+		if respondContentWarning(c, err) {
+			return
+		}
 		response.NotFound(c, err.Error())
 		return
 	}
 
-	// Fair view counting: get IP and optional userID
-	clientIP := c.ClientIP()
-	var userID *uuid.UUID
-	if uid, exists := c.Get("user_id"); exists {
-		if id, ok := uid.(uuid.UUID); ok {
-			userID = &id
-		}
+	// Slug cũ: trả về redirect kèm slug hiện tại, không đếm view
+	if story.Slug != slug {
+		response.MovedPermanently(c, "/api/stories/"+story.Slug, gin.H{"slug": story.Slug})
+		return
 	}
 
 	// Record view (1 per user/IP per 24h)
-	_ = h.storyService.RecordStoryView(story.ID, userID, clientIP)
+	v := viewerFromContext(c)
+	_ = h.storyService.RecordStoryView(story.ID, v.UserID, v.IP, v.Referrer)
 
 	response.Oke(c, story)
 }
@@ -114,7 +129,7 @@ func (h *StoryHandler) GetLatestStories(c *gin.Context) {
 		limit = 10
 	}
 
-	stories, err := h.storyService.GetLatestStories(limit)
+	stories, err := h.storyService.GetLatestStories(limit, showMatureContent(c))
 	if err != nil {
 		response.InternalServerError(c, "Không thể lấy truyện mới")
 		return
@@ -124,19 +139,26 @@ func (h *StoryHandler) GetLatestStories(c *gin.Context) {
 }
 
 // GetHotStories godoc
-// @Summary Lấy truyện hot
+// @Summary Lấy truyện hot (trending theo khung thời gian)
 // @Tags Stories
 // @Produce json
+// @Param window query string false "Window: 24h, 7d, 30d" default(7d)
 // @Param limit query int false "Number of stories" default(10)
 // @Success 200 {object} response.Response
 // @Router /api/stories/hot [get]
 func (h *StoryHandler) GetHotStories(c *gin.Context) {
+	window := c.DefaultQuery("window", models.TrendingWindow7d)
+	if !models.IsValidTrendingWindow(window) {
+		response.BadRequest(c, "Window không hợp lệ (24h, 7d, 30d)")
+		return
+	}
+
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
 	if limit < 1 || limit > 50 {
 		limit = 10
 	}
 
-	stories, err := h.storyService.GetHotStories(limit)
+	stories, err := h.storyService.GetHotStories(window, limit, showMatureContent(c))
 	if err != nil {
 		response.InternalServerError(c, "Không thể lấy truyện hot")
 		return
@@ -152,7 +174,7 @@ func (h *StoryHandler) GetHotStories(c *gin.Context) {
 // @Success 200 {object} response.Response
 // @Router /api/stories/random [get]
 func (h *StoryHandler) GetRandomStory(c *gin.Context) {
-	story, err := h.storyService.GetRandomStory()
+	story, err := h.storyService.GetRandomStory(showMatureContent(c))
 	if err != nil {
 		response.NotFound(c, err.Error())
 		return
@@ -167,11 +189,13 @@ func (h *StoryHandler) GetRandomStory(c *gin.Context) {
 // @Produce json
 // @Param q query string false "Search query"
 // @Param genres query string false "Genre slugs (comma separated)"
+// @Param tags query string false "Tag slugs bắt buộc có (comma separated)"
+// @Param exclude_tags query string false "Tag slugs loại trừ (comma separated)"
 // @Param status query string false "Status: ongoing, completed, hiatus"
 // @Param country query string false "Country: JP, CN, KR, VN"
 // @Param year_from query int false "Release year from"
 // @Param year_to query int false "Release year to"
-// @Param sort query string false "Sort: latest, popular, name, rating, oldest"
+// @Param sort query string false "Sort: latest, popular, name, rating, oldest, relevance"
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Items per page" default(20)
 // @Success 200 {object} response.Pagination
@@ -185,16 +209,10 @@ func (h *StoryHandler) SearchStories(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 
-	// Parse genres
-	var genreSlugs []string
-	if genresParam != "" {
-		for _, g := range strings.Split(genresParam, ",") {
-			g = strings.TrimSpace(g)
-			if g != "" {
-				genreSlugs = append(genreSlugs, g)
-			}
-		}
-	}
+	// Parse genres / tags
+	genreSlugs := splitCommaList(genresParam)
+	includeTags := splitCommaList(c.Query("tags"))
+	excludeTags := splitCommaList(c.Query("exclude_tags"))
 
 	// Parse year filters
 	var yearFrom, yearTo *int
@@ -210,7 +228,8 @@ func (h *StoryHandler) SearchStories(c *gin.Context) {
 	}
 
 	// Use advanced search if any filter is provided
-	hasFilters := status != "" || country != "" || yearFrom != nil || yearTo != nil || sortBy != "latest" || len(genreSlugs) > 0
+	hasFilters := status != "" || country != "" || yearFrom != nil || yearTo != nil || sortBy != "latest" || len(genreSlugs) > 0 ||
+		len(includeTags) > 0 || len(excludeTags) > 0
 	
 	if !hasFilters && query == "" {
 		response.BadRequest(c, "Từ khóa tìm kiếm không được để trống")
@@ -219,15 +238,18 @@ func (h *StoryHandler) SearchStories(c *gin.Context) {
 
 	// Import repositories for SearchFilters
 	filters := &repositories.SearchFilters{
-		Query:      query,
-		GenreSlugs: genreSlugs,
-		Status:     status,
-		Country:    country,
-		YearFrom:   yearFrom,
-		YearTo:     yearTo,
-		SortBy:     sortBy,
-		Page:       page,
-		Limit:      limit,
+		Query:       query,
+		GenreSlugs:  genreSlugs,
+		IncludeTags: includeTags,
+		ExcludeTags: excludeTags,
+		Status:      status,
+		Country:     country,
+		YearFrom:    yearFrom,
+		YearTo:      yearTo,
+		ShowMature:  showMatureContent(c),
+		SortBy:      sortBy,
+		Page:        page,
+		Limit:       limit,
 	}
 
 	stories, total, err := h.storyService.AdvancedSearchStories(filters)
@@ -239,6 +261,17 @@ func (h *StoryHandler) SearchStories(c *gin.Context) {
 	response.PaginatedResponse(c, stories, page, limit, total)
 }
 
+// splitCommaList - Tách "a, b,c" thành ["a", "b", "c"], bỏ phần tử rỗng
+func splitCommaList(param string) []string {
+	var items []string
+	for _, item := range strings.Split(param, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 // GetStoriesByGenre godoc
 // @Summary Lấy truyện theo thể loại
 // @Tags Stories
@@ -253,7 +286,7 @@ func (h *StoryHandler) GetStoriesByGenre(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 
-	stories, total, err := h.storyService.GetStoriesByGenre(genreSlug, page, limit)
+	stories, total, err := h.storyService.GetStoriesByGenre(genreSlug, page, limit, showMatureContent(c))
 	if err != nil {
 		response.NotFound(c, err.Error())
 		return
@@ -310,6 +343,7 @@ func (h *StoryHandler) CreateStory(c *gin.Context) {
 		ReleaseYear:   req.ReleaseYear,
 		EndYear:       req.EndYear,
 		Status:        req.Status,
+		AgeRating:     req.AgeRating,
 		IsPublished:   req.IsPublished,
 	}
 
@@ -367,6 +401,7 @@ func (h *StoryHandler) UpdateStory(c *gin.Context) {
 		ReleaseYear:   req.ReleaseYear,
 		EndYear:       req.EndYear,
 		Status:        req.Status,
+		AgeRating:     req.AgeRating,
 		IsPublished:   req.IsPublished,
 	}
 
@@ -439,6 +474,167 @@ func (h *StoryHandler) GetStoryByID(c *gin.Context) {
 	response.Oke(c, story)
 }
 
+// PinSlugRequest - DTO cho ghim slug tùy chỉnh
+type PinSlugRequest struct {
+	Slug   string `json:"slug" binding:"required,max=255"`
+	Locked *bool  `json:"locked"` // Mặc định true
+}
+
+// PinStorySlug godoc
+// @Summary Đặt slug tùy chỉnh cho truyện, slug cũ vẫn chuyển hướng (Admin)
+// @Tags Admin - Stories
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Story ID"
+// @Param body body PinSlugRequest true "Slug"
+// @Success 200 {object} response.Response
+// @Router /api/admin/stories/{id}/slug [put]
+func (h *StoryHandler) PinStorySlug(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "ID không hợp lệ")
+		return
+	}
+
+	var req PinSlugRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Dữ liệu không hợp lệ")
+		return
+	}
+	locked := true
+	if req.Locked != nil {
+		locked = *req.Locked
+	}
+
+	story, err := h.storyService.PinStorySlug(id, req.Slug, locked)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	response.Oke(c, story)
+}
+
+// GetSlugHistory godoc
+// @Summary Lấy các slug cũ của truyện (Admin)
+// @Tags Admin - Stories
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Story ID"
+// @Success 200 {object} response.Response
+// @Router /api/admin/stories/{id}/slug-history [get]
+func (h *StoryHandler) GetSlugHistory(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "ID không hợp lệ")
+		return
+	}
+
+	history, err := h.storyService.GetSlugHistory(id)
+	if err != nil {
+		response.InternalServerError(c, "Không thể lấy lịch sử slug")
+		return
+	}
+
+	response.Oke(c, history)
+}
+
+// StoryRelationRequest - DTO cho liên kết truyện
+type StoryRelationRequest struct {
+	RelatedStoryID string `json:"related_story_id" binding:"required"`
+	RelationType   string `json:"relation_type" binding:"required"` // sequel, prequel, side_story, main_story, adaptation, source, alternate, same_franchise
+}
+
+// GetStoryRelations godoc
+// @Summary Lấy danh sách truyện liên quan (Admin)
+// @Tags Admin - Stories
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Story ID"
+// @Success 200 {object} response.Response
+// @Router /api/admin/stories/{id}/relations [get]
+func (h *StoryHandler) GetStoryRelations(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "ID không hợp lệ")
+		return
+	}
+
+	relations, err := h.storyService.GetStoryRelations(id)
+	if err != nil {
+		response.InternalServerError(c, "Không thể lấy truyện liên quan")
+		return
+	}
+
+	response.Oke(c, relations)
+}
+
+// SetStoryRelation godoc
+// @Summary Liên kết truyện (quan hệ ngược được tạo tự động) (Admin)
+// @Tags Admin - Stories
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Story ID"
+// @Param body body StoryRelationRequest true "Relation"
+// @Success 200 {object} response.Response
+// @Router /api/admin/stories/{id}/relations [post]
+func (h *StoryHandler) SetStoryRelation(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "ID không hợp lệ")
+		return
+	}
+
+	var req StoryRelationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Dữ liệu không hợp lệ")
+		return
+	}
+	relatedID, err := uuid.Parse(req.RelatedStoryID)
+	if err != nil {
+		response.BadRequest(c, "Related story ID không hợp lệ")
+		return
+	}
+
+	if err := h.storyService.SetStoryRelation(id, relatedID, req.RelationType); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	response.Oke(c, gin.H{"message": "Đã liên kết truyện"})
+}
+
+// DeleteStoryRelation godoc
+// @Summary Gỡ liên kết truyện (Admin)
+// @Tags Admin - Stories
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Story ID"
+// @Param relatedId path string true "Related Story ID"
+// @Success 200 {object} response.Response
+// @Router /api/admin/stories/{id}/relations/{relatedId} [delete]
+func (h *StoryHandler) DeleteStoryRelation(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "ID không hợp lệ")
+		return
+	}
+	relatedID, err := uuid.Parse(c.Param("relatedId"))
+	if err != nil {
+		response.BadRequest(c, "Related story ID không hợp lệ")
+		return
+	}
+
+	if err := h.storyService.DeleteStoryRelation(id, relatedID); err != nil {
+		response.InternalServerError(c, "Không thể gỡ liên kết")
+		return
+	}
+
+	response.Oke(c, gin.H{"message": "Đã gỡ liên kết"})
+}
+
 // GetAllStoriesAdmin godoc
 // @Summary Lấy tất cả truyện (Admin)
 // @Tags Admin - Stories
@@ -0,0 +1,359 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"nekozanedex/internal/services"
+	"nekozanedex/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type TagHandler struct {
+	tagService services.TagService
+}
+
+func NewTagHandler(tagService services.TagService) *TagHandler {
+	return &TagHandler{tagService: tagService}
+}
+
+// ProposeTagRequest - DTO cho đề xuất tag
+type ProposeTagRequest struct {
+	Name string `json:"name" binding:"required,min=1,max=50"`
+}
+
+// VoteTagRequest - DTO cho vote tag (1, -1, 0 = bỏ phiếu)
+type VoteTagRequest struct {
+	Value *int `json:"value" binding:"required,min=-1,max=1"`
+}
+
+// TagRequest - DTO cho tạo/cập nhật tag (Admin)
+type TagRequest struct {
+	Name        string  `json:"name" binding:"required,min=1,max=50"`
+	Description *string `json:"description"`
+}
+
+// MergeTagRequest - DTO cho gộp tag
+type MergeTagRequest struct {
+	SourceID string `json:"source_id" binding:"required"`
+}
+
+// SearchTags godoc
+// @Summary Tìm tag (autocomplete)
+// @Tags Tags
+// @Produce json
+// @Param q query string false "Tiền tố tên tag"
+// @Param limit query int false "Limit" default(20)
+// @Success 200 {object} response.Response
+// @Router /api/tags [get]
+func (h *TagHandler) SearchTags(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	tags, err := h.tagService.SearchTags(c.Query("q"), limit)
+	if err != nil {
+		response.InternalServerError(c, "Không thể lấy danh sách tag")
+		return
+	}
+	response.Oke(c, tags)
+}
+
+// GetStoryTags godoc
+// @Summary Lấy tag của truyện (đăng nhập thì kèm tag đang chờ vote và phiếu của mình)
+// @Tags Tags
+// @Produce json
+// @Param storyId path string true "Story ID"
+// @Success 200 {object} response.Response
+// @Router /api/tags/story/{storyId} [get]
+func (h *TagHandler) GetStoryTags(c *gin.Context) {
+	storyID, err := uuid.Parse(c.Param("storyId"))
+	if err != nil {
+		response.BadRequest(c, "Story ID không hợp lệ")
+		return
+	}
+
+	var viewerID *uuid.UUID
+	if id, exists := c.Get("user_id"); exists {
+		uid := id.(uuid.UUID)
+		viewerID = &uid
+	}
+
+	tags, err := h.tagService.GetStoryTags(storyID, viewerID)
+	if err != nil {
+		response.InternalServerError(c, "Không thể lấy tag của truyện")
+		return
+	}
+	response.Oke(c, tags)
+}
+
+// ProposeTag godoc
+// @Summary Đề xuất tag cho truyện
+// @Tags Tags
+// @Security BearerAuth
+// @Param storyId path string true "Story ID"
+// @Param request body ProposeTagRequest true "Tag"
+// @Success 201 {object} response.Response
+// @Router /api/tags/story/{storyId} [post]
+func (h *TagHandler) ProposeTag(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Unauthorized(c, "Chưa đăng nhập")
+		return
+	}
+
+	storyID, err := uuid.Parse(c.Param("storyId"))
+	if err != nil {
+		response.BadRequest(c, "Story ID không hợp lệ")
+		return
+	}
+
+	var req ProposeTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Dữ liệu không hợp lệ")
+		return
+	}
+
+	tag, err := h.tagService.ProposeTag(storyID, userID.(uuid.UUID), req.Name, showMatureContent(c))
+	if err != nil {
+		if respondContentWarning(c, err) {
+			return
+		}
+		response.BadRequest(c, err.Error())
+		return
+	}
+	response.Created(c, tag)
+}
+
+// VoteTag godoc
+// @Summary Vote tag trên truyện (1 = đồng ý, -1 = không đồng ý, 0 = bỏ phiếu)
+// @Tags Tags
+// @Security BearerAuth
+// @Param storyId path string true "Story ID"
+// @Param tagId path string true "Tag ID"
+// @Param request body VoteTagRequest true "Vote"
+// @Success 200 {object} response.Response
+// @Router /api/tags/story/{storyId}/{tagId}/vote [post]
+func (h *TagHandler) VoteTag(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Unauthorized(c, "Chưa đăng nhập")
+		return
+	}
+
+	storyID, err := uuid.Parse(c.Param("storyId"))
+	if err != nil {
+		response.BadRequest(c, "Story ID không hợp lệ")
+		return
+	}
+	tagID, err := uuid.Parse(c.Param("tagId"))
+	if err != nil {
+		response.BadRequest(c, "Tag ID không hợp lệ")
+		return
+	}
+
+	var req VoteTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Dữ liệu không hợp lệ")
+		return
+	}
+
+	if err := h.tagService.VoteTag(storyID, tagID, userID.(uuid.UUID), *req.Value, showMatureContent(c)); err != nil {
+		if respondContentWarning(c, err) {
+			return
+		}
+		response.BadRequest(c, err.Error())
+		return
+	}
+	response.Oke(c, gin.H{"message": "Đã ghi nhận phiếu"})
+}
+
+// ============ ADMIN ENDPOINTS ============
+
+// GetAllTagsAdmin godoc
+// @Summary Danh sách tag, gồm alias và tag bị cấm (Admin)
+// @Tags Admin Tags
+// @Security BearerAuth
+// @Param search query string false "Tên tag"
+// @Param status query string false "active, banned"
+// @Param page query int false "Page" default(1)
+// @Param limit query int false "Limit" default(20)
+// @Success 200 {object} response.Response
+// @Router /api/admin/tags [get]
+func (h *TagHandler) GetAllTagsAdmin(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	tags, total, err := h.tagService.GetAllTags(c.Query("search"), c.Query("status"), page, limit)
+	if err != nil {
+		response.InternalServerError(c, "Không thể lấy danh sách tag")
+		return
+	}
+	response.PaginatedResponse(c, tags, page, limit, total)
+}
+
+// CreateTag godoc
+// @Summary Tạo tag (Admin)
+// @Tags Admin Tags
+// @Security BearerAuth
+// @Param request body TagRequest true "Tag data"
+// @Success 201 {object} response.Response
+// @Router /api/admin/tags [post]
+func (h *TagHandler) CreateTag(c *gin.Context) {
+	var req TagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Dữ liệu không hợp lệ: "+err.Error())
+		return
+	}
+
+	tag, err := h.tagService.CreateTag(req.Name, req.Description)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrTagNameInvalid):
+			response.BadRequest(c, err.Error())
+		case errors.Is(err, services.ErrTagNameTaken):
+			response.Conflict(c, err.Error())
+		default:
+			response.InternalServerError(c, err.Error())
+		}
+		return
+	}
+	response.Created(c, tag)
+}
+
+// UpdateTag godoc
+// @Summary Cập nhật tag (Admin)
+// @Tags Admin Tags
+// @Security BearerAuth
+// @Param id path string true "Tag ID"
+// @Param request body TagRequest true "Tag data"
+// @Success 200 {object} response.Response
+// @Router /api/admin/tags/{id} [put]
+func (h *TagHandler) UpdateTag(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "ID không hợp lệ")
+		return
+	}
+
+	var req TagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Dữ liệu không hợp lệ: "+err.Error())
+		return
+	}
+
+	tag, err := h.tagService.UpdateTag(id, req.Name, req.Description)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	response.Oke(c, tag)
+}
+
+// AddTagAlias godoc
+// @Summary Thêm tên khác cho tag (Admin)
+// @Tags Admin Tags
+// @Security BearerAuth
+// @Param id path string true "Tag ID"
+// @Param request body ProposeTagRequest true "Alias name"
+// @Success 201 {object} response.Response
+// @Router /api/admin/tags/{id}/aliases [post]
+func (h *TagHandler) AddTagAlias(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "ID không hợp lệ")
+		return
+	}
+
+	var req ProposeTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Dữ liệu không hợp lệ: "+err.Error())
+		return
+	}
+
+	alias, err := h.tagService.AddAlias(id, req.Name)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	response.Created(c, alias)
+}
+
+// MergeTag godoc
+// @Summary Gộp tag nguồn vào tag này, tag nguồn thành alias (Admin)
+// @Tags Admin Tags
+// @Security BearerAuth
+// @Param id path string true "Target Tag ID"
+// @Param request body MergeTagRequest true "Source tag"
+// @Success 200 {object} response.Response
+// @Router /api/admin/tags/{id}/merge [post]
+func (h *TagHandler) MergeTag(c *gin.Context) {
+	targetID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "ID không hợp lệ")
+		return
+	}
+
+	var req MergeTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Dữ liệu không hợp lệ: "+err.Error())
+		return
+	}
+	sourceID, err := uuid.Parse(req.SourceID)
+	if err != nil {
+		response.BadRequest(c, "Source ID không hợp lệ")
+		return
+	}
+
+	if err := h.tagService.MergeTags(targetID, sourceID); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	response.Oke(c, gin.H{"message": "Đã gộp tag"})
+}
+
+// BanTag godoc
+// @Summary Cấm tag (Admin)
+// @Tags Admin Tags
+// @Security BearerAuth
+// @Param id path string true "Tag ID"
+// @Success 200 {object} response.Response
+// @Router /api/admin/tags/{id}/ban [post]
+func (h *TagHandler) BanTag(c *gin.Context) {
+	h.setTagBanned(c, true)
+}
+
+// UnbanTag godoc
+// @Summary Bỏ cấm tag (Admin)
+// @Tags Admin Tags
+// @Security BearerAuth
+// @Param id path string true "Tag ID"
+// @Success 200 {object} response.Response
+// @Router /api/admin/tags/{id}/unban [post]
+func (h *TagHandler) UnbanTag(c *gin.Context) {
+	h.setTagBanned(c, false)
+}
+
+func (h *TagHandler) setTagBanned(c *gin.Context, banned bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "ID không hợp lệ")
+		return
+	}
+
+	tag, err := h.tagService.SetTagBanned(id, banned)
+	if err != nil {
+		response.NotFound(c, err.Error())
+		return
+	}
+	response.Oke(c, tag)
+}
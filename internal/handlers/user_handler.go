@@ -1,7 +1,10 @@
 package handlers
 
 import (
+	"nekozanedex/internal/middleware"
+	"nekozanedex/internal/models"
 	"nekozanedex/internal/repositories"
+	"nekozanedex/internal/services"
 	"nekozanedex/pkg/response"
 	"strconv"
 
@@ -11,15 +14,16 @@ import (
 )
 
 type UserHandler struct {
-	userRepo repositories.UserRepository
+	userRepo    repositories.UserRepository
+	roleService services.RoleService
 }
 
-func NewUserHandler(userRepo repositories.UserRepository) *UserHandler {
-	return &UserHandler{userRepo: userRepo}
+func NewUserHandler(userRepo repositories.UserRepository, roleService services.RoleService) *UserHandler {
+	return &UserHandler{userRepo: userRepo, roleService: roleService}
 }
 
 type UpdateRoleRequest struct {
-	Role string `json:"role" binding:"required,oneof=admin reader"`
+	Role string `json:"role" binding:"required,max=20"` // Tên role trong bảng roles
 }
 type UpdateStatusRequest struct {
 	IsActive bool `json:"is_active"`
@@ -142,8 +146,13 @@ func (h *UserHandler) UpdateUserRole(c *gin.Context) {
 		return
 	}
 
-	if user.Role == "admin" && req.Role == "reader" {
-		response.Forbidden(c, "Không thể hạ quyền Admin xuống Reader")
+	if err := h.roleService.CheckRoleAssignment(user.Role, req.Role, middleware.Permissions(c)); err != nil {
+		respondRoleError(c, err)
+		return
+	}
+
+	if user.Role == models.RoleAdmin && req.Role != models.RoleAdmin {
+		response.Forbidden(c, "Không thể hạ quyền Admin")
 		return
 	}
 
@@ -189,7 +198,7 @@ func (h *UserHandler) ToggleUserStatus(c *gin.Context) {
 		return
 	}
 
-	if user.Role == "admin" && !req.IsActive {
+	if user.Role == models.RoleAdmin && !req.IsActive {
 		response.Forbidden(c, "Không thể vô hiệu hóa tài khoản Admin")
 		return
 	}
@@ -210,7 +219,7 @@ func (h *UserHandler) ToggleUserStatus(c *gin.Context) {
 type AdminUpdateUserRequest struct {
 	Username string `json:"username" binding:"omitempty,min=3,max=50"`
 	Email    string `json:"email" binding:"omitempty,email"`
-	Role     string `json:"role" binding:"omitempty,oneof=admin reader"`
+	Role     string `json:"role" binding:"omitempty,max=20"`
 }
 
 type AdminResetPasswordRequest struct {
@@ -244,9 +253,15 @@ func (h *UserHandler) AdminUpdateUser(c *gin.Context) {
 		return
 	}
 
-	if user.Role == "admin" && req.Role == "reader" {
-		response.Forbidden(c, "Không thể hạ quyền Admin xuống Reader")
-		return
+	if req.Role != "" {
+		if err := h.roleService.CheckRoleAssignment(user.Role, req.Role, middleware.Permissions(c)); err != nil {
+			respondRoleError(c, err)
+			return
+		}
+		if user.Role == models.RoleAdmin && req.Role != models.RoleAdmin {
+			response.Forbidden(c, "Không thể hạ quyền Admin")
+			return
+		}
 	}
 
 	if req.Username != "" {
@@ -18,12 +18,13 @@ func NewUserSettingsHandler(settingsService services.UserSettingsService) *UserS
 }
 
 type UpdateSettingsRequest struct {
-	Theme           string  `json:"theme"`
-	FontSize        int     `json:"font_size"`
-	FontFamily      string  `json:"font_family"`
-	LineHeight      float64 `json:"line_height"`
-	ReadingBg       string  `json:"reading_bg"`
-	AutoScrollSpeed int     `json:"auto_scroll_speed"`
+	Theme             string  `json:"theme"`
+	FontSize          int     `json:"font_size"`
+	FontFamily        string  `json:"font_family"`
+	LineHeight        float64 `json:"line_height"`
+	ReadingBg         string  `json:"reading_bg"`
+	AutoScrollSpeed   int     `json:"auto_scroll_speed"`
+	ShowMatureContent *bool   `json:"show_mature_content"` // nil = giữ nguyên
 }
 
 // GetMySettings godoc
@@ -105,7 +106,7 @@ func (h *UserSettingsHandler) UpdateMySettings(c *gin.Context) {
 		AutoScrollSpeed: req.AutoScrollSpeed,
 	}
 
-	settings, err := h.settingsService.UpdateSettings(userID.(uuid.UUID), updates)
+	settings, err := h.settingsService.UpdateSettings(userID.(uuid.UUID), updates, req.ShowMatureContent)
 	if err != nil {
 		response.InternalServerError(c, "Không thể cập nhật settings")
 		return
@@ -113,3 +114,8 @@ func (h *UserSettingsHandler) UpdateMySettings(c *gin.Context) {
 
 	response.Oke(c, settings)
 }
+
+// ShowMatureContent - User đã bật hiển thị nội dung người lớn chưa (dùng cho MatureContentMiddleware)
+func (h *UserSettingsHandler) ShowMatureContent(userID uuid.UUID) bool {
+	return h.settingsService.ShowMatureContent(userID)
+}
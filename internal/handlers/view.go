@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// viewer - Thông tin người xem dùng cho fair view counting và analytics
+type viewer struct {
+	UserID   *uuid.UUID
+	IP       string
+	Referrer *string
+}
+
+// viewerFromContext - Lấy userID (nếu có OptionalAuth), IP và host nguồn truy cập
+// Nguồn lấy từ ?ref= (frontend SPA tự gửi) rồi mới tới header Referer
+func viewerFromContext(c *gin.Context) viewer {
+	v := viewer{IP: c.ClientIP()}
+	if uid, exists := c.Get("user_id"); exists {
+		if id, ok := uid.(uuid.UUID); ok {
+			v.UserID = &id
+		}
+	}
+
+	ref := c.Query("ref")
+	if ref == "" {
+		ref = c.GetHeader("Referer")
+	}
+	if host := referrerHost(ref, c.Request.Host); host != "" {
+		v.Referrer = &host
+	}
+	return v
+}
+
+// referrerHost - Chuẩn hóa referrer về host (bỏ www., lowercase), bỏ qua truy cập nội bộ
+func referrerHost(ref, selfHost string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return ""
+	}
+	host := ref
+	if u, err := url.Parse(ref); err == nil && u.Host != "" {
+		host = u.Hostname()
+	}
+	host = strings.TrimPrefix(strings.ToLower(host), "www.")
+	if host == "" || strings.ContainsAny(host, "/ ") || host == strings.TrimPrefix(strings.ToLower(hostOnly(selfHost)), "www.") {
+		return ""
+	}
+	if len(host) > 255 {
+		host = host[:255]
+	}
+	return host
+}
+
+// hostOnly - Bỏ port khỏi "host:port"
+func hostOnly(hostport string) string {
+	if i := strings.LastIndex(hostport, ":"); i > 0 && !strings.Contains(hostport[i:], "]") {
+		return hostport[:i]
+	}
+	return hostport
+}
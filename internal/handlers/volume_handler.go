@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"nekozanedex/internal/services"
+	"nekozanedex/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type VolumeHandler struct {
+	volumeService services.VolumeService
+}
+
+func NewVolumeHandler(volumeService services.VolumeService) *VolumeHandler {
+	return &VolumeHandler{volumeService: volumeService}
+}
+
+// VolumeRequest - DTO cho tạo/cập nhật tập
+type VolumeRequest struct {
+	Number      int     `json:"number"`
+	Title       *string `json:"title" binding:"omitempty,max=255"`
+	Description *string `json:"description"`
+	CoverURL    *string `json:"cover_url"` // URL từ /admin/media, chuỗi rỗng để bỏ ảnh bìa
+}
+
+// VolumeChaptersRequest - Danh sách chapter thuộc tập (thay thế toàn bộ)
+type VolumeChaptersRequest struct {
+	ChapterIDs []string `json:"chapter_ids"`
+}
+
+func (req VolumeRequest) toInput() services.VolumeInput {
+	return services.VolumeInput{
+		Number:      req.Number,
+		Title:       req.Title,
+		Description: req.Description,
+		CoverURL:    req.CoverURL,
+	}
+}
+
+// GetStoryVolumes godoc
+// @Summary Danh sách tập của truyện (Admin)
+// @Tags Admin - Volumes
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Story ID"
+// @Success 200 {object} response.Response
+// @Router /api/admin/stories/{id}/volumes [get]
+func (h *VolumeHandler) GetStoryVolumes(c *gin.Context) {
+	storyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Story ID không hợp lệ")
+		return
+	}
+
+	volumes, err := h.volumeService.GetVolumes(storyID)
+	if err != nil {
+		response.NotFound(c, err.Error())
+		return
+	}
+	response.Oke(c, volumes)
+}
+
+// CreateVolume godoc
+// @Summary Tạo tập mới (Admin)
+// @Tags Admin - Volumes
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Story ID"
+// @Param body body VolumeRequest true "Volume Info"
+// @Success 201 {object} response.Response
+// @Router /api/admin/stories/{id}/volumes [post]
+func (h *VolumeHandler) CreateVolume(c *gin.Context) {
+	storyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Story ID không hợp lệ")
+		return
+	}
+
+	var req VolumeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Dữ liệu không hợp lệ")
+		return
+	}
+
+	volume, err := h.volumeService.CreateVolume(storyID, req.toInput())
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	response.Created(c, volume)
+}
+
+// UpdateVolume godoc
+// @Summary Cập nhật tập (Admin)
+// @Tags Admin - Volumes
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Volume ID"
+// @Param body body VolumeRequest true "Volume Info"
+// @Success 200 {object} response.Response
+// @Router /api/admin/volumes/{id} [put]
+func (h *VolumeHandler) UpdateVolume(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "ID không hợp lệ")
+		return
+	}
+
+	var req VolumeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Dữ liệu không hợp lệ")
+		return
+	}
+
+	volume, err := h.volumeService.UpdateVolume(id, req.toInput())
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	response.Oke(c, volume)
+}
+
+// DeleteVolume godoc
+// @Summary Xóa tập, chapters trở về chưa phân tập (Admin)
+// @Tags Admin - Volumes
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Volume ID"
+// @Success 200 {object} response.Response
+// @Router /api/admin/volumes/{id} [delete]
+func (h *VolumeHandler) DeleteVolume(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "ID không hợp lệ")
+		return
+	}
+
+	if err := h.volumeService.DeleteVolume(id); err != nil {
+		response.NotFound(c, err.Error())
+		return
+	}
+	response.Oke(c, gin.H{"message": "Xóa tập thành công"})
+}
+
+// SetVolumeChapters godoc
+// @Summary Gán chapters cho tập, chapter không có trong danh sách sẽ bị gỡ khỏi tập (Admin)
+// @Tags Admin - Volumes
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Volume ID"
+// @Param body body VolumeChaptersRequest true "Chapter IDs"
+// @Success 200 {object} response.Response
+// @Router /api/admin/volumes/{id}/chapters [put]
+func (h *VolumeHandler) SetVolumeChapters(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "ID không hợp lệ")
+		return
+	}
+
+	var req VolumeChaptersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Dữ liệu không hợp lệ")
+		return
+	}
+
+	chapterIDs := make([]uuid.UUID, 0, len(req.ChapterIDs))
+	for _, raw := range req.ChapterIDs {
+		chapterID, err := uuid.Parse(raw)
+		if err != nil {
+			response.BadRequest(c, "Chapter ID không hợp lệ: "+raw)
+			return
+		}
+		chapterIDs = append(chapterIDs, chapterID)
+	}
+
+	assigned, err := h.volumeService.SetVolumeChapters(id, chapterIDs)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	response.Oke(c, gin.H{
+		"message":  "Cập nhật chapters của tập thành công",
+		"assigned": assigned,
+	})
+}
+
+// UploadVolumeCover godoc
+// @Summary Upload ảnh bìa tập (Admin)
+// @Tags Admin - Volumes
+// @Security BearerAuth
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "Volume ID"
+// @Param image formData file true "Image file"
+// @Success 200 {object} response.Response
+// @Router /api/admin/volumes/{id}/cover [post]
+func (h *VolumeHandler) UploadVolumeCover(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "ID không hợp lệ")
+		return
+	}
+
+	file, header, err := c.Request.FormFile("image")
+	if err != nil {
+		response.BadRequest(c, "Không tìm thấy file ảnh")
+		return
+	}
+	defer file.Close()
+
+	if header.Size > 10*1024*1024 {
+		response.BadRequest(c, "File quá lớn (tối đa 10MB)")
+		return
+	}
+
+	volume, err := h.volumeService.UploadCover(id, file, header.Filename)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	response.Oke(c, volume)
+}
@@ -4,6 +4,7 @@ import (
 	"strings"
 
 	"nekozanedex/internal/config"
+	"nekozanedex/internal/models"
 	"nekozanedex/internal/utils"
 	"nekozanedex/pkg/response"
 
@@ -40,45 +41,18 @@ func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 		c.Set("user_id", claims.UserID)
 		c.Set("username", claims.Username)
 		c.Set("role", claims.Role)
+		c.Set("permissions", claims.Permissions)
 
 		c.Next() // Chạy middleware tiếp theo
 	}
 }
 
-// Admin Middleware - Chỉ cho phép admin
-func AdminMiddleware() gin.HandlerFunc {
+// RequirePermission - Chỉ cho phép user có ít nhất một trong các quyền (lấy từ JWT)
+// Usage: RequirePermission("comments.moderate") hoặc RequirePermission("stories.manage", "chapters.manage")
+func RequirePermission(permissions ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		role, exists := c.Get("role")
-		if !exists || role != "admin" {
-			response.Forbidden(c, "Cần Quyền Admin")
-			c.Abort()
-			return
-		}
-		c.Next() // Chạy middleware tiếp theo
-	}
-}
-
-// RoleMiddleware - Cho phép nhiều roles (linh hoạt hơn AdminMiddleware)
-// Usage: RoleMiddleware("admin") hoặc RoleMiddleware("reader", "admin")
-func RoleMiddleware(allowedRoles ...string) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		role, exists := c.Get("role")
-		if !exists {
-			response.Forbidden(c, "Không tìm thấy role trong token")
-			c.Abort()
-			return
-		}
-
-		roleStr, ok := role.(string)
-		if !ok {
-			response.Forbidden(c, "Role không hợp lệ")
-			c.Abort()
-			return
-		}
-
-		// Kiểm tra role có trong danh sách cho phép không
-		for _, allowedRole := range allowedRoles {
-			if roleStr == allowedRole {
+		for _, permission := range permissions {
+			if HasPermission(c, permission) {
 				c.Next()
 				return
 			}
@@ -89,23 +63,43 @@ func RoleMiddleware(allowedRoles ...string) gin.HandlerFunc {
 	}
 }
 
+// HasPermission - User hiện tại có quyền không (dùng trong handler cho kiểm tra theo ngữ cảnh)
+func HasPermission(c *gin.Context, permission string) bool {
+	return models.HasPermission(Permissions(c), permission)
+}
+
+// Permissions - Quyền của user hiện tại (lấy từ JWT), nil nếu chưa đăng nhập
+func Permissions(c *gin.Context) []string {
+	value, exists := c.Get("permissions")
+	if !exists {
+		return nil
+	}
+	permissions, _ := value.([]string)
+	return permissions
+}
+
 // Optional Auth Middleware - Auth không bắt buộc (cho guest)
 func OptionalAuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
-		
-		if authHeader == "" {
+		var tokenString string
+		if strings.HasPrefix(authHeader, "Bearer ") {
+			tokenString = strings.TrimPrefix(authHeader, "Bearer ")
+		} else {
+			// Giống AuthMiddleware: frontend dùng cookie httpOnly
+			tokenString, _ = c.Cookie("access_token")
+		}
+
+		if tokenString == "" {
 			c.Next()
 			return
 		}
 
-		parts := strings.Split(authHeader, " ")
-		if len(parts) == 2 && parts[0] == "Bearer" {
-			if claims, err := utils.VerifyAccessToken(parts[1], cfg.Jwt.AccessSecret); err == nil {
-				c.Set("user_id", claims.UserID)
-				c.Set("username", claims.Username)
-				c.Set("role", claims.Role)
-			}
+		if claims, err := utils.VerifyAccessToken(tokenString, cfg.Jwt.AccessSecret); err == nil {
+			c.Set("user_id", claims.UserID)
+			c.Set("username", claims.Username)
+			c.Set("role", claims.Role)
+			c.Set("permissions", claims.Permissions)
 		}
 
 		c.Next() // Chạy middleware tiếp theo
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// MatureContentMiddleware - Gắn "show_mature" vào context theo setting của user (khách luôn false)
+// Dùng sau OptionalAuthMiddleware hoặc AuthMiddleware
+func MatureContentMiddleware(showMature func(userID uuid.UUID) bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		show := false
+		if uid, exists := c.Get("user_id"); exists {
+			if id, ok := uid.(uuid.UUID); ok {
+				show = showMature(id)
+			}
+		}
+		c.Set("show_mature", show)
+		c.Next()
+	}
+}
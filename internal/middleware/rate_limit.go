@@ -8,6 +8,7 @@ import (
 	"nekozanedex/pkg/response"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 // RateLimiter - In-memory rate limiter - Tối ưu hóa hiệu suất
@@ -16,6 +17,7 @@ type RateLimiter struct {
 	mu       sync.RWMutex
 	limit    int           // Số requests tối đa
 	window   time.Duration // Thời gian window
+	keyFunc  func(c *gin.Context) string
 }
 
 type clientInfo struct {
@@ -29,6 +31,7 @@ func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
 		requests: make(map[string]*clientInfo),
 		limit:    limit,
 		window:   window,
+		keyFunc:  clientIPKey,
 	}
 
 	// Cleanup routine - xóa các entries hết hạn
@@ -37,20 +40,41 @@ func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
 	return rl
 }
 
+// NewUserRateLimiter - Rate limiter đếm theo user đã đăng nhập (chưa đăng nhập thì theo IP)
+// Dùng sau AuthMiddleware/OptionalAuthMiddleware để đổi IP không lách được giới hạn
+func NewUserRateLimiter(limit int, window time.Duration) *RateLimiter {
+	rl := NewRateLimiter(limit, window)
+	rl.keyFunc = userKey
+	return rl
+}
+
+func clientIPKey(c *gin.Context) string {
+	return c.ClientIP()
+}
+
+func userKey(c *gin.Context) string {
+	if uid, exists := c.Get("user_id"); exists {
+		if id, ok := uid.(uuid.UUID); ok {
+			return "user:" + id.String()
+		}
+	}
+	return "ip:" + c.ClientIP()
+}
+
 // Middleware - Rate limiting middleware
 func (rl *RateLimiter) Middleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ip := c.ClientIP()
+		key := rl.keyFunc(c)
 
 		rl.mu.Lock()
 
 		// Lấy hoặc tạo client info
-		client, exists := rl.requests[ip]
+		client, exists := rl.requests[key]
 		now := time.Now()
 
 		if !exists || now.After(client.resetTime) {
 			// Client mới hoặc đã reset
-			rl.requests[ip] = &clientInfo{
+			rl.requests[key] = &clientInfo{
 				count:     1,
 				resetTime: now.Add(rl.window),
 			}
@@ -96,9 +120,9 @@ func (rl *RateLimiter) cleanup() {
 	for range ticker.C {
 		rl.mu.Lock()
 		now := time.Now()
-		for ip, client := range rl.requests {
+		for key, client := range rl.requests {
 			if now.After(client.resetTime) {
-				delete(rl.requests, ip)
+				delete(rl.requests, key)
 			}
 		}
 		rl.mu.Unlock()
@@ -137,6 +161,18 @@ func LikeRateLimiter() gin.HandlerFunc {
 	return limiter.Middleware()
 }
 
+// DownloadRateLimiter - 20 lượt tải/giờ cho mỗi user (CBZ, chống cào dữ liệu hàng loạt)
+func DownloadRateLimiter() gin.HandlerFunc {
+	limiter := NewUserRateLimiter(20, 1*time.Hour)
+	return limiter.Middleware()
+}
+
+// ExportRateLimiter - 30 lượt xuất EPUB/giờ cho mỗi user hoặc IP (mỗi khoảng chapter là một bản dựng riêng)
+func ExportRateLimiter() gin.HandlerFunc {
+	limiter := NewUserRateLimiter(30, 1*time.Hour)
+	return limiter.Middleware()
+}
+
 // HealthCheckSkip - Skip health check từ rate limiting
 func HealthCheckSkip(c *gin.Context) bool {
 	return c.Request.URL.Path == "/health"
@@ -13,25 +13,65 @@ type Chapter struct {
 	ID            uuid.UUID      `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
 	StoryID       uuid.UUID      `json:"story_id" gorm:"type:uuid;not null;index"`
 	ChapterNumber int            `json:"chapter_number" gorm:"not null"`
-	ChapterLabel  *string        `json:"chapter_label" gorm:"size:50"`               // Tên hiển thị tùy chỉnh ("1.3", "Extra 1", "Omake")
+	ChapterLabel  *string        `json:"chapter_label" gorm:"size:50"`                // Tên hiển thị tùy chỉnh ("1.3", "Extra 1", "Omake")
 	ChapterType   string         `json:"chapter_type" gorm:"default:regular;size:20"` // regular, extra, bonus, omake
 	Ordering      float64        `json:"ordering" gorm:"default:0"`                   // Thứ tự sort (1.0, 1.5, 2.0)
 	Title         string         `json:"title" gorm:"not null;size:255"`
-	Content       string         `json:"content" gorm:"type:text;default:''"` // Text content (for novel-style)
-	Images        datatypes.JSON `json:"images" gorm:"type:jsonb"`            // []string - URLs of manga pages
+	Content       string         `json:"content" gorm:"type:text;default:''"`                // Text content (for novel-style)
+	ContentFormat string         `json:"content_format" gorm:"default:plain;size:20"`        // plain, markdown, html
+	ContentHTML   string         `json:"content_html,omitempty" gorm:"type:text;default:''"` // Content đã render + lọc, dựng khi lưu
+	Notes         datatypes.JSON `json:"notes" gorm:"type:jsonb"`                            // []ChapterNote - ghi chú dịch giả/chú thích
+	Images        datatypes.JSON `json:"images" gorm:"type:jsonb"`                           // []string - URLs of manga pages
 	PageCount     int            `json:"page_count" gorm:"default:0"`
 	IsPublished   bool           `json:"is_published" gorm:"default:false"`
 	PublishedAt   *time.Time     `json:"published_at"`
 	ScheduledAt   *time.Time     `json:"scheduled_at"` // Scheduled publishing
 	ViewCount     int64          `json:"view_count" gorm:"default:0"`
+	GroupID       *uuid.UUID     `json:"group_id" gorm:"type:uuid;index"`  // Nhóm dịch được ghi công (mặc định nhóm của truyện)
+	VolumeID      *uuid.UUID     `json:"volume_id" gorm:"type:uuid;index"` // Tập chứa chapter (nil = chưa phân tập)
 	CreatedAt     time.Time      `json:"created_at"`
 	UpdatedAt     time.Time      `json:"updated_at"`
 	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
 	// Relations
 	Story    Story     `json:"story,omitempty" gorm:"foreignKey:StoryID"`
+	Group    *Group    `json:"group,omitempty" gorm:"foreignKey:GroupID"`
 	Comments []Comment `json:"comments,omitempty" gorm:"foreignKey:ChapterID"`
 }
 
+// Content formats - Định dạng của Chapter.Content
+const (
+	ContentFormatPlain    = "plain"
+	ContentFormatMarkdown = "markdown"
+	ContentFormatHTML     = "html"
+)
+
+// ContentFormats - Danh sách định dạng hợp lệ
+var ContentFormats = []string{ContentFormatPlain, ContentFormatMarkdown, ContentFormatHTML}
+
+// IsValidContentFormat - Kiểm tra định dạng nội dung hợp lệ
+func IsValidContentFormat(format string) bool {
+	return containsString(ContentFormats, format)
+}
+
+// Note types - Loại ghi chú trong chapter
+const (
+	NoteTypeTranslator = "translator" // Ghi chú của dịch giả
+	NoteTypeFootnote   = "footnote"   // Chú thích của tác giả/bản gốc
+)
+
+// IsValidNoteType - Kiểm tra loại ghi chú hợp lệ
+func IsValidNoteType(noteType string) bool {
+	return noteType == NoteTypeTranslator || noteType == NoteTypeFootnote
+}
+
+// ChapterNote - Ghi chú gắn vào nội dung bằng marker [^id], client hiện dạng popover
+type ChapterNote struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Content string `json:"content"`        // Cùng định dạng với nội dung chapter
+	HTML    string `json:"html,omitempty"` // Đã render + lọc, dựng khi lưu
+}
+
 // TableName - custom table name
 func (Chapter) TableName() string {
 	return "chapters"
@@ -62,4 +102,23 @@ func (c *Chapter) SetImagesFromSlice(images []string) error {
 	c.Images = data
 	c.PageCount = len(images)
 	return nil
-}
\ No newline at end of file
+}
+
+// GetNotes - Helper to get notes as []ChapterNote
+func (c *Chapter) GetNotes() []ChapterNote {
+	var notes []ChapterNote
+	if c.Notes != nil {
+		_ = json.Unmarshal(c.Notes, &notes)
+	}
+	return notes
+}
+
+// SetNotes - Helper to set notes from []ChapterNote
+func (c *Chapter) SetNotes(notes []ChapterNote) error {
+	data, err := json.Marshal(notes)
+	if err != nil {
+		return err
+	}
+	c.Notes = data
+	return nil
+}
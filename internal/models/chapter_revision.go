@@ -0,0 +1,68 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// ChapterRevision - Bản lưu bất biến của chapter sau mỗi lần tạo/sửa/khôi phục
+type ChapterRevision struct {
+	ID             uuid.UUID      `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	ChapterID      uuid.UUID      `json:"chapter_id" gorm:"type:uuid;not null;uniqueIndex:idx_chapter_revision_number"`
+	RevisionNumber int            `json:"revision_number" gorm:"not null;uniqueIndex:idx_chapter_revision_number"`
+	EditorID       *uuid.UUID     `json:"editor_id" gorm:"type:uuid;index"` // nil = hệ thống (bản gốc trước khi có lịch sử)
+	Title          string         `json:"title" gorm:"not null;size:255"`
+	Content        string         `json:"content,omitempty" gorm:"type:text;default:''"`
+	ContentFormat  string         `json:"content_format" gorm:"default:plain;size:20"`
+	Notes          datatypes.JSON `json:"notes,omitempty" gorm:"type:jsonb"`
+	Images         datatypes.JSON `json:"images,omitempty" gorm:"type:jsonb"`
+	PageCount      int            `json:"page_count" gorm:"default:0"`
+	RestoredFrom   *int           `json:"restored_from"` // Số revision được khôi phục (nếu có)
+	CreatedAt      time.Time      `json:"created_at"`
+
+	// Relations
+	Editor *User `json:"editor,omitempty" gorm:"foreignKey:EditorID"`
+}
+
+func (ChapterRevision) TableName() string {
+	return "chapter_revisions"
+}
+
+func (r *ChapterRevision) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// BeforeUpdate - Revision là bất biến
+func (r *ChapterRevision) BeforeUpdate(tx *gorm.DB) error {
+	return gorm.ErrInvalidData
+}
+
+// GetImagesSlice - Helper to get images as []string
+func (r *ChapterRevision) GetImagesSlice() []string {
+	var images []string
+	if r.Images != nil {
+		_ = json.Unmarshal(r.Images, &images)
+	}
+	return images
+}
+
+// NewChapterRevision - Chụp trạng thái hiện tại của chapter
+func NewChapterRevision(chapter *Chapter, editorID *uuid.UUID) *ChapterRevision {
+	return &ChapterRevision{
+		ChapterID:     chapter.ID,
+		EditorID:      editorID,
+		Title:         chapter.Title,
+		Content:       chapter.Content,
+		ContentFormat: chapter.ContentFormat,
+		Notes:         chapter.Notes,
+		Images:        chapter.Images,
+		PageCount:     chapter.PageCount,
+	}
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Group member roles - Vai trò trong nhóm dịch
+// owner: quản lý thành viên + thông tin nhóm, editor: xóa/xuất bản/hẹn giờ chapter, uploader: tạo/sửa chapter
+const (
+	GroupRoleOwner    = "owner"
+	GroupRoleEditor   = "editor"
+	GroupRoleUploader = "uploader"
+)
+
+// groupRoleRanks - Quyền tăng dần, role cao hơn có mọi quyền của role thấp hơn
+var groupRoleRanks = map[string]int{
+	GroupRoleUploader: 1,
+	GroupRoleEditor:   2,
+	GroupRoleOwner:    3,
+}
+
+// IsValidGroupRole - Kiểm tra role thành viên nhóm có hợp lệ không
+func IsValidGroupRole(role string) bool {
+	_, ok := groupRoleRanks[role]
+	return ok
+}
+
+// GroupRoleAtLeast - role có quyền >= minRole không
+func GroupRoleAtLeast(role, minRole string) bool {
+	return groupRoleRanks[role] >= groupRoleRanks[minRole] && groupRoleRanks[role] > 0
+}
+
+// Group - Nhóm dịch, được ghi công trên truyện và chapter
+type Group struct {
+	ID          uuid.UUID      `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	Name        string         `json:"name" gorm:"not null;size:100"`
+	Slug        string         `json:"slug" gorm:"uniqueIndex;not null;size:120"`
+	Description *string        `json:"description"`
+	AvatarURL   *string        `json:"avatar_url"`
+	Website     *string        `json:"website" gorm:"size:255"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relations
+	Members []GroupMember `json:"members,omitempty" gorm:"foreignKey:GroupID"`
+}
+
+func (Group) TableName() string {
+	return "groups"
+}
+
+func (g *Group) BeforeCreate(tx *gorm.DB) error {
+	if g.ID == uuid.Nil {
+		g.ID = uuid.New()
+	}
+	return nil
+}
+
+// GroupMember - Thành viên nhóm dịch và vai trò
+type GroupMember struct {
+	GroupID   uuid.UUID `json:"group_id" gorm:"type:uuid;primaryKey"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;primaryKey;index"`
+	Role      string    `json:"role" gorm:"size:20;not null;default:uploader"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// Relations
+	Group *Group `json:"group,omitempty" gorm:"foreignKey:GroupID"`
+	User  *User  `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+func (GroupMember) TableName() string {
+	return "group_members"
+}
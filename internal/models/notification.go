@@ -9,13 +9,13 @@ import (
 
 type Notification struct {
 	ID        uuid.UUID `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
-	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;not null;index"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;not null;index;index:idx_notifications_user_created,priority:1"`
 	Type      string    `json:"type" gorm:"size:50;not null"` // new_chapter, reply, system
 	Title     string    `json:"title" gorm:"size:255;not null"`
 	Message   *string   `json:"message" gorm:"column:content"` // Map 'message' JSON to 'content' DB column
 	Link      *string   `json:"link"`                     // URL để navigate
 	IsRead    bool      `json:"is_read" gorm:"default:false;index"`
-	CreatedAt time.Time `json:"created_at"`
+	CreatedAt time.Time `json:"created_at" gorm:"index:idx_notifications_user_created,priority:2"` // Keyset pagination
 
 	// Relations
 	User User `json:"user,omitempty" gorm:"foreignKey:UserID"`
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Vai trò của người tham gia truyện
+const (
+	PersonRoleAuthor     = "author"
+	PersonRoleArtist     = "artist"
+	PersonRoleTranslator = "translator"
+)
+
+// PersonRoles - Danh sách vai trò hợp lệ
+var PersonRoles = []string{PersonRoleAuthor, PersonRoleArtist, PersonRoleTranslator}
+
+// Person - Tác giả / họa sĩ / dịch giả (một người có thể có nhiều vai trò)
+type Person struct {
+	ID        uuid.UUID      `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	Name      string         `json:"name" gorm:"not null;size:100;index"`
+	Slug      string         `json:"slug" gorm:"uniqueIndex;not null;size:120"`
+	Aliases   datatypes.JSON `json:"aliases" gorm:"type:jsonb"` // Cách viết khác ["Tên 1", "Tên 2"]
+	Bio       *string        `json:"bio"`
+	AvatarURL *string        `json:"avatar_url"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+func (Person) TableName() string {
+	return "people"
+}
+
+func (p *Person) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+// GetAliases - Helper to get aliases as []string
+func (p *Person) GetAliases() []string {
+	var aliases []string
+	if p.Aliases != nil {
+		_ = json.Unmarshal(p.Aliases, &aliases)
+	}
+	return aliases
+}
+
+// SetAliases - Helper to set aliases from []string
+func (p *Person) SetAliases(aliases []string) error {
+	data, err := json.Marshal(aliases)
+	if err != nil {
+		return err
+	}
+	p.Aliases = data
+	return nil
+}
+
+// StoryPerson - Liên kết truyện với người tham gia theo vai trò
+type StoryPerson struct {
+	StoryID   uuid.UUID `json:"story_id" gorm:"type:uuid;primaryKey"`
+	PersonID  uuid.UUID `json:"person_id" gorm:"type:uuid;primaryKey;index"`
+	Role      string    `json:"role" gorm:"primaryKey;size:20"` // author, artist, translator
+	CreatedAt time.Time `json:"created_at"`
+
+	// Relations
+	Story  *Story  `json:"story,omitempty" gorm:"foreignKey:StoryID;constraint:OnDelete:CASCADE"`
+	Person *Person `json:"person,omitempty" gorm:"foreignKey:PersonID;constraint:OnDelete:CASCADE"`
+}
+
+func (StoryPerson) TableName() string {
+	return "story_people"
+}
+
+// IsValidPersonRole - Kiểm tra vai trò hợp lệ
+func IsValidPersonRole(role string) bool {
+	return containsString(PersonRoles, role)
+}
@@ -0,0 +1,163 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Permissions - Quyền được kiểm tra bởi middleware.RequirePermission
+const (
+	PermAll              = "*"                 // Mọi quyền (admin)
+	PermCommentsWrite    = "comments.write"    // Bình luận
+	PermRatingsWrite     = "ratings.write"     // Đánh giá truyện
+	PermTagsVote         = "tags.vote"         // Đề xuất/vote tag cho truyện
+	PermCommentsModerate = "comments.moderate" // Ghim/xóa comment của người khác, xử lý báo cáo
+	PermStoriesManage    = "stories.manage"    // Tạo/sửa/xóa truyện, slug, quan hệ truyện
+	PermChaptersManage   = "chapters.manage"   // Tạo/sửa/xóa/xuất bản chapter
+	PermMediaUpload      = "media.upload"      // Upload ảnh bìa, ảnh chapter
+	PermGenresManage     = "genres.manage"
+	PermTagsManage       = "tags.manage"
+	PermPeopleManage     = "people.manage"
+	PermGroupsManage     = "groups.manage"
+	PermAnalyticsView    = "analytics.view"
+	PermUsersManage      = "users.manage"
+	PermRolesManage      = "roles.manage"
+)
+
+// Permissions - Danh sách quyền hợp lệ (không gồm "*")
+var Permissions = []string{
+	PermCommentsWrite,
+	PermRatingsWrite,
+	PermTagsVote,
+	PermCommentsModerate,
+	PermStoriesManage,
+	PermChaptersManage,
+	PermMediaUpload,
+	PermGenresManage,
+	PermTagsManage,
+	PermPeopleManage,
+	PermGroupsManage,
+	PermAnalyticsView,
+	PermUsersManage,
+	PermRolesManage,
+}
+
+// AdminPermissions - Quyền cho phép vào khu /admin (mọi quyền quản trị, trừ quyền của người đọc)
+var AdminPermissions = []string{
+	PermCommentsModerate,
+	PermStoriesManage,
+	PermChaptersManage,
+	PermMediaUpload,
+	PermGenresManage,
+	PermTagsManage,
+	PermPeopleManage,
+	PermGroupsManage,
+	PermAnalyticsView,
+	PermUsersManage,
+	PermRolesManage,
+}
+
+// Role mặc định, admin và reader là role hệ thống không xóa được
+const (
+	RoleAdmin     = "admin"
+	RoleReader    = "reader"
+	RoleModerator = "moderator"
+	RoleUploader  = "uploader"
+)
+
+// IsValidPermission - Kiểm tra quyền có trong danh sách không
+func IsValidPermission(permission string) bool {
+	return permission == PermAll || containsString(Permissions, permission)
+}
+
+// HasPermission - Danh sách quyền có chứa permission (hoặc "*") không
+func HasPermission(permissions []string, permission string) bool {
+	for _, p := range permissions {
+		if p == PermAll || p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// CanGrantPermissions - grantor có đủ mọi quyền trong granted (chỉ người có "*" mới cấp/thu hồi được "*")
+func CanGrantPermissions(granted, grantor []string) bool {
+	for _, p := range granted {
+		if !HasPermission(grantor, p) {
+			return false
+		}
+	}
+	return true
+}
+
+// Role - Vai trò người dùng = tập quyền, User.Role tham chiếu theo Name
+type Role struct {
+	ID          uuid.UUID      `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	Name        string         `json:"name" gorm:"uniqueIndex;not null;size:20"`
+	Description *string        `json:"description"`
+	Permissions datatypes.JSON `json:"permissions" gorm:"type:jsonb"` // ["comments.write", "chapters.manage"]
+	IsSystem    bool           `json:"is_system" gorm:"default:false"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+func (Role) TableName() string {
+	return "roles"
+}
+
+func (r *Role) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// GetPermissions - Helper to get permissions as []string
+func (r *Role) GetPermissions() []string {
+	permissions := []string{}
+	if r.Permissions != nil {
+		_ = json.Unmarshal(r.Permissions, &permissions)
+	}
+	return permissions
+}
+
+// SetPermissions - Helper to set permissions from []string
+func (r *Role) SetPermissions(permissions []string) error {
+	if permissions == nil {
+		permissions = []string{}
+	}
+	data, err := json.Marshal(permissions)
+	if err != nil {
+		return err
+	}
+	r.Permissions = data
+	return nil
+}
+
+// DefaultRoles - Role được tạo khi khởi động nếu chưa có
+func DefaultRoles() []Role {
+	defaults := []struct {
+		name        string
+		description string
+		system      bool
+		permissions []string
+	}{
+		{RoleAdmin, "Toàn quyền quản trị", true, []string{PermAll}},
+		{RoleReader, "Người đọc", true, []string{PermCommentsWrite, PermRatingsWrite, PermTagsVote}},
+		{RoleModerator, "Kiểm duyệt bình luận", false, []string{PermCommentsWrite, PermRatingsWrite, PermTagsVote, PermCommentsModerate}},
+		{RoleUploader, "Đăng và sửa chapter", false, []string{PermCommentsWrite, PermRatingsWrite, PermTagsVote, PermChaptersManage, PermMediaUpload}},
+	}
+
+	roles := make([]Role, 0, len(defaults))
+	for _, d := range defaults {
+		description := d.description
+		role := Role{Name: d.name, Description: &description, IsSystem: d.system}
+		_ = role.SetPermissions(d.permissions)
+		roles = append(roles, role)
+	}
+	return roles
+}
@@ -10,9 +10,10 @@ import (
 )
 
 type Story struct {
-	ID            uuid.UUID      `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	ID            uuid.UUID      `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid();index:idx_stories_updated_at_id,priority:2"`
 	Title         string         `json:"title" gorm:"not null;size:255"`
 	Slug          string         `json:"slug" gorm:"uniqueIndex;not null;size:255"`
+	SlugLocked    bool           `json:"slug_locked" gorm:"default:false"`         // Admin ghim slug, đổi tên không sinh slug mới
 	OriginalTitle *string        `json:"original_title" gorm:"size:255"`           // Tên gốc (JP/CN/KR)
 	AltTitles     datatypes.JSON `json:"alt_titles" gorm:"type:jsonb"`             // Tên phụ ["Tên 1", "Tên 2"]
 	Description   *string        `json:"description"`
@@ -26,18 +27,45 @@ type Story struct {
 	ReleaseYear   *int           `json:"release_year"`                             // Năm ra mắt
 	EndYear       *int           `json:"end_year"`                                 // Năm kết thúc (null = đang tiếp diễn)
 	Status        string         `json:"status" gorm:"default:ongoing;size:20"`    // ongoing, completed, hiatus
+	AgeRating     string         `json:"age_rating" gorm:"default:everyone;size:20;index"` // everyone, teen, mature, adult
+	GroupID       *uuid.UUID     `json:"group_id" gorm:"type:uuid;index"`          // Nhóm dịch phụ trách, thành viên nhóm được quản lý chapter
 	IsPublished   bool           `json:"is_published" gorm:"default:false"`
 	ViewCount     int64          `json:"view_count" gorm:"default:0"`
 	TotalChapters int            `json:"total_chapters" gorm:"default:0"`
 	Rating        *float64       `json:"rating" gorm:"type:decimal(3,2)"`          // Rating trung bình (0.00 - 5.00)
 	RatingCount   int            `json:"rating_count" gorm:"default:0"`            // Số lượt đánh giá
 	CreatedAt     time.Time      `json:"created_at"`
-	UpdatedAt     time.Time      `json:"updated_at"`
+	UpdatedAt     time.Time      `json:"updated_at" gorm:"index:idx_stories_updated_at_id,priority:1"` // Keyset pagination (updated_at, id)
 	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
 
 	// Relations
 	Chapters []Chapter `json:"chapters,omitempty" gorm:"foreignKey:StoryID"`
 	Genres   []Genre   `json:"genres,omitempty" gorm:"many2many:story_genres"`
+	People   []StoryPerson `json:"people,omitempty" gorm:"foreignKey:StoryID"` // Tác giả/họa sĩ/dịch giả đã liên kết
+	Group    *Group        `json:"group,omitempty" gorm:"foreignKey:GroupID"`
+
+	// Truyện liên quan theo loại quan hệ (sequel, adaptation...), không lưu DB
+	Related map[string][]Story `json:"related,omitempty" gorm:"-"`
+}
+
+// Age ratings - Phân loại độ tuổi của truyện
+const (
+	AgeRatingEveryone = "everyone"
+	AgeRatingTeen     = "teen"
+	AgeRatingMature   = "mature"
+	AgeRatingAdult    = "adult"
+)
+
+// MatureAgeRatings - Các mức bị ẩn khi người xem chưa bật "hiển thị nội dung người lớn"
+var MatureAgeRatings = []string{AgeRatingMature, AgeRatingAdult}
+
+// IsValidAgeRating - Kiểm tra age rating có hợp lệ không
+func IsValidAgeRating(rating string) bool {
+	switch rating {
+	case AgeRatingEveryone, AgeRatingTeen, AgeRatingMature, AgeRatingAdult:
+		return true
+	}
+	return false
 }
 
 // TableName - custom table name
@@ -69,4 +97,9 @@ func (s *Story) SetAltTitles(titles []string) error {
 	}
 	s.AltTitles = data
 	return nil
-}
\ No newline at end of file
+}
+
+// IsMature - Truyện thuộc nhóm nội dung người lớn (cần người xem bật hiển thị)
+func (s *Story) IsMature() bool {
+	return containsString(MatureAgeRatings, s.AgeRating)
+}
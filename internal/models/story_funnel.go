@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StoryChapterFunnel - Số người đọc đã đọc tới (ít nhất) từng chapter của truyện
+// Được job nền tính lại định kỳ từ story_reader_progress, reading_history và story_views cấp chapter
+type StoryChapterFunnel struct {
+	StoryID       uuid.UUID `json:"story_id" gorm:"type:uuid;primaryKey"`
+	ChapterID     uuid.UUID `json:"chapter_id" gorm:"type:uuid;primaryKey"`
+	ChapterNumber int       `json:"chapter_number" gorm:"not null"`
+	Readers       int64     `json:"readers" gorm:"default:0"`
+	ComputedAt    time.Time `json:"computed_at"`
+}
+
+func (StoryChapterFunnel) TableName() string {
+	return "story_chapter_funnels"
+}
+
+// StoryReaderProgress - Chapter xa nhất (theo thứ tự đọc) mỗi người đọc từng mở của một truyện
+// Được cập nhật khi rollup, trước khi raw story_views bị xóa, nên funnel không bị mất người đọc cũ
+type StoryReaderProgress struct {
+	StoryID   uuid.UUID `json:"story_id" gorm:"type:uuid;primaryKey"`
+	Reader    string    `json:"reader" gorm:"primaryKey;size:64"` // user_id nếu đăng nhập, không thì IP
+	ChapterID uuid.UUID `json:"chapter_id" gorm:"type:uuid;not null"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (StoryReaderProgress) TableName() string {
+	return "story_reader_progress"
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Ranking types - Loại bảng xếp hạng
+const (
+	RankingTypeViews     = "views"
+	RankingTypeRating    = "rating"
+	RankingTypeBookmarks = "bookmarks"
+)
+
+// Ranking periods - Chu kỳ bảng xếp hạng
+const (
+	RankingPeriodDaily   = "daily"
+	RankingPeriodWeekly  = "weekly"
+	RankingPeriodMonthly = "monthly"
+	RankingPeriodAllTime = "all_time"
+)
+
+var (
+	RankingTypes   = []string{RankingTypeViews, RankingTypeRating, RankingTypeBookmarks}
+	RankingPeriods = []string{RankingPeriodDaily, RankingPeriodWeekly, RankingPeriodMonthly, RankingPeriodAllTime}
+)
+
+// StoryRanking - Một dòng trong snapshot bảng xếp hạng theo ngày
+type StoryRanking struct {
+	ID           uuid.UUID `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	RankingType  string    `json:"ranking_type" gorm:"size:20;not null;uniqueIndex:idx_ranking_snapshot_rank,priority:1"`
+	Period       string    `json:"period" gorm:"size:20;not null;uniqueIndex:idx_ranking_snapshot_rank,priority:2"`
+	SnapshotDate time.Time `json:"snapshot_date" gorm:"type:date;not null;uniqueIndex:idx_ranking_snapshot_rank,priority:3"`
+	Rank         int       `json:"rank" gorm:"not null;uniqueIndex:idx_ranking_snapshot_rank,priority:4"`
+	StoryID      uuid.UUID `json:"story_id" gorm:"type:uuid;not null;index"`
+	Score        float64   `json:"score"`
+	PreviousRank *int      `json:"previous_rank"`             // Hạng ở snapshot trước, NULL = mới vào bảng
+	Movement     int       `json:"movement" gorm:"default:0"` // > 0 tăng hạng, < 0 giảm hạng
+	IsNew        bool      `json:"is_new" gorm:"default:false"`
+	CreatedAt    time.Time `json:"created_at"`
+
+	// Relations
+	Story Story `json:"story" gorm:"foreignKey:StoryID"`
+}
+
+func (StoryRanking) TableName() string {
+	return "story_rankings"
+}
+
+func (r *StoryRanking) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// IsValidRanking - Kiểm tra loại và chu kỳ bảng xếp hạng
+func IsValidRanking(rankingType, period string) bool {
+	return containsString(RankingTypes, rankingType) && containsString(RankingPeriods, period)
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
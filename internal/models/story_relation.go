@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Loại quan hệ giữa hai truyện
+const (
+	RelationSequel        = "sequel"         // Phần sau
+	RelationPrequel       = "prequel"        // Phần trước
+	RelationSideStory     = "side_story"     // Ngoại truyện / spin-off
+	RelationMainStory     = "main_story"     // Truyện chính của ngoại truyện
+	RelationAdaptation    = "adaptation"     // Bản chuyển thể (novel -> manga...)
+	RelationSource        = "source"         // Nguyên tác của bản chuyển thể
+	RelationAlternate     = "alternate"      // Phiên bản khác (bản dịch khác, bản màu...)
+	RelationSameFranchise = "same_franchise" // Cùng thương hiệu
+)
+
+// relationInverses - Quan hệ ngược, được lưu song song để truy vấn một chiều là đủ
+var relationInverses = map[string]string{
+	RelationSequel:        RelationPrequel,
+	RelationPrequel:       RelationSequel,
+	RelationSideStory:     RelationMainStory,
+	RelationMainStory:     RelationSideStory,
+	RelationAdaptation:    RelationSource,
+	RelationSource:        RelationAdaptation,
+	RelationAlternate:     RelationAlternate,
+	RelationSameFranchise: RelationSameFranchise,
+}
+
+// StoryRelation - Liên kết có hướng: RelatedStory là <RelationType> của Story
+// Mỗi liên kết luôn có bản ghi ngược lại (xem InverseRelation)
+type StoryRelation struct {
+	StoryID        uuid.UUID `json:"story_id" gorm:"type:uuid;primaryKey"`
+	RelatedStoryID uuid.UUID `json:"related_story_id" gorm:"type:uuid;primaryKey;index"`
+	RelationType   string    `json:"relation_type" gorm:"not null;size:20"`
+	CreatedAt      time.Time `json:"created_at"`
+
+	// Relations
+	Story        *Story `json:"-" gorm:"foreignKey:StoryID;constraint:OnDelete:CASCADE"`
+	RelatedStory *Story `json:"related_story,omitempty" gorm:"foreignKey:RelatedStoryID;constraint:OnDelete:CASCADE"`
+}
+
+func (StoryRelation) TableName() string {
+	return "story_relations"
+}
+
+// IsValidRelationType - Kiểm tra loại quan hệ hợp lệ
+func IsValidRelationType(relationType string) bool {
+	_, ok := relationInverses[relationType]
+	return ok
+}
+
+// InverseRelation - Quan hệ nhìn từ phía truyện còn lại (sequel <-> prequel, ...)
+func InverseRelation(relationType string) string {
+	return relationInverses[relationType]
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StorySimilarity - Truyện tương tự đã tính sẵn (job nền)
+// Score = kết hợp Jaccard thể loại và đồng đọc (reading_history + bookmarks)
+type StorySimilarity struct {
+	StoryID        uuid.UUID `json:"story_id" gorm:"type:uuid;primaryKey;index:idx_similarity_story_score,priority:1"`
+	SimilarStoryID uuid.UUID `json:"similar_story_id" gorm:"type:uuid;primaryKey"`
+	Score          float64   `json:"score" gorm:"not null;default:0;index:idx_similarity_story_score,priority:2,sort:desc"`
+	GenreScore     float64   `json:"genre_score" gorm:"default:0"`   // Jaccard trên story_genres
+	CoReadScore    float64   `json:"co_read_score" gorm:"default:0"` // Cosine trên tập người đọc
+	ComputedAt     time.Time `json:"computed_at"`
+
+	// Relations
+	SimilarStory Story `json:"similar_story" gorm:"foreignKey:SimilarStoryID"`
+}
+
+func (StorySimilarity) TableName() string {
+	return "story_similarities"
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// StorySlugHistory - Slug cũ của truyện, giữ lại để link cũ vẫn chuyển hướng được
+type StorySlugHistory struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	StoryID   uuid.UUID `json:"story_id" gorm:"type:uuid;not null;index"`
+	Slug      string    `json:"slug" gorm:"uniqueIndex;not null;size:255"`
+	CreatedAt time.Time `json:"created_at"` // Thời điểm slug bị thay
+
+	// Relations
+	Story *Story `json:"-" gorm:"foreignKey:StoryID;constraint:OnDelete:CASCADE"`
+}
+
+func (StorySlugHistory) TableName() string {
+	return "story_slug_history"
+}
+
+func (h *StorySlugHistory) BeforeCreate(tx *gorm.DB) error {
+	if h.ID == uuid.Nil {
+		h.ID = uuid.New()
+	}
+	return nil
+}
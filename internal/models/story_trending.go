@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Trending windows - Khung thời gian tính điểm trending
+const (
+	TrendingWindow24h = "24h"
+	TrendingWindow7d  = "7d"
+	TrendingWindow30d = "30d"
+)
+
+// StoryTrendingScore - Điểm trending đã tính sẵn cho từng truyện theo khung thời gian
+// Được job nền làm mới định kỳ từ story_views, story_ratings, bookmarks, comments
+type StoryTrendingScore struct {
+	StoryID    uuid.UUID `json:"story_id" gorm:"type:uuid;primaryKey"`
+	TimeWindow string    `json:"time_window" gorm:"size:10;primaryKey;index:idx_trending_window_score,priority:1"`
+	Score      float64   `json:"score" gorm:"not null;default:0;index:idx_trending_window_score,priority:2,sort:desc"`
+	Views      int64     `json:"views" gorm:"default:0"`
+	Ratings    int64     `json:"ratings" gorm:"default:0"`
+	Bookmarks  int64     `json:"bookmarks" gorm:"default:0"`
+	Comments   int64     `json:"comments" gorm:"default:0"`
+	ComputedAt time.Time `json:"computed_at"`
+
+	// Relations
+	Story Story `json:"-" gorm:"foreignKey:StoryID"`
+}
+
+func (StoryTrendingScore) TableName() string {
+	return "story_trending_scores"
+}
+
+// IsValidTrendingWindow - Kiểm tra window có hợp lệ không
+func IsValidTrendingWindow(window string) bool {
+	switch window {
+	case TrendingWindow24h, TrendingWindow7d, TrendingWindow30d:
+		return true
+	}
+	return false
+}
@@ -15,6 +15,7 @@ type StoryView struct {
 	IPAddress string     `json:"ip_address" gorm:"size:45"`
 	ViewedAt  time.Time  `json:"viewed_at" gorm:"type:date;default:CURRENT_DATE;index"`
 	ViewCount int        `json:"view_count" gorm:"default:1"`
+	Referrer  *string    `json:"referrer" gorm:"size:255"` // Host nguồn truy cập (google.com...), NULL = trực tiếp
 
 	// Relations
 	Story   Story    `json:"story,omitempty" gorm:"foreignKey:StoryID"`
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Trạng thái tag
+const (
+	TagStatusActive = "active"
+	TagStatusBanned = "banned" // Không hiển thị, không cho đề xuất lại
+)
+
+// TagVisibilityThreshold - Điểm (upvote - downvote) tối thiểu để tag hiện trên truyện
+const TagVisibilityThreshold = 3
+
+// Tag - Tag chi tiết do cộng đồng đề xuất (khác Genre do admin quản lý)
+// AliasOfID != nil nghĩa là tag này chỉ là tên khác của tag chính
+type Tag struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	Name        string     `json:"name" gorm:"uniqueIndex;not null;size:50"`
+	Slug        string     `json:"slug" gorm:"uniqueIndex;not null;size:60"`
+	Description *string    `json:"description"`
+	Status      string     `json:"status" gorm:"default:active;size:20;index"`
+	AliasOfID   *uuid.UUID `json:"alias_of_id" gorm:"type:uuid;index"`
+	CreatedBy   *uuid.UUID `json:"created_by" gorm:"type:uuid"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+
+	// Relations
+	AliasOf *Tag `json:"alias_of,omitempty" gorm:"foreignKey:AliasOfID"`
+}
+
+func (Tag) TableName() string {
+	return "tags"
+}
+
+func (t *Tag) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+// StoryTag - Tag gắn với truyện, điểm tổng hợp từ StoryTagVote
+type StoryTag struct {
+	StoryID    uuid.UUID  `json:"story_id" gorm:"type:uuid;primaryKey"`
+	TagID      uuid.UUID  `json:"tag_id" gorm:"type:uuid;primaryKey;index"`
+	Upvotes    int        `json:"upvotes" gorm:"default:0"`
+	Downvotes  int        `json:"downvotes" gorm:"default:0"`
+	Score      int        `json:"score" gorm:"default:0;index"` // upvotes - downvotes
+	ProposedBy *uuid.UUID `json:"proposed_by" gorm:"type:uuid"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+
+	// Relations
+	Story *Story `json:"-" gorm:"foreignKey:StoryID;constraint:OnDelete:CASCADE"`
+	Tag   *Tag   `json:"tag,omitempty" gorm:"foreignKey:TagID;constraint:OnDelete:CASCADE"`
+}
+
+func (StoryTag) TableName() string {
+	return "story_tags"
+}
+
+// StoryTagVote - Phiếu của người đọc cho một tag trên một truyện (+1 / -1)
+type StoryTagVote struct {
+	StoryID   uuid.UUID `json:"story_id" gorm:"type:uuid;primaryKey"`
+	TagID     uuid.UUID `json:"tag_id" gorm:"type:uuid;primaryKey"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;primaryKey;index"`
+	Value     int       `json:"value" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Relations
+	User *User `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+func (StoryTagVote) TableName() string {
+	return "story_tag_votes"
+}
@@ -16,6 +16,7 @@ type UserSettings struct {
 	LineHeight      float64   `json:"line_height" gorm:"default:1.8"`
 	ReadingBg       string    `json:"reading_bg" gorm:"size:20;default:white"`      // white, sepia, dark
 	AutoScrollSpeed int       `json:"auto_scroll_speed" gorm:"default:0"`           // 0 = off
+	ShowMatureContent bool    `json:"show_mature_content" gorm:"default:false"`     // Hiển thị truyện mature/adult
 	UpdatedAt       time.Time `json:"updated_at"`
 
 	// Relations
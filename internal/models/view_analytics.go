@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Bảng tổng hợp lượt xem theo ngày, được rollup định kỳ từ story_views
+// Raw story_views chỉ giữ trong thời gian ngắn, analytics dài hạn đọc từ các bảng này
+
+// SiteViewDaily - Lượt xem toàn site theo ngày
+type SiteViewDaily struct {
+	Day            time.Time `json:"day" gorm:"type:date;primaryKey"`
+	Views          int64     `json:"views"`
+	UniqueReaders  int64     `json:"unique_readers"`
+	LoggedInViews  int64     `json:"logged_in_views"`
+	AnonymousViews int64     `json:"anonymous_views"`
+}
+
+func (SiteViewDaily) TableName() string {
+	return "site_view_daily"
+}
+
+// StoryViewDaily - Lượt xem trang truyện theo ngày
+type StoryViewDaily struct {
+	StoryID        uuid.UUID `json:"story_id" gorm:"type:uuid;primaryKey"`
+	Day            time.Time `json:"day" gorm:"type:date;primaryKey;index"`
+	Views          int64     `json:"views"`
+	UniqueReaders  int64     `json:"unique_readers"`
+	LoggedInViews  int64     `json:"logged_in_views"`
+	AnonymousViews int64     `json:"anonymous_views"`
+}
+
+func (StoryViewDaily) TableName() string {
+	return "story_view_daily"
+}
+
+// ChapterViewDaily - Lượt xem chapter theo ngày
+type ChapterViewDaily struct {
+	ChapterID      uuid.UUID `json:"chapter_id" gorm:"type:uuid;primaryKey"`
+	Day            time.Time `json:"day" gorm:"type:date;primaryKey"`
+	StoryID        uuid.UUID `json:"story_id" gorm:"type:uuid;not null;index"`
+	Views          int64     `json:"views"`
+	UniqueReaders  int64     `json:"unique_readers"`
+	LoggedInViews  int64     `json:"logged_in_views"`
+	AnonymousViews int64     `json:"anonymous_views"`
+}
+
+func (ChapterViewDaily) TableName() string {
+	return "chapter_view_daily"
+}
+
+// ReferrerViewDaily - Lượt truy cập theo nguồn (referrer host) mỗi truyện mỗi ngày
+type ReferrerViewDaily struct {
+	StoryID  uuid.UUID `json:"story_id" gorm:"type:uuid;primaryKey"`
+	Day      time.Time `json:"day" gorm:"type:date;primaryKey;index"`
+	Referrer string    `json:"referrer" gorm:"primaryKey;size:255"` // "" = trực tiếp
+	Views    int64     `json:"views"`
+}
+
+func (ReferrerViewDaily) TableName() string {
+	return "referrer_view_daily"
+}
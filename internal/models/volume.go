@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Volume - Tập/arc của truyện, chapter thuộc tối đa một volume
+type Volume struct {
+	ID          uuid.UUID `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	StoryID     uuid.UUID `json:"story_id" gorm:"type:uuid;not null;uniqueIndex:idx_volume_story_number"`
+	Number      int       `json:"number" gorm:"not null;uniqueIndex:idx_volume_story_number"`
+	Title       *string   `json:"title" gorm:"size:255"`
+	Description *string   `json:"description"`
+	CoverURL    *string   `json:"cover_url"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// Relations
+	Chapters []Chapter `json:"chapters,omitempty" gorm:"foreignKey:VolumeID"`
+}
+
+func (Volume) TableName() string {
+	return "volumes"
+}
+
+func (v *Volume) BeforeCreate(tx *gorm.DB) error {
+	if v.ID == uuid.Nil {
+		v.ID = uuid.New()
+	}
+	return nil
+}
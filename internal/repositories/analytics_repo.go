@@ -0,0 +1,288 @@
+package repositories
+
+import (
+	"time"
+
+	"nekozanedex/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DailyViews - Một điểm trong chuỗi lượt xem theo ngày
+type DailyViews struct {
+	Day            time.Time `json:"day"`
+	Views          int64     `json:"views"`
+	UniqueReaders  int64     `json:"unique_readers"`
+	LoggedInViews  int64     `json:"logged_in_views"`
+	AnonymousViews int64     `json:"anonymous_views"`
+}
+
+// ReferrerStat - Nguồn truy cập và số lượt
+type ReferrerStat struct {
+	Referrer string `json:"referrer"` // "" = trực tiếp
+	Views    int64  `json:"views"`
+}
+
+// TopViewedItem - Truyện / chapter có nhiều lượt xem nhất trong khoảng
+type TopViewedItem struct {
+	ID            uuid.UUID `json:"id"`
+	Title         string    `json:"title"`
+	ChapterNumber *int      `json:"chapter_number,omitempty"`
+	Views         int64     `json:"views"`
+	UniqueReaders int64     `json:"unique_readers"`
+}
+
+// FunnelStep - Một chapter trong funnel kèm số người đọc tới chapter đó
+type FunnelStep struct {
+	ChapterID     uuid.UUID `json:"chapter_id"`
+	ChapterNumber int       `json:"chapter_number"`
+	Title         string    `json:"title"`
+	Readers       int64     `json:"readers"`
+	ComputedAt    time.Time `json:"-"`
+}
+
+type AnalyticsRepository interface {
+	RollupViews(since time.Time) error
+	PruneRawViews(before time.Time) (int64, error)
+	GetSiteDailyViews(from, to time.Time) ([]DailyViews, error)
+	GetStoryDailyViews(storyID uuid.UUID, from, to time.Time) ([]DailyViews, error)
+	GetChapterDailyViews(chapterID uuid.UUID, from, to time.Time) ([]DailyViews, error)
+	CountUniqueReaders(storyID, chapterID *uuid.UUID, from, to time.Time) (int64, error)
+	GetTopReferrers(storyID *uuid.UUID, from, to time.Time, limit int) ([]ReferrerStat, error)
+	GetTopStories(from, to time.Time, limit int) ([]TopViewedItem, error)
+	GetTopChapters(storyID uuid.UUID, from, to time.Time, limit int) ([]TopViewedItem, error)
+	RefreshChapterFunnels() error
+	GetChapterFunnel(storyID uuid.UUID) ([]FunnelStep, error)
+}
+
+type analyticsRepository struct {
+	db *gorm.DB
+}
+
+func NewAnalyticsRepository(db *gorm.DB) AnalyticsRepository {
+	return &analyticsRepository{db: db}
+}
+
+// readerKey - Người đọc duy nhất: user_id nếu đăng nhập, không thì IP
+const readerKey = "COALESCE(user_id::text, ip_address)"
+
+// dateOnly - Ngày dạng YYYY-MM-DD để so sánh với cột date (tránh lệch múi giờ)
+func dateOnly(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+// rollupStatements - Tổng hợp lại (ghi đè) các ngày >= @since từ raw story_views
+// Dòng có chapter_id là lượt xem chapter, không có là lượt xem trang truyện
+var rollupStatements = []string{
+	`INSERT INTO site_view_daily (day, views, unique_readers, logged_in_views, anonymous_views)
+	SELECT viewed_at, SUM(view_count), COUNT(DISTINCT ` + readerKey + `),
+		COALESCE(SUM(view_count) FILTER (WHERE user_id IS NOT NULL), 0),
+		COALESCE(SUM(view_count) FILTER (WHERE user_id IS NULL), 0)
+	FROM story_views WHERE viewed_at >= @since
+	GROUP BY viewed_at
+	ON CONFLICT (day) DO UPDATE SET views = EXCLUDED.views, unique_readers = EXCLUDED.unique_readers,
+		logged_in_views = EXCLUDED.logged_in_views, anonymous_views = EXCLUDED.anonymous_views`,
+
+	`INSERT INTO story_view_daily (story_id, day, views, unique_readers, logged_in_views, anonymous_views)
+	SELECT story_id, viewed_at, SUM(view_count), COUNT(DISTINCT ` + readerKey + `),
+		COALESCE(SUM(view_count) FILTER (WHERE user_id IS NOT NULL), 0),
+		COALESCE(SUM(view_count) FILTER (WHERE user_id IS NULL), 0)
+	FROM story_views WHERE viewed_at >= @since AND chapter_id IS NULL
+	GROUP BY story_id, viewed_at
+	ON CONFLICT (story_id, day) DO UPDATE SET views = EXCLUDED.views, unique_readers = EXCLUDED.unique_readers,
+		logged_in_views = EXCLUDED.logged_in_views, anonymous_views = EXCLUDED.anonymous_views`,
+
+	`INSERT INTO chapter_view_daily (chapter_id, day, story_id, views, unique_readers, logged_in_views, anonymous_views)
+	SELECT chapter_id, viewed_at, MIN(story_id::text)::uuid, SUM(view_count), COUNT(DISTINCT ` + readerKey + `),
+		COALESCE(SUM(view_count) FILTER (WHERE user_id IS NOT NULL), 0),
+		COALESCE(SUM(view_count) FILTER (WHERE user_id IS NULL), 0)
+	FROM story_views WHERE viewed_at >= @since AND chapter_id IS NOT NULL
+	GROUP BY chapter_id, viewed_at
+	ON CONFLICT (chapter_id, day) DO UPDATE SET views = EXCLUDED.views, unique_readers = EXCLUDED.unique_readers,
+		logged_in_views = EXCLUDED.logged_in_views, anonymous_views = EXCLUDED.anonymous_views`,
+
+	`INSERT INTO referrer_view_daily (story_id, day, referrer, views)
+	SELECT story_id, viewed_at, COALESCE(referrer, ''), SUM(view_count)
+	FROM story_views WHERE viewed_at >= @since
+	GROUP BY story_id, viewed_at, COALESCE(referrer, '')
+	ON CONFLICT (story_id, day, referrer) DO UPDATE SET views = EXCLUDED.views`,
+
+	// Chapter xa nhất mỗi người đọc tới, chỉ ghi đè khi chapter mới nằm sau chapter đã lưu
+	// (hoặc chapter đã lưu bị xóa) để tiến độ không lùi khi người đọc mở lại chapter cũ
+	`INSERT INTO story_reader_progress (story_id, reader, chapter_id, updated_at)
+	SELECT DISTINCT ON (sv.story_id, ` + readerKey + `) sv.story_id, ` + readerKey + `, sv.chapter_id, NOW()
+	FROM story_views sv
+	JOIN chapters c ON c.id = sv.chapter_id AND c.is_published = true AND c.deleted_at IS NULL
+	WHERE sv.viewed_at >= @since AND ` + readerKey + ` IS NOT NULL
+	ORDER BY sv.story_id, ` + readerKey + `, c.ordering DESC, c.chapter_number DESC
+	ON CONFLICT (story_id, reader) DO UPDATE SET chapter_id = EXCLUDED.chapter_id, updated_at = EXCLUDED.updated_at
+	WHERE NOT EXISTS (
+		SELECT 1 FROM chapters cur, chapters nxt
+		WHERE cur.id = story_reader_progress.chapter_id AND cur.deleted_at IS NULL
+			AND nxt.id = EXCLUDED.chapter_id
+			AND (cur.ordering, cur.chapter_number) >= (nxt.ordering, nxt.chapter_number)
+	)`,
+}
+
+// RollupViews - Tổng hợp raw views từ ngày since vào các bảng daily và tiến độ người đọc (idempotent)
+func (r *analyticsRepository) RollupViews(since time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		args := map[string]interface{}{"since": dateOnly(since)}
+		for _, stmt := range rollupStatements {
+			if err := tx.Exec(stmt, args).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// PruneRawViews - Xóa raw story_views trước ngày before (đã được rollup)
+func (r *analyticsRepository) PruneRawViews(before time.Time) (int64, error) {
+	result := r.db.Exec("DELETE FROM story_views WHERE viewed_at < ?", dateOnly(before))
+	return result.RowsAffected, result.Error
+}
+
+// GetSiteDailyViews - Lượt xem toàn site theo ngày
+func (r *analyticsRepository) GetSiteDailyViews(from, to time.Time) ([]DailyViews, error) {
+	var rows []DailyViews
+	err := r.db.Table("site_view_daily").
+		Select("day, views, unique_readers, logged_in_views, anonymous_views").
+		Where("day BETWEEN ? AND ?", dateOnly(from), dateOnly(to)).
+		Order("day ASC").Scan(&rows).Error
+	return rows, err
+}
+
+// GetStoryDailyViews - Lượt xem trang truyện theo ngày
+func (r *analyticsRepository) GetStoryDailyViews(storyID uuid.UUID, from, to time.Time) ([]DailyViews, error) {
+	var rows []DailyViews
+	err := r.db.Table("story_view_daily").
+		Select("day, views, unique_readers, logged_in_views, anonymous_views").
+		Where("story_id = ? AND day BETWEEN ? AND ?", storyID, dateOnly(from), dateOnly(to)).
+		Order("day ASC").Scan(&rows).Error
+	return rows, err
+}
+
+// GetChapterDailyViews - Lượt xem chapter theo ngày
+func (r *analyticsRepository) GetChapterDailyViews(chapterID uuid.UUID, from, to time.Time) ([]DailyViews, error) {
+	var rows []DailyViews
+	err := r.db.Table("chapter_view_daily").
+		Select("day, views, unique_readers, logged_in_views, anonymous_views").
+		Where("chapter_id = ? AND day BETWEEN ? AND ?", chapterID, dateOnly(from), dateOnly(to)).
+		Order("day ASC").Scan(&rows).Error
+	return rows, err
+}
+
+// CountUniqueReaders - Số người đọc duy nhất trong khoảng, đếm trực tiếp từ raw story_views
+// Chỉ chính xác khi from còn trong thời gian giữ raw
+func (r *analyticsRepository) CountUniqueReaders(storyID, chapterID *uuid.UUID, from, to time.Time) (int64, error) {
+	var count int64
+	query := r.db.Table("story_views").Where("viewed_at BETWEEN ? AND ?", dateOnly(from), dateOnly(to))
+	switch {
+	case chapterID != nil:
+		query = query.Where("chapter_id = ?", *chapterID)
+	case storyID != nil:
+		query = query.Where("story_id = ? AND chapter_id IS NULL", *storyID)
+	}
+	err := query.Select("COUNT(DISTINCT " + readerKey + ")").Scan(&count).Error
+	return count, err
+}
+
+// GetTopReferrers - Nguồn truy cập nhiều nhất, storyID = nil để lấy toàn site
+func (r *analyticsRepository) GetTopReferrers(storyID *uuid.UUID, from, to time.Time, limit int) ([]ReferrerStat, error) {
+	var stats []ReferrerStat
+	query := r.db.Table("referrer_view_daily").
+		Select("referrer, SUM(views) AS views").
+		Where("day BETWEEN ? AND ?", dateOnly(from), dateOnly(to))
+	if storyID != nil {
+		query = query.Where("story_id = ?", *storyID)
+	}
+	err := query.Group("referrer").Order("views DESC, referrer ASC").Limit(limit).Scan(&stats).Error
+	return stats, err
+}
+
+// GetTopStories - Truyện nhiều lượt xem nhất trong khoảng
+// unique_readers là tổng theo ngày (một người đọc nhiều ngày được đếm nhiều lần)
+func (r *analyticsRepository) GetTopStories(from, to time.Time, limit int) ([]TopViewedItem, error) {
+	var items []TopViewedItem
+	err := r.db.Table("story_view_daily d").
+		Select("s.id, s.title, SUM(d.views) AS views, SUM(d.unique_readers) AS unique_readers").
+		Joins("JOIN stories s ON s.id = d.story_id").
+		Where("d.day BETWEEN ? AND ?", dateOnly(from), dateOnly(to)).
+		Group("s.id, s.title").
+		Order("views DESC, s.id").Limit(limit).Scan(&items).Error
+	return items, err
+}
+
+// GetTopChapters - Chapter nhiều lượt xem nhất của một truyện trong khoảng
+func (r *analyticsRepository) GetTopChapters(storyID uuid.UUID, from, to time.Time, limit int) ([]TopViewedItem, error) {
+	var items []TopViewedItem
+	err := r.db.Table("chapter_view_daily d").
+		Select("c.id, c.title, c.chapter_number, SUM(d.views) AS views, SUM(d.unique_readers) AS unique_readers").
+		Joins("JOIN chapters c ON c.id = d.chapter_id").
+		Where("d.story_id = ? AND d.day BETWEEN ? AND ?", storyID, dateOnly(from), dateOnly(to)).
+		Group("c.id, c.title, c.chapter_number").
+		Order("views DESC, c.chapter_number").Limit(limit).Scan(&items).Error
+	return items, err
+}
+
+// refreshFunnelSQL - Mỗi người đọc (user_id hoặc IP) được tính là đã đọc tới chapter xa nhất từng mở
+// Tiến độ lâu dài lấy từ story_reader_progress (sống sót khi raw bị xóa), raw story_views bù phần chưa rollup
+// Chapter được xếp hạng theo thứ tự đọc (ordering), không theo số chapter, vì chapter chèn giữa có số lớn hơn
+// Người đọc tới vị trí N được đếm cho mọi chapter ở vị trí <= N (tổng dồn từ chapter cuối ngược lên)
+// nên đường retention luôn không tăng
+const refreshFunnelSQL = `
+INSERT INTO story_chapter_funnels (story_id, chapter_id, chapter_number, readers, computed_at)
+WITH ranked AS (
+	SELECT id, story_id, chapter_number,
+		ROW_NUMBER() OVER (PARTITION BY story_id ORDER BY ordering, chapter_number) AS position
+	FROM chapters
+	WHERE is_published = true AND deleted_at IS NULL
+),
+reached AS (
+	SELECT p.story_id, p.reader, c.position
+	FROM story_reader_progress p
+	JOIN ranked c ON c.id = p.chapter_id
+	UNION ALL
+	SELECT rh.story_id, rh.user_id::text, c.position
+	FROM reading_history rh
+	JOIN ranked c ON c.id = rh.chapter_id
+	UNION ALL
+	SELECT sv.story_id, COALESCE(sv.user_id::text, sv.ip_address), c.position
+	FROM story_views sv
+	JOIN ranked c ON c.id = sv.chapter_id
+),
+furthest AS (
+	SELECT story_id, reader, MAX(position) AS position
+	FROM reached GROUP BY story_id, reader
+),
+stopped AS (
+	SELECT story_id, position, COUNT(*) AS readers
+	FROM furthest GROUP BY story_id, position
+)
+SELECT c.story_id, c.id, c.chapter_number,
+	SUM(COALESCE(s.readers, 0)) OVER (PARTITION BY c.story_id ORDER BY c.position DESC), NOW()
+FROM ranked c
+LEFT JOIN stopped s ON s.story_id = c.story_id AND s.position = c.position`
+
+// RefreshChapterFunnels - Tính lại funnel cho tất cả truyện
+func (r *analyticsRepository) RefreshChapterFunnels() error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&models.StoryChapterFunnel{}).Error; err != nil {
+			return err
+		}
+		return tx.Exec(refreshFunnelSQL).Error
+	})
+}
+
+// GetChapterFunnel - Funnel đã tính sẵn của truyện, theo thứ tự đọc
+func (r *analyticsRepository) GetChapterFunnel(storyID uuid.UUID) ([]FunnelStep, error) {
+	var steps []FunnelStep
+	err := r.db.Table("story_chapter_funnels f").
+		Select("f.chapter_id, f.chapter_number, c.title, f.readers, f.computed_at").
+		Joins("JOIN chapters c ON c.id = f.chapter_id").
+		Where("f.story_id = ?", storyID).
+		Order("c.ordering ASC, c.chapter_number ASC").Scan(&steps).Error
+	return steps, err
+}
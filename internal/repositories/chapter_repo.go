@@ -1,22 +1,47 @@
 package repositories
 
 import (
+	"time"
+
 	"nekozanedex/internal/models"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ChapterRepository interface {
 	Create(chapter *models.Chapter) error
+	CreateBatch(chapters []models.Chapter) error
 	FindByID(id uuid.UUID) (*models.Chapter, error)
 	FindByStoryAndNumber(storyID uuid.UUID, chapterNumber int) (*models.Chapter, error)
 	Update(chapter *models.Chapter) error
 	Delete(id uuid.UUID) error
 	GetByStory(storyID uuid.UUID, published bool) ([]models.Chapter, error)
+	GetPublishedStamps(storyID uuid.UUID) ([]ChapterStamp, error)
+	FindByIDs(ids []uuid.UUID) ([]models.Chapter, error)
 	GetByStoryPaginated(storyID uuid.UUID, published bool, offset, limit int) ([]models.Chapter, int64, error)
+	GetByStoryCursor(storyID uuid.UUID, published bool, cursor string, limit int) ([]models.Chapter, string, error)
 	IncrementViewCount(id uuid.UUID) error
 	GetScheduledChapters() ([]models.Chapter, error)
+	FindAdjacentPublished(chapter *models.Chapter, after bool) (*models.Chapter, error)
+
+	// Ordering / numbering
+	MaxOrdering(storyID uuid.UUID) (float64, error)
+	NeighborOrdering(storyID uuid.UUID, ordering float64, excludeID uuid.UUID, after bool) (*float64, error)
+	UpdateOrdering(id uuid.UUID, ordering float64) error
+	NormalizeOrdering(storyID uuid.UUID) error
+	CountByStory(storyID uuid.UUID) (int, error)
+}
+
+// ChapterStamp - Thông tin nhẹ của chapter (không kèm nội dung) để tính fingerprint
+type ChapterStamp struct {
+	ID            uuid.UUID
+	ChapterNumber int
+	VolumeID      *uuid.UUID
+	UpdatedAt     time.Time
+	HasText       bool
+	HasImages     bool
 }
 
 type chapterRepository struct {
@@ -29,8 +54,37 @@ func NewChapterRepository(db *gorm.DB) ChapterRepository {
 }
 
 //Create Chapter - Tạo Chapter
+// Số chapter được gán trong transaction dưới khóa dòng truyện để các lần tạo đồng thời không lấy trùng số
 func (r *chapterRepository) Create(chapter *models.Chapter) error {
-	return r.db.Create(chapter).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		next, err := nextChapterNumber(tx, chapter.StoryID)
+		if err != nil {
+			return err
+		}
+		chapter.ChapterNumber = next
+		return tx.Create(chapter).Error
+	})
+}
+
+// CreateBatch - Tạo nhiều chapter của cùng một truyện trong một transaction (lỗi thì không chapter nào được tạo)
+// Số chapter được gán liên tiếp dưới khóa dòng truyện, theo thứ tự trong slice
+func (r *chapterRepository) CreateBatch(chapters []models.Chapter) error {
+	if len(chapters) == 0 {
+		return nil
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		next, err := nextChapterNumber(tx, chapters[0].StoryID)
+		if err != nil {
+			return err
+		}
+		for i := range chapters {
+			chapters[i].ChapterNumber = next + i
+			if err := tx.Create(&chapters[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 //Find Chapter By ID - Tìm Chapter Theo ID
@@ -54,6 +108,29 @@ func (r *chapterRepository) FindByStoryAndNumber(storyID uuid.UUID, chapterNumbe
 	return &chapter, nil
 }
 
+// FindAdjacentPublished - Chapter đã phát hành liền sau (after = true) hoặc liền trước theo ordering, nil nếu không có
+func (r *chapterRepository) FindAdjacentPublished(chapter *models.Chapter, after bool) (*models.Chapter, error) {
+	query := r.db.Select("id", "story_id", "chapter_number", "chapter_label", "chapter_type", "title", "ordering", "volume_id").
+		Where("story_id = ? AND is_published = ?", chapter.StoryID, true).
+		Where("scheduled_at IS NULL OR scheduled_at <= ?", time.Now())
+	if after {
+		query = query.Where("ordering > ? OR (ordering = ? AND chapter_number > ?)", chapter.Ordering, chapter.Ordering, chapter.ChapterNumber).
+			Order("ordering ASC, chapter_number ASC")
+	} else {
+		query = query.Where("ordering < ? OR (ordering = ? AND chapter_number < ?)", chapter.Ordering, chapter.Ordering, chapter.ChapterNumber).
+			Order("ordering DESC, chapter_number DESC")
+	}
+
+	var chapters []models.Chapter
+	if err := query.Limit(1).Find(&chapters).Error; err != nil {
+		return nil, err
+	}
+	if len(chapters) == 0 {
+		return nil, nil
+	}
+	return &chapters[0], nil
+}
+
 //Update Chapter - Cập Nhật Chapter
 func (r *chapterRepository) Update(chapter *models.Chapter) error {
 	return r.db.Save(chapter).Error
@@ -71,7 +148,29 @@ func (r *chapterRepository) GetByStory(storyID uuid.UUID, published bool) ([]mod
 	if published {
 		query = query.Where("is_published = ?", true)
 	}
-	err := query.Order("chapter_number ASC").Find(&chapters).Error
+	err := query.Order("ordering ASC, chapter_number ASC").Find(&chapters).Error
+	return chapters, err
+}
+
+// GetPublishedStamps - Chapter đã publish theo thứ tự đọc, không tải nội dung/ảnh
+func (r *chapterRepository) GetPublishedStamps(storyID uuid.UUID) ([]ChapterStamp, error) {
+	var stamps []ChapterStamp
+	err := r.db.Model(&models.Chapter{}).
+		Select("id, chapter_number, volume_id, updated_at, TRIM(content) <> '' AS has_text, " +
+			"(jsonb_typeof(images) = 'array' AND jsonb_array_length(images) > 0) IS TRUE AS has_images").
+		Where("story_id = ? AND is_published = ?", storyID, true).
+		Order("ordering ASC, chapter_number ASC").
+		Scan(&stamps).Error
+	return stamps, err
+}
+
+// FindByIDs - Chapter đầy đủ theo danh sách ID, giữ thứ tự đọc
+func (r *chapterRepository) FindByIDs(ids []uuid.UUID) ([]models.Chapter, error) {
+	var chapters []models.Chapter
+	if len(ids) == 0 {
+		return chapters, nil
+	}
+	err := r.db.Where("id IN ?", ids).Order("ordering ASC, chapter_number ASC").Find(&chapters).Error
 	return chapters, err
 }
 
@@ -91,10 +190,41 @@ func (r *chapterRepository) GetByStoryPaginated(storyID uuid.UUID, published boo
 	}
 
 	// Get paginated results
-	err := query.Order("chapter_number DESC").Offset(offset).Limit(limit).Find(&chapters).Error
+	err := query.Order("ordering DESC, id DESC").Offset(offset).Limit(limit).Find(&chapters).Error
 	return chapters, total, err
 }
 
+// chapterCursor - Khóa keyset cho danh sách chapter (ordering DESC, id DESC)
+type chapterCursor struct {
+	Ordering float64   `json:"o"`
+	ID       uuid.UUID `json:"i"`
+}
+
+// GetByStoryCursor - Lấy chapters theo cursor (keyset), không cần Count
+func (r *chapterRepository) GetByStoryCursor(storyID uuid.UUID, published bool, cursor string, limit int) ([]models.Chapter, string, error) {
+	var chapters []models.Chapter
+	query := r.db.Model(&models.Chapter{}).Where("story_id = ?", storyID)
+	if published {
+		query = query.Where("is_published = ?", true)
+	}
+	if cursor != "" {
+		var key chapterCursor
+		if err := decodeCursor(cursor, &key); err != nil {
+			return nil, "", err
+		}
+		query = query.Where("(ordering, id) < (?, ?)", key.Ordering, key.ID)
+	}
+
+	err := query.Order("ordering DESC, id DESC").Limit(limit + 1).Find(&chapters).Error
+	if err != nil {
+		return nil, "", err
+	}
+	chapters, next := cursorPage(chapters, limit, func(c models.Chapter) interface{} {
+		return chapterCursor{Ordering: c.Ordering, ID: c.ID}
+	})
+	return chapters, next, nil
+}
+
 //Increment View Count - Tăng Lượt Xem
 func (r *chapterRepository) IncrementViewCount(id uuid.UUID) error {
 	return r.db.Model(&models.Chapter{}).Where("id = ?", id).
@@ -106,4 +236,62 @@ func (r *chapterRepository) GetScheduledChapters() ([]models.Chapter, error) {
 	var chapters []models.Chapter
 	err := r.db.Where("is_published = ? AND scheduled_at <= NOW()", false).Find(&chapters).Error
 	return chapters, err
-}
\ No newline at end of file
+}
+// nextChapterNumber - Khóa dòng truyện (giữ tới hết transaction) rồi lấy số chapter kế tiếp
+// Tính cả chapter đã xóa để URL cũ không trỏ sang chapter khác
+func nextChapterNumber(tx *gorm.DB, storyID uuid.UUID) (int, error) {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id").First(&models.Story{}, "id = ?", storyID).Error; err != nil {
+		return 0, err
+	}
+
+	var last int
+	err := tx.Unscoped().Model(&models.Chapter{}).Where("story_id = ?", storyID).
+		Select("COALESCE(MAX(chapter_number), 0)").Scan(&last).Error
+	return last + 1, err
+}
+
+// MaxOrdering - Ordering lớn nhất của truyện (0 nếu chưa có chapter)
+func (r *chapterRepository) MaxOrdering(storyID uuid.UUID) (float64, error) {
+	var maxOrdering float64
+	err := r.db.Model(&models.Chapter{}).Where("story_id = ?", storyID).
+		Select("COALESCE(MAX(ordering), 0)").Scan(&maxOrdering).Error
+	return maxOrdering, err
+}
+
+// NeighborOrdering - Ordering liền sau (after = true) hoặc liền trước, nil nếu không có
+func (r *chapterRepository) NeighborOrdering(storyID uuid.UUID, ordering float64, excludeID uuid.UUID, after bool) (*float64, error) {
+	query := r.db.Model(&models.Chapter{}).Where("story_id = ? AND id <> ?", storyID, excludeID)
+	if after {
+		query = query.Where("ordering > ?", ordering).Select("MIN(ordering)")
+	} else {
+		query = query.Where("ordering < ?", ordering).Select("MAX(ordering)")
+	}
+
+	var neighbor *float64
+	err := query.Scan(&neighbor).Error
+	return neighbor, err
+}
+
+// UpdateOrdering - Chỉ cập nhật ordering
+func (r *chapterRepository) UpdateOrdering(id uuid.UUID, ordering float64) error {
+	return r.db.Model(&models.Chapter{}).Where("id = ?", id).UpdateColumn("ordering", ordering).Error
+}
+
+// NormalizeOrdering - Đánh lại ordering 1, 2, 3... theo thứ tự hiện tại (khi khoảng cách quá nhỏ)
+func (r *chapterRepository) NormalizeOrdering(storyID uuid.UUID) error {
+	return r.db.Exec(`
+		UPDATE chapters c SET ordering = o.rn
+		FROM (
+			SELECT id, ROW_NUMBER() OVER (ORDER BY ordering, chapter_number) AS rn
+			FROM chapters WHERE story_id = ? AND deleted_at IS NULL
+		) o
+		WHERE c.id = o.id`, storyID).Error
+}
+
+// CountByStory - Số chapter (chưa xóa) của truyện
+func (r *chapterRepository) CountByStory(storyID uuid.UUID) (int, error) {
+	var count int64
+	err := r.db.Model(&models.Chapter{}).Where("story_id = ?", storyID).Count(&count).Error
+	return int(count), err
+}
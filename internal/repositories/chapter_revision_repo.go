@@ -0,0 +1,89 @@
+package repositories
+
+import (
+	"nekozanedex/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ChapterRevisionRepository interface {
+	SaveChapterWithRevision(chapterID uuid.UUID, edit ChapterEdit) error
+	FindRevision(chapterID uuid.UUID, number int) (*models.ChapterRevision, error)
+	GetRevisions(chapterID uuid.UUID) ([]models.ChapterRevision, error)
+}
+
+type chapterRevisionRepository struct {
+	db *gorm.DB
+}
+
+func NewChapterRevisionRepository(db *gorm.DB) ChapterRevisionRepository {
+	return &chapterRevisionRepository{db: db}
+}
+
+// ChapterEdit - Áp thay đổi lên bản chapter vừa đọc lại dưới khóa
+// Trả về revision cần lưu, nil nếu chỉ đổi nhãn/thứ tự
+type ChapterEdit func(chapter *models.Chapter) (*models.ChapterRevision, error)
+
+// SaveChapterWithRevision - Đọc lại chapter dưới khóa dòng, áp thay đổi rồi lưu chapter và revision trong cùng transaction
+// Hai lần sửa đồng thời được tuần tự hóa: lần sau áp lên kết quả của lần trước, không ghi đè bản cũ
+// Chapter chưa có revision nào: trạng thái trước khi sửa được lưu làm revision 1
+func (r *chapterRevisionRepository) SaveChapterWithRevision(chapterID uuid.UUID, edit ChapterEdit) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var chapter models.Chapter
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&chapter, "id = ?", chapterID).Error; err != nil {
+			return err
+		}
+		var last int
+		if err := tx.Model(&models.ChapterRevision{}).
+			Where("chapter_id = ?", chapterID).
+			Select("COALESCE(MAX(revision_number), 0)").Scan(&last).Error; err != nil {
+			return err
+		}
+
+		base := models.NewChapterRevision(&chapter, nil)
+		revision, err := edit(&chapter)
+		if err != nil {
+			return err
+		}
+		if last == 0 {
+			base.RevisionNumber = 1
+			if err := tx.Create(base).Error; err != nil {
+				return err
+			}
+			last = 1
+		}
+
+		if err := tx.Save(&chapter).Error; err != nil {
+			return err
+		}
+		if revision == nil {
+			return nil
+		}
+		revision.RevisionNumber = last + 1
+		return tx.Create(revision).Error
+	})
+}
+
+// FindRevision - Lấy revision đầy đủ nội dung theo số thứ tự
+func (r *chapterRevisionRepository) FindRevision(chapterID uuid.UUID, number int) (*models.ChapterRevision, error) {
+	var revision models.ChapterRevision
+	err := r.db.Preload("Editor", publicUserFields).
+		First(&revision, "chapter_id = ? AND revision_number = ?", chapterID, number).Error
+	if err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
+
+// GetRevisions - Danh sách revision mới nhất trước, không kèm nội dung
+func (r *chapterRevisionRepository) GetRevisions(chapterID uuid.UUID) ([]models.ChapterRevision, error) {
+	var revisions []models.ChapterRevision
+	err := r.db.Select("id", "chapter_id", "revision_number", "editor_id", "title", "page_count", "restored_from", "created_at").
+		Preload("Editor", publicUserFields).
+		Where("chapter_id = ?", chapterID).
+		Order("revision_number DESC").
+		Find(&revisions).Error
+	return revisions, err
+}
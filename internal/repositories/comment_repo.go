@@ -1,6 +1,8 @@
 package repositories
 
 import (
+	"time"
+
 	"nekozanedex/internal/models"
 
 	"github.com/google/uuid"
@@ -14,6 +16,8 @@ type CommentRepository interface {
 	DeleteComment(id uuid.UUID) error
 	GetCommentsByStory(storyID uuid.UUID, page, limit int, sortBy string) ([]models.Comment, int64, error)
 	GetCommentsByChapter(chapterID uuid.UUID, page, limit int, sortBy string) ([]models.Comment, int64, error)
+	GetCommentsByStoryCursor(storyID uuid.UUID, cursor string, limit int, sortBy string) ([]models.Comment, string, error)
+	GetCommentsByChapterCursor(chapterID uuid.UUID, cursor string, limit int, sortBy string) ([]models.Comment, string, error)
 	GetCommentReplies(parentID uuid.UUID) ([]models.Comment, error)
 	UpdateLikeCount(commentID uuid.UUID, count int) error
 	TogglePin(commentID uuid.UUID, isPinned bool) error
@@ -56,14 +60,72 @@ func (r *commentRepository) DeleteComment(id uuid.UUID) error {
 func getCommentOrderClause(sortBy string) string {
 	switch sortBy {
 	case "oldest":
-		return "is_pinned DESC, created_at ASC"
+		return "is_pinned DESC, created_at ASC, id ASC"
 	case "top":
-		return "is_pinned DESC, like_count DESC, created_at DESC"
+		return "is_pinned DESC, like_count DESC, created_at DESC, id DESC"
 	default: // "newest" or empty
-		return "is_pinned DESC, created_at DESC"
+		return "is_pinned DESC, created_at DESC, id DESC"
 	}
 }
 
+// commentCursor - Khóa keyset cho comments, khớp với getCommentOrderClause
+type commentCursor struct {
+	IsPinned  bool      `json:"p"`
+	LikeCount int       `json:"l,omitempty"`
+	CreatedAt time.Time `json:"c"`
+	ID        uuid.UUID `json:"i"`
+}
+
+// whereAfterComment - Điều kiện "sau cursor" theo đúng thứ tự của sortBy
+func whereAfterComment(db *gorm.DB, key commentCursor, sortBy string) *gorm.DB {
+	switch sortBy {
+	case "oldest":
+		// is_pinned giảm dần nhưng created_at, id tăng dần nên không dùng so sánh bộ được
+		return db.Where("(is_pinned < @p OR (is_pinned = @p AND (created_at, id) > (@c, @i)))",
+			map[string]interface{}{"p": key.IsPinned, "c": key.CreatedAt, "i": key.ID})
+	case "top":
+		return db.Where("(is_pinned, like_count, created_at, id) < (?, ?, ?, ?)", key.IsPinned, key.LikeCount, key.CreatedAt, key.ID)
+	default:
+		return db.Where("(is_pinned, created_at, id) < (?, ?, ?)", key.IsPinned, key.CreatedAt, key.ID)
+	}
+}
+
+// getCommentsByCursor - Lấy comments top-level theo cursor (keyset)
+func (r *commentRepository) getCommentsByCursor(query *gorm.DB, cursor string, limit int, sortBy string) ([]models.Comment, string, error) {
+	var comments []models.Comment
+	if cursor != "" {
+		var key commentCursor
+		if err := decodeCursor(cursor, &key); err != nil {
+			return nil, "", err
+		}
+		query = whereAfterComment(query, key, sortBy)
+	}
+
+	err := query.Preload("User").Preload("Replies.User").
+		Order(getCommentOrderClause(sortBy)).
+		Limit(limit + 1).
+		Find(&comments).Error
+	if err != nil {
+		return nil, "", err
+	}
+	comments, next := cursorPage(comments, limit, func(c models.Comment) interface{} {
+		return commentCursor{IsPinned: c.IsPinned, LikeCount: c.LikeCount, CreatedAt: c.CreatedAt, ID: c.ID}
+	})
+	return comments, next, nil
+}
+
+// GetCommentsByStoryCursor - Lấy Comments theo Story với cursor (top-level only)
+func (r *commentRepository) GetCommentsByStoryCursor(storyID uuid.UUID, cursor string, limit int, sortBy string) ([]models.Comment, string, error) {
+	query := r.db.Where("story_id = ? AND chapter_id IS NULL AND parent_id IS NULL AND is_approved = ?", storyID, true)
+	return r.getCommentsByCursor(query, cursor, limit, sortBy)
+}
+
+// GetCommentsByChapterCursor - Lấy Comments theo Chapter với cursor
+func (r *commentRepository) GetCommentsByChapterCursor(chapterID uuid.UUID, cursor string, limit int, sortBy string) ([]models.Comment, string, error) {
+	query := r.db.Where("chapter_id = ? AND parent_id IS NULL AND is_approved = ?", chapterID, true)
+	return r.getCommentsByCursor(query, cursor, limit, sortBy)
+}
+
 // GetCommentsByStory - Lấy Comments theo Story (top-level only)
 func (r *commentRepository) GetCommentsByStory(storyID uuid.UUID, page, limit int, sortBy string) ([]models.Comment, int64, error) {
 	var comments []models.Comment
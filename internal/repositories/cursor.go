@@ -0,0 +1,40 @@
+package repositories
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+// ErrInvalidCursor - Cursor không giải mã được (bị sửa hoặc từ danh sách khác)
+var ErrInvalidCursor = errors.New("cursor không hợp lệ")
+
+// encodeCursor - Mã hóa khóa sắp xếp của phần tử cuối thành chuỗi opaque cho client
+func encodeCursor(key interface{}) string {
+	data, err := json.Marshal(key)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor - Giải mã cursor vào struct khóa tương ứng của danh sách
+func decodeCursor(cursor string, key interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, key); err != nil {
+		return ErrInvalidCursor
+	}
+	return nil
+}
+
+// cursorPage - Cắt kết quả đã lấy dư 1 phần tử, trả về cursor trang sau ("" nếu hết)
+func cursorPage[T any](items []T, limit int, key func(T) interface{}) ([]T, string) {
+	if len(items) <= limit {
+		return items, ""
+	}
+	items = items[:limit]
+	return items, encodeCursor(key(items[len(items)-1]))
+}
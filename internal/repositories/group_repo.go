@@ -0,0 +1,197 @@
+package repositories
+
+import (
+	"nekozanedex/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// publicUserFields - Chỉ lộ thông tin public của user (thành viên nhóm, người sửa chapter)
+func publicUserFields(db *gorm.DB) *gorm.DB {
+	return db.Select("id", "username", "tag_name", "avatar_url")
+}
+
+type GroupRepository interface {
+	CreateGroup(group *models.Group, ownerID uuid.UUID) error
+	FindGroupByID(id uuid.UUID) (*models.Group, error)
+	FindGroupBySlug(slug string) (*models.Group, error)
+	IsSlugTaken(slug string) (bool, error)
+	UpdateGroup(group *models.Group) error
+	DeleteGroup(id uuid.UUID) error
+	GetAllGroups(query string, page, limit int) ([]models.Group, int64, error)
+
+	GetMember(groupID, userID uuid.UUID) (*models.GroupMember, error)
+	UpsertMember(member *models.GroupMember) error
+	RemoveMember(groupID, userID uuid.UUID) error
+	CountOwners(groupID uuid.UUID) (int64, error)
+	GetUserGroups(userID uuid.UUID) ([]models.GroupMember, error)
+
+	GetGroupStories(groupID uuid.UUID, publishedOnly, showMature bool) ([]models.Story, error)
+	GetGroupReleases(groupID uuid.UUID, page, limit int, showMature bool) ([]models.Chapter, int64, error)
+	AssignStory(storyID uuid.UUID, groupID *uuid.UUID) error
+}
+
+type groupRepository struct {
+	db *gorm.DB
+}
+
+func NewGroupRepository(db *gorm.DB) GroupRepository {
+	return &groupRepository{db: db}
+}
+
+// CreateGroup - Tạo Group và thêm người tạo làm owner trong cùng transaction
+func (r *groupRepository) CreateGroup(group *models.Group, ownerID uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(group).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.GroupMember{
+			GroupID: group.ID,
+			UserID:  ownerID,
+			Role:    models.GroupRoleOwner,
+		}).Error
+	})
+}
+
+// FindGroupByID - Tìm Group theo ID
+func (r *groupRepository) FindGroupByID(id uuid.UUID) (*models.Group, error) {
+	var group models.Group
+	err := r.db.First(&group, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+// FindGroupBySlug - Tìm Group theo Slug kèm danh sách thành viên
+func (r *groupRepository) FindGroupBySlug(slug string) (*models.Group, error) {
+	var group models.Group
+	err := r.db.Preload("Members", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at ASC")
+	}).Preload("Members.User", publicUserFields).First(&group, "slug = ?", slug).Error
+	if err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+// IsSlugTaken - Slug đã được dùng, kể cả nhóm đã xóa (unique index gồm cả dòng đã xóa mềm)
+func (r *groupRepository) IsSlugTaken(slug string) (bool, error) {
+	var count int64
+	err := r.db.Unscoped().Model(&models.Group{}).Where("slug = ?", slug).Count(&count).Error
+	return count > 0, err
+}
+
+// UpdateGroup - Cập nhật Group
+func (r *groupRepository) UpdateGroup(group *models.Group) error {
+	return r.db.Omit("Members").Save(group).Error
+}
+
+// DeleteGroup - Xóa Group, gỡ nhóm khỏi các truyện đang được giao
+func (r *groupRepository) DeleteGroup(id uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Story{}).Where("group_id = ?", id).Update("group_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("group_id = ?", id).Delete(&models.GroupMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Group{}, "id = ?", id).Error
+	})
+}
+
+// GetAllGroups - Danh sách Group, lọc theo tên nếu có query
+func (r *groupRepository) GetAllGroups(query string, page, limit int) ([]models.Group, int64, error) {
+	var groups []models.Group
+	var total int64
+
+	db := r.db.Model(&models.Group{})
+	if query != "" {
+		db = db.Where("f_unaccent(lower(name)) LIKE '%' || f_unaccent(lower(?)) || '%'", escapeSearchQuery(query))
+	}
+	db.Count(&total)
+
+	offset := (page - 1) * limit
+	err := db.Order("name ASC").Offset(offset).Limit(limit).Find(&groups).Error
+	return groups, total, err
+}
+
+// GetMember - Lấy thành viên của Group
+func (r *groupRepository) GetMember(groupID, userID uuid.UUID) (*models.GroupMember, error) {
+	var member models.GroupMember
+	err := r.db.First(&member, "group_id = ? AND user_id = ?", groupID, userID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// UpsertMember - Thêm thành viên hoặc đổi role nếu đã có
+func (r *groupRepository) UpsertMember(member *models.GroupMember) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "group_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role"}),
+	}).Create(member).Error
+}
+
+// RemoveMember - Xóa thành viên khỏi Group
+func (r *groupRepository) RemoveMember(groupID, userID uuid.UUID) error {
+	return r.db.Where("group_id = ? AND user_id = ?", groupID, userID).Delete(&models.GroupMember{}).Error
+}
+
+// CountOwners - Đếm số owner của Group
+func (r *groupRepository) CountOwners(groupID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.GroupMember{}).
+		Where("group_id = ? AND role = ?", groupID, models.GroupRoleOwner).
+		Count(&count).Error
+	return count, err
+}
+
+// GetUserGroups - Các Group mà user là thành viên
+func (r *groupRepository) GetUserGroups(userID uuid.UUID) ([]models.GroupMember, error) {
+	var memberships []models.GroupMember
+	err := r.db.Joins("Group").
+		Where("group_members.user_id = ?", userID).
+		Order("group_members.created_at ASC").
+		Find(&memberships).Error
+	return memberships, err
+}
+
+// GetGroupStories - Các truyện được giao cho Group
+func (r *groupRepository) GetGroupStories(groupID uuid.UUID, publishedOnly, showMature bool) ([]models.Story, error) {
+	var stories []models.Story
+	db := r.db.Where("group_id = ?", groupID)
+	if publishedOnly {
+		db = db.Where("is_published = ?", true)
+	}
+	db = whereAudience(db, "age_rating", showMature)
+	err := db.Preload("Genres").Order("updated_at DESC").Find(&stories).Error
+	return stories, err
+}
+
+// GetGroupReleases - Chapter đã xuất bản được ghi công cho Group, mới nhất trước
+func (r *groupRepository) GetGroupReleases(groupID uuid.UUID, page, limit int, showMature bool) ([]models.Chapter, int64, error) {
+	var chapters []models.Chapter
+	var total int64
+
+	db := r.db.Model(&models.Chapter{}).
+		Joins("JOIN stories ON stories.id = chapters.story_id AND stories.deleted_at IS NULL").
+		Where("chapters.group_id = ? AND chapters.is_published = ? AND stories.is_published = ?", groupID, true, true)
+	db = whereAudience(db, "stories.age_rating", showMature)
+	db.Count(&total)
+
+	offset := (page - 1) * limit
+	err := db.Preload("Story").
+		Order("chapters.published_at DESC NULLS LAST, chapters.created_at DESC").
+		Offset(offset).Limit(limit).
+		Find(&chapters).Error
+	return chapters, total, err
+}
+
+// AssignStory - Giao truyện cho Group (nil = gỡ nhóm)
+func (r *groupRepository) AssignStory(storyID uuid.UUID, groupID *uuid.UUID) error {
+	return r.db.Model(&models.Story{}).Where("id = ?", storyID).Update("group_id", groupID).Error
+}
@@ -1,6 +1,8 @@
 package repositories
 
 import (
+	"time"
+
 	"nekozanedex/internal/models"
 
 	"github.com/google/uuid"
@@ -11,6 +13,7 @@ type NotificationRepository interface {
 	CreateNotification(notification *models.Notification) error
 	FindNotificationByID(id uuid.UUID) (*models.Notification, error)
 	GetNotificationsByUser(userID uuid.UUID, page, limit int) ([]models.Notification, int64, error)
+	GetNotificationsByUserCursor(userID uuid.UUID, cursor string, limit int) ([]models.Notification, string, error)
 	MarkNotificationAsRead(id uuid.UUID) error
 	MarkAllNotificationsAsRead(userID uuid.UUID) error
 	GetUnreadNotificationCount(userID uuid.UUID) int64
@@ -50,12 +53,40 @@ func (r *notificationRepository) GetNotificationsByUser(userID uuid.UUID, page,
 	offset := (page - 1) * limit
 	err := r.db.Where("user_id = ?", userID).
 		Offset(offset).Limit(limit).
-		Order("created_at DESC").
+		Order("created_at DESC, id DESC").
 		Find(&notifications).Error
 
 	return notifications, total, err
 }
 
+// notificationCursor - Khóa keyset cho thông báo (created_at DESC, id DESC)
+type notificationCursor struct {
+	CreatedAt time.Time `json:"c"`
+	ID        uuid.UUID `json:"i"`
+}
+
+// GetNotificationsByUserCursor - Lấy Notifications theo cursor (keyset)
+func (r *notificationRepository) GetNotificationsByUserCursor(userID uuid.UUID, cursor string, limit int) ([]models.Notification, string, error) {
+	var notifications []models.Notification
+	query := r.db.Where("user_id = ?", userID)
+	if cursor != "" {
+		var key notificationCursor
+		if err := decodeCursor(cursor, &key); err != nil {
+			return nil, "", err
+		}
+		query = query.Where("(created_at, id) < (?, ?)", key.CreatedAt, key.ID)
+	}
+
+	err := query.Order("created_at DESC, id DESC").Limit(limit + 1).Find(&notifications).Error
+	if err != nil {
+		return nil, "", err
+	}
+	notifications, next := cursorPage(notifications, limit, func(n models.Notification) interface{} {
+		return notificationCursor{CreatedAt: n.CreatedAt, ID: n.ID}
+	})
+	return notifications, next, nil
+}
+
 // MarkNotificationAsRead - Đánh dấu đã đọc
 func (r *notificationRepository) MarkNotificationAsRead(id uuid.UUID) error {
 	return r.db.Model(&models.Notification{}).Where("id = ?", id).
@@ -0,0 +1,154 @@
+package repositories
+
+import (
+	"nekozanedex/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PersonRepository interface {
+	CreatePerson(person *models.Person) error
+	FindPersonByID(id uuid.UUID) (*models.Person, error)
+	FindPersonBySlug(slug string) (*models.Person, error)
+	FindPersonByName(name string) (*models.Person, error)
+	UpdatePerson(person *models.Person) error
+	DeletePerson(id uuid.UUID) error
+	GetAllPeople(query string, page, limit int) ([]models.Person, int64, error)
+	GetPersonWorks(personID uuid.UUID, publishedOnly bool) ([]models.StoryPerson, error)
+	LinkStoryPerson(link *models.StoryPerson) (bool, error)
+	ReplaceStoryPeople(storyID uuid.UUID, links []models.StoryPerson) error
+	MergePeople(target *models.Person, sourceID uuid.UUID) error
+}
+
+type personRepository struct {
+	db *gorm.DB
+}
+
+func NewPersonRepository(db *gorm.DB) PersonRepository {
+	return &personRepository{db: db}
+}
+
+// CreatePerson - Tạo Person
+func (r *personRepository) CreatePerson(person *models.Person) error {
+	return r.db.Create(person).Error
+}
+
+// FindPersonByID - Tìm Person theo ID
+func (r *personRepository) FindPersonByID(id uuid.UUID) (*models.Person, error) {
+	var person models.Person
+	err := r.db.First(&person, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &person, nil
+}
+
+// FindPersonBySlug - Tìm Person theo Slug
+func (r *personRepository) FindPersonBySlug(slug string) (*models.Person, error) {
+	var person models.Person
+	err := r.db.First(&person, "slug = ?", slug).Error
+	if err != nil {
+		return nil, err
+	}
+	return &person, nil
+}
+
+// FindPersonByName - Tìm Person theo tên hoặc alias (không phân biệt hoa thường, dấu)
+func (r *personRepository) FindPersonByName(name string) (*models.Person, error) {
+	var person models.Person
+	err := r.db.Where(`f_unaccent(lower(name)) = f_unaccent(lower(@name)) OR EXISTS (
+			SELECT 1 FROM jsonb_array_elements_text(CASE WHEN jsonb_typeof(aliases) = 'array' THEN aliases ELSE '[]'::jsonb END) AS alias
+			WHERE f_unaccent(lower(alias)) = f_unaccent(lower(@name))
+		)`, map[string]interface{}{"name": name}).
+		Order("created_at ASC").First(&person).Error
+	if err != nil {
+		return nil, err
+	}
+	return &person, nil
+}
+
+// UpdatePerson - Cập nhật Person
+func (r *personRepository) UpdatePerson(person *models.Person) error {
+	return r.db.Save(person).Error
+}
+
+// DeletePerson - Xóa Person (liên kết với truyện bị xóa theo)
+func (r *personRepository) DeletePerson(id uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("person_id = ?", id).Delete(&models.StoryPerson{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Person{}, "id = ?", id).Error
+	})
+}
+
+// GetAllPeople - Danh sách Person (Admin), lọc theo tên nếu có query
+func (r *personRepository) GetAllPeople(query string, page, limit int) ([]models.Person, int64, error) {
+	var people []models.Person
+	var total int64
+
+	db := r.db.Model(&models.Person{})
+	if query != "" {
+		db = db.Where("f_unaccent(lower(name)) LIKE '%' || f_unaccent(lower(?)) || '%'", escapeSearchQuery(query))
+	}
+	db.Count(&total)
+
+	offset := (page - 1) * limit
+	err := db.Order("name ASC").Offset(offset).Limit(limit).Find(&people).Error
+	return people, total, err
+}
+
+// GetPersonWorks - Lấy các truyện của Person kèm vai trò, mới nhất trước
+func (r *personRepository) GetPersonWorks(personID uuid.UUID, publishedOnly bool) ([]models.StoryPerson, error) {
+	var works []models.StoryPerson
+	db := r.db.Joins("JOIN stories ON stories.id = story_people.story_id AND stories.deleted_at IS NULL").
+		Where("story_people.person_id = ?", personID)
+	if publishedOnly {
+		db = db.Where("stories.is_published = ?", true)
+	}
+	err := db.Preload("Story.Genres").
+		Order("stories.release_year DESC NULLS LAST, stories.created_at DESC").
+		Find(&works).Error
+	return works, err
+}
+
+// LinkStoryPerson - Thêm liên kết nếu chưa có, trả về true nếu vừa tạo mới
+func (r *personRepository) LinkStoryPerson(link *models.StoryPerson) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(link)
+	return result.RowsAffected > 0, result.Error
+}
+
+// ReplaceStoryPeople - Thay toàn bộ người tham gia của truyện
+func (r *personRepository) ReplaceStoryPeople(storyID uuid.UUID, links []models.StoryPerson) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("story_id = ?", storyID).Delete(&models.StoryPerson{}).Error; err != nil {
+			return err
+		}
+		if len(links) == 0 {
+			return nil
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&links).Error
+	})
+}
+
+// MergePeople - Gộp source vào target: chuyển liên kết truyện, xóa source rồi lưu target (alias, bio, avatar đã gộp)
+// Liên kết trùng (cùng truyện, cùng vai trò) được bỏ qua
+func (r *personRepository) MergePeople(target *models.Person, sourceID uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`
+			INSERT INTO story_people (story_id, person_id, role, created_at)
+			SELECT story_id, ?, role, created_at FROM story_people WHERE person_id = ?
+			ON CONFLICT DO NOTHING`, target.ID, sourceID).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("person_id = ?", sourceID).Delete(&models.StoryPerson{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.Person{}, "id = ?", sourceID).Error; err != nil {
+			return err
+		}
+		return tx.Save(target).Error
+	})
+}
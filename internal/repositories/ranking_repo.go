@@ -0,0 +1,151 @@
+package repositories
+
+import (
+	"database/sql"
+	"time"
+
+	"nekozanedex/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RankingEntry - Kết quả tính hạng thô (trước khi lưu snapshot)
+type RankingEntry struct {
+	StoryID uuid.UUID
+	Score   float64
+}
+
+// Bayesian average cho rating: kéo truyện ít lượt đánh giá về mức trung bình
+const (
+	ratingPriorMean  = 3.0
+	ratingPriorCount = 5.0
+)
+
+type RankingRepository interface {
+	ComputeRanking(rankingType string, since *time.Time, limit int) ([]RankingEntry, error)
+	GetPreviousRanks(rankingType, period string, before time.Time) (map[uuid.UUID]int, error)
+	ReplaceSnapshot(rankingType, period string, snapshotDate time.Time, rankings []models.StoryRanking) error
+	GetSnapshot(rankingType, period string, onOrBefore time.Time, limit int, showMature bool) ([]models.StoryRanking, error)
+}
+
+type rankingRepository struct {
+	db *gorm.DB
+}
+
+func NewRankingRepository(db *gorm.DB) RankingRepository {
+	return &rankingRepository{db: db}
+}
+
+// ComputeRanking - Tính bảng xếp hạng từ story_views / story_ratings / bookmarks
+// since = nil nghĩa là all-time
+func (r *rankingRepository) ComputeRanking(rankingType string, since *time.Time, limit int) ([]RankingEntry, error) {
+	var entries []RankingEntry
+	var query *gorm.DB
+
+	switch rankingType {
+	case models.RankingTypeViews:
+		if since == nil {
+			// All-time dùng view_count đã cache trên story
+			query = r.db.Table("stories s").
+				Select("s.id AS story_id, s.view_count::float AS score").
+				Where("s.view_count > 0")
+		} else {
+			query = r.db.Table("story_views sv").
+				Select("sv.story_id, SUM(sv.view_count)::float AS score").
+				Joins("JOIN stories s ON s.id = sv.story_id").
+				Where("sv.viewed_at >= ? AND sv.chapter_id IS NULL", *since). // Cùng mốc since với rating/bookmark
+				Group("sv.story_id")
+		}
+	case models.RankingTypeRating:
+		query = r.db.Table("story_ratings sr").
+			Select("sr.story_id, (SUM(sr.rating) + ? * ?) / (COUNT(*) + ?) AS score",
+				ratingPriorMean, ratingPriorCount, ratingPriorCount).
+			Joins("JOIN stories s ON s.id = sr.story_id").
+			Group("sr.story_id")
+		if since != nil {
+			query = query.Where("sr.updated_at >= ?", *since)
+		}
+	case models.RankingTypeBookmarks:
+		query = r.db.Table("bookmarks b").
+			Select("b.story_id, COUNT(*)::float AS score").
+			Joins("JOIN stories s ON s.id = b.story_id").
+			Group("b.story_id")
+		if since != nil {
+			query = query.Where("b.created_at >= ?", *since)
+		}
+	default:
+		return entries, nil
+	}
+
+	err := query.Where("s.is_published = ? AND s.deleted_at IS NULL", true).
+		Order("score DESC, story_id").
+		Limit(limit).
+		Scan(&entries).Error
+	return entries, err
+}
+
+// GetPreviousRanks - Lấy hạng của snapshot gần nhất trước ngày `before`
+func (r *rankingRepository) GetPreviousRanks(rankingType, period string, before time.Time) (map[uuid.UUID]int, error) {
+	ranks := make(map[uuid.UUID]int)
+
+	var previousDate sql.NullTime
+	err := r.db.Model(&models.StoryRanking{}).
+		Select("MAX(snapshot_date)").
+		Where("ranking_type = ? AND period = ? AND snapshot_date < ?", rankingType, period, before).
+		Scan(&previousDate).Error
+	if err != nil || !previousDate.Valid {
+		return ranks, err
+	}
+
+	var rankings []models.StoryRanking
+	err = r.db.Select("story_id, rank").
+		Where("ranking_type = ? AND period = ? AND snapshot_date = ?", rankingType, period, previousDate.Time).
+		Find(&rankings).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ranking := range rankings {
+		ranks[ranking.StoryID] = ranking.Rank
+	}
+	return ranks, nil
+}
+
+// ReplaceSnapshot - Ghi đè snapshot của một ngày (chạy lại trong ngày sẽ cập nhật)
+func (r *rankingRepository) ReplaceSnapshot(rankingType, period string, snapshotDate time.Time, rankings []models.StoryRanking) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("ranking_type = ? AND period = ? AND snapshot_date = ?", rankingType, period, snapshotDate).
+			Delete(&models.StoryRanking{}).Error; err != nil {
+			return err
+		}
+		if len(rankings) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(rankings, 100).Error
+	})
+}
+
+// GetSnapshot - Lấy snapshot mới nhất có ngày <= onOrBefore
+// Truyện bị ẩn (mature) được bỏ qua, rank giữ nguyên như trong snapshot
+func (r *rankingRepository) GetSnapshot(rankingType, period string, onOrBefore time.Time, limit int, showMature bool) ([]models.StoryRanking, error) {
+	var rankings []models.StoryRanking
+
+	var snapshotDate sql.NullTime
+	err := r.db.Model(&models.StoryRanking{}).
+		Select("MAX(snapshot_date)").
+		Where("ranking_type = ? AND period = ? AND snapshot_date <= ?", rankingType, period, onOrBefore).
+		Scan(&snapshotDate).Error
+	if err != nil || !snapshotDate.Valid {
+		return rankings, err
+	}
+
+	err = whereAudience(r.db.Preload("Story"), "stories.age_rating", showMature).
+		Joins("JOIN stories ON stories.id = story_rankings.story_id AND stories.is_published = ? AND stories.deleted_at IS NULL", true).
+		Where("story_rankings.ranking_type = ? AND story_rankings.period = ? AND story_rankings.snapshot_date = ?",
+			rankingType, period, snapshotDate.Time).
+		Order("story_rankings.rank ASC").
+		Limit(limit).
+		Find(&rankings).Error
+	return rankings, err
+}
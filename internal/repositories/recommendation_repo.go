@@ -0,0 +1,196 @@
+package repositories
+
+import (
+	"nekozanedex/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SimilarityWeights - Trọng số kết hợp giữa thể loại và đồng đọc
+type SimilarityWeights struct {
+	Genre  float64
+	CoRead float64
+}
+
+// RecommendationWeights - Trọng số các thành phần điểm gợi ý cá nhân
+type RecommendationWeights struct {
+	Genre      float64 // Độ hợp thể loại với sở thích người đọc
+	Neighbour  float64 // Tương tự với truyện người đọc đã đọc/bookmark/đánh giá cao
+	Popularity float64 // Prior độ phổ biến (log view_count)
+}
+
+// ScoredStory - Truyện ứng viên kèm điểm
+type ScoredStory struct {
+	StoryID uuid.UUID
+	Score   float64
+}
+
+type RecommendationRepository interface {
+	RefreshSimilarities(neighbours, minCoReaders int, weights SimilarityWeights) error
+	GetSimilarStories(storyID uuid.UUID, limit int, showMature bool) ([]models.Story, error)
+	GetRecommendationCandidates(userID uuid.UUID, limit int, weights RecommendationWeights, showMature bool) ([]ScoredStory, error)
+}
+
+type recommendationRepository struct {
+	db *gorm.DB
+}
+
+func NewRecommendationRepository(db *gorm.DB) RecommendationRepository {
+	return &recommendationRepository{db: db}
+}
+
+// genre_pairs: Jaccard |G(a) ∩ G(b)| / |G(a) ∪ G(b)|
+// co_pairs: cosine |R(a) ∩ R(b)| / sqrt(|R(a)| * |R(b)|), R = người đọc (lịch sử đọc hoặc bookmark)
+// Mỗi truyện chỉ giữ `neighbours` truyện có điểm cao nhất
+const refreshSimilaritiesSQL = `
+WITH published AS (
+	SELECT id FROM stories WHERE is_published = true AND deleted_at IS NULL
+),
+sg AS (
+	SELECT sg.story_id, sg.genre_id FROM story_genres sg JOIN published p ON p.id = sg.story_id
+),
+genre_counts AS (
+	SELECT story_id, COUNT(*) AS cnt FROM sg GROUP BY story_id
+),
+genre_pairs AS (
+	SELECT a.story_id, b.story_id AS similar_story_id,
+		COUNT(*)::float / (ga.cnt + gb.cnt - COUNT(*)) AS score
+	FROM sg a
+	JOIN sg b ON b.genre_id = a.genre_id AND b.story_id <> a.story_id
+	JOIN genre_counts ga ON ga.story_id = a.story_id
+	JOIN genre_counts gb ON gb.story_id = b.story_id
+	GROUP BY a.story_id, b.story_id, ga.cnt, gb.cnt
+),
+readers AS (
+	SELECT x.user_id, x.story_id FROM (
+		SELECT user_id, story_id FROM reading_history
+		UNION
+		SELECT user_id, story_id FROM bookmarks
+	) x JOIN published p ON p.id = x.story_id
+),
+reader_counts AS (
+	SELECT story_id, COUNT(*) AS cnt FROM readers GROUP BY story_id
+),
+co_pairs AS (
+	SELECT a.story_id, b.story_id AS similar_story_id,
+		COUNT(*)::float / sqrt(ra.cnt * rb.cnt) AS score
+	FROM readers a
+	JOIN readers b ON b.user_id = a.user_id AND b.story_id <> a.story_id
+	JOIN reader_counts ra ON ra.story_id = a.story_id
+	JOIN reader_counts rb ON rb.story_id = b.story_id
+	GROUP BY a.story_id, b.story_id, ra.cnt, rb.cnt
+	HAVING COUNT(*) >= @min_co_readers
+),
+combined AS (
+	SELECT COALESCE(g.story_id, c.story_id) AS story_id,
+		COALESCE(g.similar_story_id, c.similar_story_id) AS similar_story_id,
+		COALESCE(g.score, 0) AS genre_score,
+		COALESCE(c.score, 0) AS co_read_score
+	FROM genre_pairs g
+	FULL OUTER JOIN co_pairs c ON c.story_id = g.story_id AND c.similar_story_id = g.similar_story_id
+),
+ranked AS (
+	SELECT story_id, similar_story_id, genre_score, co_read_score,
+		@w_genre * genre_score + @w_co_read * co_read_score AS score,
+		ROW_NUMBER() OVER (
+			PARTITION BY story_id
+			ORDER BY @w_genre * genre_score + @w_co_read * co_read_score DESC, similar_story_id
+		) AS rn
+	FROM combined
+)
+INSERT INTO story_similarities (story_id, similar_story_id, score, genre_score, co_read_score, computed_at)
+SELECT story_id, similar_story_id, score, genre_score, co_read_score, NOW()
+FROM ranked WHERE rn <= @neighbours`
+
+// RefreshSimilarities - Tính lại toàn bộ bảng truyện tương tự
+func (r *recommendationRepository) RefreshSimilarities(neighbours, minCoReaders int, weights SimilarityWeights) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM story_similarities").Error; err != nil {
+			return err
+		}
+		return tx.Exec(refreshSimilaritiesSQL, map[string]interface{}{
+			"neighbours":     neighbours,
+			"min_co_readers": minCoReaders,
+			"w_genre":        weights.Genre,
+			"w_co_read":      weights.CoRead,
+		}).Error
+	})
+}
+
+// GetSimilarStories - Lấy truyện tương tự đã tính sẵn (index story_id, score)
+func (r *recommendationRepository) GetSimilarStories(storyID uuid.UUID, limit int, showMature bool) ([]models.Story, error) {
+	var stories []models.Story
+	err := whereAudience(r.db.Preload("Genres"), "stories.age_rating", showMature).
+		Joins("JOIN story_similarities ss ON ss.similar_story_id = stories.id AND ss.story_id = ?", storyID).
+		Where("stories.is_published = ?", true).
+		Order("ss.score DESC").
+		Limit(limit).
+		Find(&stories).Error
+	return stories, err
+}
+
+// seeds: truyện người đọc đã tương tác, trọng số = đọc (giảm dần theo thời gian) + bookmark + (rating - 3)
+// seen: mọi truyện đã đọc/bookmark/đánh giá (kể cả đã đọc xong hoặc bỏ dở) bị loại khỏi gợi ý
+// genre_affinity chia cho MAX(ABS(w)) nên vẫn nằm trong [-1, 1] và giữ dấu khi mọi trọng số đều âm
+const recommendationCandidatesSQL = `
+WITH seeds AS (
+	SELECT story_id, SUM(w) AS w FROM (
+		SELECT story_id, power(0.5, EXTRACT(EPOCH FROM (NOW() - last_read_at)) / (30 * 86400)) AS w
+		FROM reading_history WHERE user_id = @user_id
+		UNION ALL
+		SELECT story_id, 2.0 FROM bookmarks WHERE user_id = @user_id
+		UNION ALL
+		SELECT story_id, (rating - 3)::float FROM story_ratings WHERE user_id = @user_id
+	) x GROUP BY story_id
+),
+seen AS (
+	SELECT story_id FROM reading_history WHERE user_id = @user_id
+	UNION SELECT story_id FROM bookmarks WHERE user_id = @user_id
+	UNION SELECT story_id FROM story_ratings WHERE user_id = @user_id
+),
+genre_affinity AS (
+	SELECT genre_id, w / NULLIF(MAX(ABS(w)) OVER (), 0) AS w FROM (
+		SELECT sg.genre_id, SUM(s.w) AS w
+		FROM seeds s JOIN story_genres sg ON sg.story_id = s.story_id
+		GROUP BY sg.genre_id
+	) g
+),
+genre_scores AS (
+	SELECT sg.story_id, SUM(COALESCE(ga.w, 0)) / sqrt(COUNT(*)) AS score
+	FROM story_genres sg LEFT JOIN genre_affinity ga ON ga.genre_id = sg.genre_id
+	GROUP BY sg.story_id
+),
+neighbour_scores AS (
+	SELECT ss.similar_story_id AS story_id, SUM(ss.score * s.w) AS score
+	FROM seeds s JOIN story_similarities ss ON ss.story_id = s.story_id
+	WHERE s.w > 0
+	GROUP BY ss.similar_story_id
+)
+SELECT st.id AS story_id,
+	@w_genre * COALESCE(g.score, 0) + @w_neighbour * COALESCE(n.score, 0) + @w_popularity * ln(1 + st.view_count) AS score
+FROM stories st
+LEFT JOIN genre_scores g ON g.story_id = st.id
+LEFT JOIN neighbour_scores n ON n.story_id = st.id
+WHERE st.is_published = true AND st.deleted_at IS NULL
+	AND st.id NOT IN (SELECT story_id FROM seen)
+	AND (@show_mature OR st.age_rating NOT IN @mature_ratings)
+	AND (g.score > 0 OR n.score > 0)
+ORDER BY score DESC, st.id
+LIMIT @limit`
+
+// GetRecommendationCandidates - Xếp hạng truyện chưa đọc theo sở thích người đọc
+// Trả về rỗng nếu người đọc chưa có tín hiệu nào
+func (r *recommendationRepository) GetRecommendationCandidates(userID uuid.UUID, limit int, weights RecommendationWeights, showMature bool) ([]ScoredStory, error) {
+	var candidates []ScoredStory
+	err := r.db.Raw(recommendationCandidatesSQL, map[string]interface{}{
+		"user_id":        userID,
+		"limit":          limit,
+		"show_mature":    showMature,
+		"mature_ratings": models.MatureAgeRatings,
+		"w_genre":        weights.Genre,
+		"w_neighbour":    weights.Neighbour,
+		"w_popularity":   weights.Popularity,
+	}).Scan(&candidates).Error
+	return candidates, err
+}
@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"nekozanedex/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type RoleRepository interface {
+	CreateRole(role *models.Role) error
+	FindRoleByID(id uuid.UUID) (*models.Role, error)
+	FindRoleByName(name string) (*models.Role, error)
+	UpdateRole(role *models.Role) error
+	DeleteRole(id uuid.UUID) error
+	GetAllRoles() ([]models.Role, error)
+	CountUsersWithRole(name string) (int64, error)
+	EnsureRole(role *models.Role) (bool, error)
+}
+
+type roleRepository struct {
+	db *gorm.DB
+}
+
+func NewRoleRepository(db *gorm.DB) RoleRepository {
+	return &roleRepository{db: db}
+}
+
+// CreateRole - Tạo Role
+func (r *roleRepository) CreateRole(role *models.Role) error {
+	return r.db.Create(role).Error
+}
+
+// FindRoleByID - Tìm Role theo ID
+func (r *roleRepository) FindRoleByID(id uuid.UUID) (*models.Role, error) {
+	var role models.Role
+	err := r.db.First(&role, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// FindRoleByName - Tìm Role theo tên (giá trị lưu ở users.role)
+func (r *roleRepository) FindRoleByName(name string) (*models.Role, error) {
+	var role models.Role
+	err := r.db.First(&role, "name = ?", name).Error
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// UpdateRole - Cập nhật Role
+func (r *roleRepository) UpdateRole(role *models.Role) error {
+	return r.db.Save(role).Error
+}
+
+// DeleteRole - Xóa Role
+func (r *roleRepository) DeleteRole(id uuid.UUID) error {
+	return r.db.Delete(&models.Role{}, "id = ?", id).Error
+}
+
+// GetAllRoles - Danh sách Role, role hệ thống trước
+func (r *roleRepository) GetAllRoles() ([]models.Role, error) {
+	var roles []models.Role
+	err := r.db.Order("is_system DESC, name ASC").Find(&roles).Error
+	return roles, err
+}
+
+// CountUsersWithRole - Đếm số user đang giữ Role
+func (r *roleRepository) CountUsersWithRole(name string) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.User{}).Where("role = ?", name).Count(&count).Error
+	return count, err
+}
+
+// EnsureRole - Tạo Role nếu chưa có tên này, không ghi đè quyền đã chỉnh
+func (r *roleRepository) EnsureRole(role *models.Role) (bool, error) {
+	result := r.db.Where("name = ?", role.Name).FirstOrCreate(role)
+	return result.RowsAffected > 0, result.Error
+}
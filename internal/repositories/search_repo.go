@@ -0,0 +1,102 @@
+package repositories
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StorySuggestion - Gợi ý truyện (chỉ các field cần cho dropdown)
+type StorySuggestion struct {
+	ID            uuid.UUID `json:"id"`
+	Title         string    `json:"title"`
+	Slug          string    `json:"slug"`
+	CoverImageURL *string   `json:"cover_image_url"`
+}
+
+// AuthorSuggestion - Gợi ý tác giả kèm số truyện
+type AuthorSuggestion struct {
+	Name       string `json:"name"`
+	StoryCount int64  `json:"story_count"`
+}
+
+// GenreSuggestion - Gợi ý thể loại
+type GenreSuggestion struct {
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+type SearchRepository interface {
+	SuggestStories(query string, limit int, showMature bool) ([]StorySuggestion, error)
+	SuggestAuthors(query string, limit int, showMature bool) ([]AuthorSuggestion, error)
+	SuggestGenres(query string, limit int) ([]GenreSuggestion, error)
+}
+
+type searchRepository struct {
+	db *gorm.DB
+}
+
+func NewSearchRepository(db *gorm.DB) SearchRepository {
+	return &searchRepository{db: db}
+}
+
+// Prefix match (LIKE 'q%') hoặc trigram similarity (%), cả hai dùng GIN trigram index
+// Prefix được ưu tiên trước, sau đó tới độ giống nhau
+const (
+	suggestMatch = "(f_unaccent(lower(%[1]s)) LIKE f_unaccent(lower(?)) || '%%' OR f_unaccent(lower(%[1]s)) %% f_unaccent(lower(?)))"
+	suggestOrder = "(f_unaccent(lower(%[1]s)) LIKE f_unaccent(lower(?)) || '%%') DESC, similarity(f_unaccent(lower(%[1]s)), f_unaccent(lower(?))) DESC"
+)
+
+// suggestClause - Gắn tên cột vào mẫu suggestMatch/suggestOrder
+func suggestClause(format, column string) string {
+	return fmt.Sprintf(format, column)
+}
+
+// orderExpr - ORDER BY với tham số (db.Order(string) không nhận placeholder)
+func orderExpr(sql string, vars ...interface{}) clause.OrderBy {
+	return clause.OrderBy{Expression: clause.Expr{SQL: sql, Vars: vars, WithoutParentheses: true}}
+}
+
+// SuggestStories - Gợi ý truyện theo tiêu đề
+func (r *searchRepository) SuggestStories(query string, limit int, showMature bool) ([]StorySuggestion, error) {
+	var suggestions []StorySuggestion
+	prefix := escapeSearchQuery(query)
+	err := whereAudience(r.db.Table("stories"), "age_rating", showMature).
+		Select("id, title, slug, cover_image_url").
+		Where("is_published = ? AND deleted_at IS NULL", true).
+		Where(suggestClause(suggestMatch, "title"), prefix, query).
+		Order(orderExpr(suggestClause(suggestOrder, "title"), prefix, query)).
+		Limit(limit).
+		Scan(&suggestions).Error
+	return suggestions, err
+}
+
+// SuggestAuthors - Gợi ý tác giả (gom theo tên)
+func (r *searchRepository) SuggestAuthors(query string, limit int, showMature bool) ([]AuthorSuggestion, error) {
+	var suggestions []AuthorSuggestion
+	prefix := escapeSearchQuery(query)
+	err := whereAudience(r.db.Table("stories"), "age_rating", showMature).
+		Select("author_name AS name, COUNT(*) AS story_count").
+		Where("is_published = ? AND deleted_at IS NULL AND author_name IS NOT NULL AND author_name <> ''", true).
+		Where(suggestClause(suggestMatch, "author_name"), prefix, query).
+		Group("author_name").
+		Order(orderExpr(suggestClause(suggestOrder, "author_name"), prefix, query)).
+		Limit(limit).
+		Scan(&suggestions).Error
+	return suggestions, err
+}
+
+// SuggestGenres - Gợi ý thể loại
+func (r *searchRepository) SuggestGenres(query string, limit int) ([]GenreSuggestion, error) {
+	var suggestions []GenreSuggestion
+	prefix := escapeSearchQuery(query)
+	err := r.db.Table("genres").
+		Select("name, slug").
+		Where(suggestClause(suggestMatch, "name"), prefix, query).
+		Order(orderExpr(suggestClause(suggestOrder, "name"), prefix, query)).
+		Limit(limit).
+		Scan(&suggestions).Error
+	return suggestions, err
+}
@@ -0,0 +1,55 @@
+package repositories
+
+import (
+	"nekozanedex/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type StoryRelationRepository interface {
+	SetRelation(storyID, relatedStoryID uuid.UUID, relationType string) error
+	DeleteRelation(storyID, relatedStoryID uuid.UUID) error
+	GetRelations(storyID uuid.UUID, publishedOnly bool) ([]models.StoryRelation, error)
+}
+
+type storyRelationRepository struct {
+	db *gorm.DB
+}
+
+func NewStoryRelationRepository(db *gorm.DB) StoryRelationRepository {
+	return &storyRelationRepository{db: db}
+}
+
+// SetRelation - Tạo/cập nhật quan hệ giữa hai truyện, ghi cả hai chiều
+func (r *storyRelationRepository) SetRelation(storyID, relatedStoryID uuid.UUID, relationType string) error {
+	relations := []models.StoryRelation{
+		{StoryID: storyID, RelatedStoryID: relatedStoryID, RelationType: relationType},
+		{StoryID: relatedStoryID, RelatedStoryID: storyID, RelationType: models.InverseRelation(relationType)},
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "story_id"}, {Name: "related_story_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"relation_type"}),
+	}).Create(&relations).Error
+}
+
+// DeleteRelation - Xóa quan hệ cả hai chiều
+func (r *storyRelationRepository) DeleteRelation(storyID, relatedStoryID uuid.UUID) error {
+	return r.db.Where("(story_id = ? AND related_story_id = ?) OR (story_id = ? AND related_story_id = ?)",
+		storyID, relatedStoryID, relatedStoryID, storyID).Delete(&models.StoryRelation{}).Error
+}
+
+// GetRelations - Lấy truyện liên quan của một truyện
+func (r *storyRelationRepository) GetRelations(storyID uuid.UUID, publishedOnly bool) ([]models.StoryRelation, error) {
+	var relations []models.StoryRelation
+	db := r.db.Joins("JOIN stories rs ON rs.id = story_relations.related_story_id AND rs.deleted_at IS NULL").
+		Where("story_relations.story_id = ?", storyID)
+	if publishedOnly {
+		db = db.Where("rs.is_published = ?", true)
+	}
+	err := db.Preload("RelatedStory").
+		Order("story_relations.relation_type ASC, rs.release_year ASC NULLS LAST, rs.created_at ASC").
+		Find(&relations).Error
+	return relations, err
+}
@@ -2,7 +2,9 @@ package repositories
 
 import (
 	"strings"
+	"time"
 
+	"nekozanedex/internal/database"
 	"nekozanedex/internal/models"
 
 	"github.com/google/uuid"
@@ -19,18 +21,49 @@ func escapeSearchQuery(query string) string {
 	return query
 }
 
+// storyTSQuery - tsquery cho full-text search (hỗ trợ "cụm từ", OR, -loại trừ)
+const storyTSQuery = "websearch_to_tsquery('" + database.SearchConfig + "', ?)"
+
+// whereStoryMatches - Lọc story theo search_vector (title, tên gốc, tên phụ, tác giả, dịch giả, mô tả)
+func whereStoryMatches(db *gorm.DB, query string) *gorm.DB {
+	return db.Where("search_vector @@ "+storyTSQuery, query)
+}
+
+// orderByRelevance - Sắp xếp theo độ liên quan (ts_rank_cd) với query
+func orderByRelevance(db *gorm.DB, query string) *gorm.DB {
+	return db.Order(orderExpr("ts_rank_cd(search_vector, "+storyTSQuery+") DESC, view_count DESC", query))
+}
+
+// whereAudience - Ẩn truyện mature/adult khi người xem chưa bật hiển thị nội dung người lớn
+// column: cột age_rating (có alias bảng nếu query có join)
+func whereAudience(db *gorm.DB, column string, showMature bool) *gorm.DB {
+	if showMature {
+		return db
+	}
+	return db.Where(column+" NOT IN ?", models.MatureAgeRatings)
+}
+
 type StoryRepository interface{
 	CreateStory(story *models.Story) error
 	FindStoryByID(id uuid.UUID) (*models.Story,error)
 	FindStoryBySlug(slug string) (*models.Story,error)
+	FindStoryMetaBySlug(slug string) (*models.Story, error)
+	FindStoryRefBySlug(slug string) (*models.Story, error)
+	FindPublishedStoriesByIDs(ids []uuid.UUID, showMature bool) ([]models.Story, error)
+	IsSlugTaken(slug string, excludeStoryID uuid.UUID) (bool, error)
+	ChangeStorySlug(storyID uuid.UUID, oldSlug, newSlug string) error
+	UpdateStoryWithSlug(story *models.Story, newSlug string) error
+	GetSlugHistory(storyID uuid.UUID) ([]models.StorySlugHistory, error)
 	UpdateStory(story *models.Story) error
 	UpdateStoryGenres(storyID uuid.UUID, genreIDs []uuid.UUID) error
 	DeleteStory(id uuid.UUID) error
-	GetAllStories(page, limit int, published bool) ([]models.Story, int64, error)
-	GetStoriesByGenre(genreID uuid.UUID, page, limit int) ([]models.Story, int64, error)
-	GetStoriesLatest(limit int) ([]models.Story, error)
-	GetStoriesHot(limit int) ([]models.Story, error)
-	SearchStories(query string, page, limit int) ([]models.Story, int64, error)
+	PurgeStory(id uuid.UUID) error
+	GetAllStories(page, limit int, published, showMature bool) ([]models.Story, int64, error)
+	GetAllStoriesByCursor(cursor string, limit int, published, showMature bool) ([]models.Story, string, error)
+	GetStoriesByGenre(genreID uuid.UUID, page, limit int, showMature bool) ([]models.Story, int64, error)
+	GetStoriesLatest(limit int, showMature bool) ([]models.Story, error)
+	GetStoriesHot(limit int, showMature bool) ([]models.Story, error)
+	SearchStories(query string, page, limit int, showMature bool) ([]models.Story, int64, error)
 	AdvancedSearchStories(filters *SearchFilters) ([]models.Story, int64, error)
 	SearchStoriesAdmin(query string, page, limit int) ([]models.Story, int64, error)
 	IncrementViewCountStory(id uuid.UUID) error
@@ -38,15 +71,18 @@ type StoryRepository interface{
 
 // SearchFilters - Filters for advanced story search
 type SearchFilters struct {
-	Query      string   // Text search query
-	Status     string   // ongoing, completed, hiatus
-	Country    string   // JP, CN, KR, VN
-	GenreSlugs []string // Genre slugs to filter by
-	YearFrom   *int     // Release year from
-	YearTo     *int     // Release year to
-	SortBy     string   // latest, popular, name, rating
-	Page       int
-	Limit      int
+	Query       string   // Text search query
+	Status      string   // ongoing, completed, hiatus
+	Country     string   // JP, CN, KR, VN
+	GenreSlugs  []string // Genre slugs to filter by
+	IncludeTags []string // Tag slugs bắt buộc có (đã đủ phiếu)
+	ExcludeTags []string // Tag slugs loại trừ
+	YearFrom    *int     // Release year from
+	YearTo      *int     // Release year to
+	ShowMature  bool     // Hiện cả truyện mature/adult
+	SortBy      string   // latest, popular, name, rating, oldest, relevance
+	Page        int
+	Limit       int
 }
 
 type storyRepository struct {
@@ -73,18 +109,110 @@ func (r *storyRepository) FindStoryByID(id uuid.UUID) (*models.Story, error){
 }
 
 //Find Story By Slug - Tìm Story Theo Slug
-
+// Slug cũ (đã đổi) vẫn tìm được truyện, khi đó story.Slug là slug hiện tại
 func (r *storyRepository) FindStoryBySlug(slug string) (*models.Story, error) {
 	var story models.Story
-	err := r.db.Preload("Genres").Preload("Chapters", func(db *gorm.DB) *gorm.DB {
-		return db.Where("is_published = ?", true).Order("chapter_number ASC")
-	}).First(&story, "slug = ? AND is_published = ?", slug, true).Error
+	err := r.db.Preload("Genres").Preload("People.Person").Preload("Chapters", func(db *gorm.DB) *gorm.DB {
+		return db.Where("is_published = ?", true).Order("ordering ASC, chapter_number ASC")
+	}).Where("is_published = ?", true).
+		Where("slug = ? OR id = (SELECT story_id FROM story_slug_history WHERE slug = ?)", slug, slug).
+		Order(orderExpr("slug = ? DESC", slug)).
+		First(&story).Error
+	if err != nil {
+		return nil, err
+	}
+	return &story, nil
+}
+
+// FindStoryMetaBySlug - Như FindStoryBySlug nhưng không tải chapters (chỉ thể loại, người liên quan)
+func (r *storyRepository) FindStoryMetaBySlug(slug string) (*models.Story, error) {
+	var story models.Story
+	err := r.db.Preload("Genres").Preload("People.Person").Where("is_published = ?", true).
+		Where("slug = ? OR id = (SELECT story_id FROM story_slug_history WHERE slug = ?)", slug, slug).
+		Order(orderExpr("slug = ? DESC", slug)).
+		First(&story).Error
+	if err != nil {
+		return nil, err
+	}
+	return &story, nil
+}
+
+// FindStoryRefBySlug - Chỉ id, slug, age_rating của truyện đã publish (không preload), để kiểm tra quyền xem
+func (r *storyRepository) FindStoryRefBySlug(slug string) (*models.Story, error) {
+	var story models.Story
+	err := r.db.Select("id", "slug", "age_rating").Where("is_published = ?", true).
+		Where("slug = ? OR id = (SELECT story_id FROM story_slug_history WHERE slug = ?)", slug, slug).
+		Order(orderExpr("slug = ? DESC", slug)).
+		First(&story).Error
 	if err != nil {
 		return nil, err
 	}
 	return &story, nil
 }
 
+// IsSlugTaken - Slug đã được dùng (kể cả truyện nháp/đã xóa hoặc slug cũ) bởi truyện khác
+func (r *storyRepository) IsSlugTaken(slug string, excludeStoryID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Raw(`SELECT
+		(SELECT COUNT(*) FROM stories WHERE slug = @slug AND id <> @exclude) +
+		(SELECT COUNT(*) FROM story_slug_history WHERE slug = @slug AND story_id <> @exclude)`,
+		map[string]interface{}{"slug": slug, "exclude": excludeStoryID}).Scan(&count).Error
+	return count > 0, err
+}
+
+// ChangeStorySlug - Đổi slug và lưu slug cũ vào lịch sử
+// Nếu slug mới từng là slug cũ của chính truyện này thì bỏ khỏi lịch sử
+func (r *storyRepository) ChangeStorySlug(storyID uuid.UUID, oldSlug, newSlug string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return changeStorySlug(tx, storyID, oldSlug, newSlug)
+	})
+}
+
+// UpdateStoryWithSlug - Lưu truyện và đổi slug (kèm lịch sử) trong cùng transaction
+// Lỗi ở bước nào thì cả tiêu đề lẫn slug đều giữ nguyên
+func (r *storyRepository) UpdateStoryWithSlug(story *models.Story, newSlug string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(story).Error; err != nil {
+			return err
+		}
+		if err := changeStorySlug(tx, story.ID, story.Slug, newSlug); err != nil {
+			return err
+		}
+		story.Slug = newSlug
+		return nil
+	})
+}
+
+func changeStorySlug(tx *gorm.DB, storyID uuid.UUID, oldSlug, newSlug string) error {
+	if err := tx.Where("story_id = ? AND slug = ?", storyID, newSlug).Delete(&models.StorySlugHistory{}).Error; err != nil {
+		return err
+	}
+	if oldSlug != "" && oldSlug != newSlug {
+		if err := tx.Create(&models.StorySlugHistory{StoryID: storyID, Slug: oldSlug}).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Model(&models.Story{}).Where("id = ?", storyID).Update("slug", newSlug).Error
+}
+
+// GetSlugHistory - Các slug cũ của truyện, mới nhất trước
+func (r *storyRepository) GetSlugHistory(storyID uuid.UUID) ([]models.StorySlugHistory, error) {
+	var history []models.StorySlugHistory
+	err := r.db.Where("story_id = ?", storyID).Order("created_at DESC").Find(&history).Error
+	return history, err
+}
+
+// FindPublishedStoriesByIDs - Lấy nhiều Story đã publish theo danh sách ID (không giữ thứ tự)
+func (r *storyRepository) FindPublishedStoriesByIDs(ids []uuid.UUID, showMature bool) ([]models.Story, error) {
+	var stories []models.Story
+	if len(ids) == 0 {
+		return stories, nil
+	}
+	query := whereAudience(r.db.Preload("Genres"), "age_rating", showMature)
+	err := query.Where("id IN ? AND is_published = ?", ids, true).Find(&stories).Error
+	return stories, err
+}
+
 //Update Story - Cập Nhật Story
 func (r *storyRepository) UpdateStory(story *models.Story) error{
 	return r.db.Save(story).Error
@@ -115,61 +243,107 @@ func (r *storyRepository) DeleteStory(id uuid.UUID) error{
 	return r.db.Delete(&models.Story{}, "id = ?", id).Error
 }
 
+// PurgeStory - Xóa hẳn truyện cùng chapter, thể loại, lịch sử slug (dọn truyện tạo dở, slug được giải phóng)
+func (r *storyRepository) PurgeStory(id uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("story_id = ?", id).Delete(&models.Chapter{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM story_genres WHERE story_id = ?", id).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("story_id = ?", id).Delete(&models.StorySlugHistory{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&models.Story{}, "id = ?", id).Error
+	})
+}
+
 //Get All Stories - Lấy Tất Cả Story
-func (r *storyRepository) GetAllStories(page, limit int, published bool) ([]models.Story, int64, error) {
+func (r *storyRepository) GetAllStories(page, limit int, published, showMature bool) ([]models.Story, int64, error) {
 	var stories []models.Story
 	var total int64
-	query := r.db.Model(&models.Story{})
+	query := whereAudience(r.db.Model(&models.Story{}), "age_rating", showMature)
 	if published {
 		query = query.Where("is_published = ?", true)
 	}
 	query.Count(&total)
 	offset := (page - 1) * limit
-	err := query.Preload("Genres").Offset(offset).Limit(limit).Order("updated_at DESC").Find(&stories).Error
+	err := query.Preload("Genres").Offset(offset).Limit(limit).Order("updated_at DESC, id DESC").Find(&stories).Error
 	return stories, total, err
 }
 
+// storyCursor - Khóa keyset cho danh sách truyện (updated_at DESC, id DESC)
+type storyCursor struct {
+	UpdatedAt time.Time `json:"u"`
+	ID        uuid.UUID `json:"i"`
+}
+
+// GetAllStoriesByCursor - Lấy Story theo cursor (keyset), không cần Count
+func (r *storyRepository) GetAllStoriesByCursor(cursor string, limit int, published, showMature bool) ([]models.Story, string, error) {
+	var stories []models.Story
+	query := whereAudience(r.db.Model(&models.Story{}), "age_rating", showMature)
+	if published {
+		query = query.Where("is_published = ?", true)
+	}
+	if cursor != "" {
+		var key storyCursor
+		if err := decodeCursor(cursor, &key); err != nil {
+			return nil, "", err
+		}
+		query = query.Where("(updated_at, id) < (?, ?)", key.UpdatedAt, key.ID)
+	}
+
+	err := query.Preload("Genres").Order("updated_at DESC, id DESC").Limit(limit + 1).Find(&stories).Error
+	if err != nil {
+		return nil, "", err
+	}
+	stories, next := cursorPage(stories, limit, func(s models.Story) interface{} {
+		return storyCursor{UpdatedAt: s.UpdatedAt, ID: s.ID}
+	})
+	return stories, next, nil
+}
+
 
 //Get Stories By Genre - Lấy Story Theo Thể Loại
-func (r *storyRepository) GetStoriesByGenre(genreID uuid.UUID, page, limit int) ([]models.Story, int64, error) {
+func (r *storyRepository) GetStoriesByGenre(genreID uuid.UUID, page, limit int, showMature bool) ([]models.Story, int64, error) {
 	var stories []models.Story
 	var total int64
 	subQuery := r.db.Table("story_genres").Select("story_id").Where("genre_id = ?", genreID)
 	
-	r.db.Model(&models.Story{}).Where("id IN (?) AND is_published = ?", subQuery, true).Count(&total)
+	whereAudience(r.db.Model(&models.Story{}), "age_rating", showMature).Where("id IN (?) AND is_published = ?", subQuery, true).Count(&total)
 	offset := (page - 1) * limit
-	err := r.db.Preload("Genres").Where("id IN (?) AND is_published = ?", subQuery, true).
+	err := whereAudience(r.db.Preload("Genres"), "age_rating", showMature).Where("id IN (?) AND is_published = ?", subQuery, true).
 		Offset(offset).Limit(limit).Order("updated_at DESC").Find(&stories).Error
 	return stories, total, err
 }
 
 //Get Stories Latest - Lấy Story Latest
-func (r *storyRepository) GetStoriesLatest(limit int) ([]models.Story, error) {
+func (r *storyRepository) GetStoriesLatest(limit int, showMature bool) ([]models.Story, error) {
 	var stories []models.Story
-	err := r.db.Preload("Genres").Where("is_published = ?", true).
+	err := whereAudience(r.db.Preload("Genres"), "age_rating", showMature).Where("is_published = ?", true).
 		Order("updated_at DESC").Limit(limit).Find(&stories).Error
 	return stories, err
 }
 
 //Get Stories Hot - Lấy Story Hot
-func (r *storyRepository) GetStoriesHot(limit int) ([]models.Story, error) {
+func (r *storyRepository) GetStoriesHot(limit int, showMature bool) ([]models.Story, error) {
 	var stories []models.Story
-	err := r.db.Preload("Genres").Where("is_published = ?", true).
+	err := whereAudience(r.db.Preload("Genres"), "age_rating", showMature).Where("is_published = ?", true).
 		Order("view_count DESC").Limit(limit).Find(&stories).Error
 	return stories, err
 }
 
+
 //Search Stories - Tìm kiếm Story
-func (r *storyRepository) SearchStories(query string, page, limit int) ([]models.Story, int64, error) {
+func (r *storyRepository) SearchStories(query string, page, limit int, showMature bool) ([]models.Story, int64, error) {
 	var stories []models.Story
 	var total int64
-	searchQuery := "%" + escapeSearchQuery(query) + "%"
-	
-	r.db.Model(&models.Story{}).Where("is_published = ? AND (title ILIKE ? OR description ILIKE ?)", 
-		true, searchQuery, searchQuery).Count(&total)
+
+	whereStoryMatches(whereAudience(r.db.Model(&models.Story{}), "age_rating", showMature), query).Where("is_published = ?", true).Count(&total)
 	offset := (page - 1) * limit
-	err := r.db.Preload("Genres").Where("is_published = ? AND (title ILIKE ? OR description ILIKE ?)", 
-		true, searchQuery, searchQuery).Offset(offset).Limit(limit).Find(&stories).Error
+	err := orderByRelevance(whereStoryMatches(whereAudience(r.db.Preload("Genres"), "age_rating", showMature), query), query).
+		Where("is_published = ?", true).Offset(offset).Limit(limit).Find(&stories).Error
 	return stories, total, err
 }
 
@@ -178,12 +352,11 @@ func (r *storyRepository) AdvancedSearchStories(filters *SearchFilters) ([]model
 	var stories []models.Story
 	var total int64
 
-	query := r.db.Model(&models.Story{}).Where("is_published = ?", true)
+	query := whereAudience(r.db.Model(&models.Story{}), "age_rating", filters.ShowMature).Where("is_published = ?", true)
 
 	// Text search
 	if filters.Query != "" {
-		searchQuery := "%" + escapeSearchQuery(filters.Query) + "%"
-		query = query.Where("title ILIKE ? OR description ILIKE ?", searchQuery, searchQuery)
+		query = whereStoryMatches(query, filters.Query)
 	}
 
 	// Status filter
@@ -213,6 +386,14 @@ func (r *storyRepository) AdvancedSearchStories(filters *SearchFilters) ([]model
 		)`, filters.GenreSlugs)
 	}
 
+	// Tag filter - alias được quy về tag chính, chỉ tính tag đã đủ phiếu
+	for _, tagSlug := range filters.IncludeTags {
+		query = query.Where("id IN (?)", storiesWithTag(r.db, tagSlug))
+	}
+	for _, tagSlug := range filters.ExcludeTags {
+		query = query.Where("id NOT IN (?)", storiesWithTag(r.db, tagSlug))
+	}
+
 	// Count total
 	query.Count(&total)
 
@@ -229,13 +410,27 @@ func (r *storyRepository) AdvancedSearchStories(filters *SearchFilters) ([]model
 		orderClause = "created_at ASC"
 	}
 
+	// Relevance chỉ có nghĩa khi có từ khóa, nếu không thì giữ mặc định latest
+	if filters.SortBy == "relevance" && filters.Query != "" {
+		query = orderByRelevance(query, filters.Query)
+	} else {
+		query = query.Order(orderClause)
+	}
+
 	// Paginate and fetch
 	offset := (filters.Page - 1) * filters.Limit
-	err := query.Preload("Genres").Order(orderClause).Offset(offset).Limit(filters.Limit).Find(&stories).Error
+	err := query.Preload("Genres").Offset(offset).Limit(filters.Limit).Find(&stories).Error
 
 	return stories, total, err
 }
 
+// storiesWithTag - Subquery story_id có tag (theo slug hoặc slug của alias) đủ ngưỡng hiển thị
+func storiesWithTag(db *gorm.DB, tagSlug string) *gorm.DB {
+	return db.Table("story_tags st").Select("st.story_id").
+		Joins("JOIN tags t ON t.id = st.tag_id AND t.status = ?", models.TagStatusActive).
+		Where("t.id = (SELECT COALESCE(alias_of_id, id) FROM tags WHERE slug = ?) AND st.score >= ?", tagSlug, models.TagVisibilityThreshold)
+}
+
 //Increment View Count Story - Tăng Lượt Xem Story
 func (r *storyRepository) IncrementViewCountStory(id uuid.UUID) error {
 	return r.db.Model(&models.Story{}).Where("id = ?", id).
@@ -246,12 +441,10 @@ func (r *storyRepository) IncrementViewCountStory(id uuid.UUID) error {
 func (r *storyRepository) SearchStoriesAdmin(query string, page, limit int) ([]models.Story, int64, error) {
 	var stories []models.Story
 	var total int64
-	searchQuery := "%" + escapeSearchQuery(query) + "%"
-	
-	r.db.Model(&models.Story{}).Where("title ILIKE ? OR description ILIKE ?", 
-		searchQuery, searchQuery).Count(&total)
+
+	whereStoryMatches(r.db.Model(&models.Story{}), query).Count(&total)
 	offset := (page - 1) * limit
-	err := r.db.Preload("Genres").Where("title ILIKE ? OR description ILIKE ?", 
-		searchQuery, searchQuery).Offset(offset).Limit(limit).Order("updated_at DESC").Find(&stories).Error
+	err := orderByRelevance(whereStoryMatches(r.db.Preload("Genres"), query), query).
+		Offset(offset).Limit(limit).Find(&stories).Error
 	return stories, total, err
 }
\ No newline at end of file
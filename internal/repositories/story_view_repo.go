@@ -11,6 +11,7 @@ import (
 
 type StoryViewRepository interface {
 	HasViewedRecently(storyID uuid.UUID, userID *uuid.UUID, ipAddress string, duration time.Duration) (bool, error)
+	HasViewedChapterRecently(chapterID uuid.UUID, userID *uuid.UUID, ipAddress string, duration time.Duration) (bool, error)
 	RecordView(view *models.StoryView) error
 	GetViewStats(storyID uuid.UUID) (int64, error)
 }
@@ -29,7 +30,7 @@ func (r *storyViewRepository) HasViewedRecently(storyID uuid.UUID, userID *uuid.
 	var count int64
 	cutoff := time.Now().Add(-duration)
 
-	query := r.db.Model(&models.StoryView{}).Where("story_id = ? AND viewed_at > ?", storyID, cutoff)
+	query := r.db.Model(&models.StoryView{}).Where("story_id = ? AND chapter_id IS NULL AND viewed_at > ?", storyID, cutoff)
 
 	// If user is logged in, check by user_id
 	if userID != nil {
@@ -46,6 +47,24 @@ func (r *storyViewRepository) HasViewedRecently(storyID uuid.UUID, userID *uuid.
 	return count > 0, nil
 }
 
+// HasViewedChapterRecently - Giống HasViewedRecently nhưng cho chapter
+func (r *storyViewRepository) HasViewedChapterRecently(chapterID uuid.UUID, userID *uuid.UUID, ipAddress string, duration time.Duration) (bool, error) {
+	var count int64
+	cutoff := time.Now().Add(-duration)
+
+	query := r.db.Model(&models.StoryView{}).Where("chapter_id = ? AND viewed_at > ?", chapterID, cutoff)
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	} else {
+		query = query.Where("user_id IS NULL AND ip_address = ?", ipAddress)
+	}
+
+	if err := query.Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
 func (r *storyViewRepository) RecordView(view *models.StoryView) error {
 	view.ViewedAt = time.Now()
 	return r.db.Create(view).Error
@@ -53,6 +72,6 @@ func (r *storyViewRepository) RecordView(view *models.StoryView) error {
 
 func (r *storyViewRepository) GetViewStats(storyID uuid.UUID) (int64, error) {
 	var count int64
-	err := r.db.Model(&models.StoryView{}).Where("story_id = ?", storyID).Count(&count).Error
+	err := r.db.Model(&models.StoryView{}).Where("story_id = ? AND chapter_id IS NULL", storyID).Count(&count).Error
 	return count, err
 }
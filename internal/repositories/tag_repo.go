@@ -0,0 +1,209 @@
+package repositories
+
+import (
+	"time"
+
+	"nekozanedex/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StoryTagView - Tag trên một truyện kèm số phiếu và phiếu của người đang xem
+type StoryTagView struct {
+	TagID     uuid.UUID `json:"tag_id"`
+	Name      string    `json:"name"`
+	Slug      string    `json:"slug"`
+	Upvotes   int       `json:"upvotes"`
+	Downvotes int       `json:"downvotes"`
+	Score     int       `json:"score"`
+	MyVote    int       `json:"my_vote"` // -1, 0, 1
+}
+
+type TagRepository interface {
+	CreateTag(tag *models.Tag) error
+	FindTagByID(id uuid.UUID) (*models.Tag, error)
+	FindTagBySlug(slug string) (*models.Tag, error)
+	FindTagByName(name string) (*models.Tag, error)
+	UpdateTag(tag *models.Tag) error
+	GetAllTags(query, status string, page, limit int) ([]models.Tag, int64, error)
+	SearchActiveTags(query string, limit int) ([]models.Tag, error)
+	GetStoryTags(storyID uuid.UUID, viewerID uuid.UUID, minScore *int) ([]StoryTagView, error)
+	Vote(storyID, tagID, userID uuid.UUID, value int) error
+	MergeTags(targetID, sourceID uuid.UUID) error
+}
+
+type tagRepository struct {
+	db *gorm.DB
+}
+
+func NewTagRepository(db *gorm.DB) TagRepository {
+	return &tagRepository{db: db}
+}
+
+// CreateTag - Tạo Tag
+func (r *tagRepository) CreateTag(tag *models.Tag) error {
+	return r.db.Create(tag).Error
+}
+
+// FindTagByID - Tìm Tag theo ID
+func (r *tagRepository) FindTagByID(id uuid.UUID) (*models.Tag, error) {
+	var tag models.Tag
+	err := r.db.First(&tag, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// FindTagBySlug - Tìm Tag theo Slug
+func (r *tagRepository) FindTagBySlug(slug string) (*models.Tag, error) {
+	var tag models.Tag
+	err := r.db.First(&tag, "slug = ?", slug).Error
+	if err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// FindTagByName - Tìm Tag theo tên (không phân biệt hoa thường)
+func (r *tagRepository) FindTagByName(name string) (*models.Tag, error) {
+	var tag models.Tag
+	err := r.db.First(&tag, "lower(name) = lower(?)", name).Error
+	if err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// UpdateTag - Cập nhật Tag
+func (r *tagRepository) UpdateTag(tag *models.Tag) error {
+	return r.db.Save(tag).Error
+}
+
+// GetAllTags - Danh sách Tag (Admin), gồm cả alias và tag bị ban
+func (r *tagRepository) GetAllTags(query, status string, page, limit int) ([]models.Tag, int64, error) {
+	var tags []models.Tag
+	var total int64
+
+	db := r.db.Model(&models.Tag{})
+	if query != "" {
+		db = db.Where("name ILIKE ?", "%"+escapeSearchQuery(query)+"%")
+	}
+	if status != "" {
+		db = db.Where("status = ?", status)
+	}
+	db.Count(&total)
+
+	offset := (page - 1) * limit
+	err := db.Preload("AliasOf").Order("name ASC").Offset(offset).Limit(limit).Find(&tags).Error
+	return tags, total, err
+}
+
+// SearchActiveTags - Tag đang hoạt động (không phải alias), phổ biến nhất trước
+func (r *tagRepository) SearchActiveTags(query string, limit int) ([]models.Tag, error) {
+	var tags []models.Tag
+	db := r.db.Where("status = ? AND alias_of_id IS NULL", models.TagStatusActive)
+	if query != "" {
+		db = db.Where("name ILIKE ?", escapeSearchQuery(query)+"%")
+	}
+	err := db.Order(orderExpr("(SELECT COUNT(*) FROM story_tags st WHERE st.tag_id = tags.id AND st.score >= ?) DESC, name ASC", models.TagVisibilityThreshold)).
+		Limit(limit).Find(&tags).Error
+	return tags, err
+}
+
+// GetStoryTags - Tag của truyện (bỏ tag bị ban/alias), minScore = nil để lấy cả tag chưa đủ phiếu
+func (r *tagRepository) GetStoryTags(storyID uuid.UUID, viewerID uuid.UUID, minScore *int) ([]StoryTagView, error) {
+	var tags []StoryTagView
+	db := r.db.Table("story_tags st").
+		Select("st.tag_id, t.name, t.slug, st.upvotes, st.downvotes, st.score, COALESCE(v.value, 0) AS my_vote").
+		Joins("JOIN tags t ON t.id = st.tag_id AND t.status = ? AND t.alias_of_id IS NULL", models.TagStatusActive).
+		Joins("LEFT JOIN story_tag_votes v ON v.story_id = st.story_id AND v.tag_id = st.tag_id AND v.user_id = ?", viewerID).
+		Where("st.story_id = ?", storyID)
+	if minScore != nil {
+		db = db.Where("st.score >= ?", *minScore)
+	}
+	err := db.Order("st.score DESC, t.name ASC").Scan(&tags).Error
+	return tags, err
+}
+
+// Vote - Ghi phiếu (value = 0 để bỏ phiếu) và tính lại điểm của tag trên truyện
+// Tag chưa có trên truyện sẽ được tạo (đề xuất); hết phiếu thì tag bị gỡ khỏi truyện
+func (r *tagRepository) Vote(storyID, tagID, userID uuid.UUID, value int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		storyTag := models.StoryTag{StoryID: storyID, TagID: tagID, ProposedBy: &userID}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&storyTag).Error; err != nil {
+			return err
+		}
+
+		if value == 0 {
+			if err := tx.Where("story_id = ? AND tag_id = ? AND user_id = ?", storyID, tagID, userID).
+				Delete(&models.StoryTagVote{}).Error; err != nil {
+				return err
+			}
+		} else {
+			vote := models.StoryTagVote{StoryID: storyID, TagID: tagID, UserID: userID, Value: value, UpdatedAt: time.Now()}
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "story_id"}, {Name: "tag_id"}, {Name: "user_id"}},
+				DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
+			}).Create(&vote).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := recountStoryTags(tx, tagID, &storyID); err != nil {
+			return err
+		}
+		return tx.Where(`story_id = ? AND tag_id = ? AND NOT EXISTS (
+			SELECT 1 FROM story_tag_votes v WHERE v.story_id = story_tags.story_id AND v.tag_id = story_tags.tag_id
+		)`, storyID, tagID).Delete(&models.StoryTag{}).Error
+	})
+}
+
+// MergeTags - Gộp source vào target: chuyển phiếu và liên kết truyện, source thành alias của target
+func (r *tagRepository) MergeTags(targetID, sourceID uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		statements := []string{
+			`INSERT INTO story_tags (story_id, tag_id, proposed_by, created_at, updated_at)
+				SELECT story_id, @target, proposed_by, created_at, NOW() FROM story_tags WHERE tag_id = @source
+				ON CONFLICT DO NOTHING`,
+			// Người đã vote cả hai tag giữ phiếu trên target
+			`INSERT INTO story_tag_votes (story_id, tag_id, user_id, value, created_at, updated_at)
+				SELECT story_id, @target, user_id, value, created_at, NOW() FROM story_tag_votes WHERE tag_id = @source
+				ON CONFLICT DO NOTHING`,
+			`DELETE FROM story_tag_votes WHERE tag_id = @source`,
+			`DELETE FROM story_tags WHERE tag_id = @source`,
+			`UPDATE tags SET alias_of_id = @target, updated_at = NOW() WHERE id = @source OR alias_of_id = @source`,
+		}
+		args := map[string]interface{}{"target": targetID, "source": sourceID}
+		for _, stmt := range statements {
+			if err := tx.Exec(stmt, args).Error; err != nil {
+				return err
+			}
+		}
+		return recountStoryTags(tx, targetID, nil)
+	})
+}
+
+// recountStoryTags - Tính lại upvotes/downvotes/score từ story_tag_votes
+func recountStoryTags(tx *gorm.DB, tagID uuid.UUID, storyID *uuid.UUID) error {
+	filter := "st2.tag_id = @tag_id"
+	args := map[string]interface{}{"tag_id": tagID}
+	if storyID != nil {
+		filter += " AND st2.story_id = @story_id"
+		args["story_id"] = *storyID
+	}
+	return tx.Exec(`
+		UPDATE story_tags st SET upvotes = c.up, downvotes = c.down, score = c.up - c.down, updated_at = NOW()
+		FROM (
+			SELECT st2.story_id, st2.tag_id,
+				COUNT(v.user_id) FILTER (WHERE v.value > 0) AS up,
+				COUNT(v.user_id) FILTER (WHERE v.value < 0) AS down
+			FROM story_tags st2
+			LEFT JOIN story_tag_votes v ON v.story_id = st2.story_id AND v.tag_id = st2.tag_id
+			WHERE `+filter+`
+			GROUP BY st2.story_id, st2.tag_id
+		) c
+		WHERE st.story_id = c.story_id AND st.tag_id = c.tag_id`, args).Error
+}
@@ -0,0 +1,113 @@
+package repositories
+
+import (
+	"time"
+
+	"nekozanedex/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TrendingWeights - Trọng số cho từng loại tín hiệu khi tính điểm trending
+type TrendingWeights struct {
+	View     float64
+	Rating   float64
+	Bookmark float64
+	Comment  float64
+}
+
+type TrendingRepository interface {
+	RefreshScores(window string, since time.Time, halfLife time.Duration, weights TrendingWeights) error
+	GetTrendingStories(window string, limit int, showMature bool) ([]models.Story, error)
+}
+
+type trendingRepository struct {
+	db *gorm.DB
+}
+
+func NewTrendingRepository(db *gorm.DB) TrendingRepository {
+	return &trendingRepository{db: db}
+}
+
+// Mỗi sự kiện đóng góp weight * 2^(-tuổi/halfLife), sự kiện càng mới càng nặng
+// story_views.viewed_at là kiểu date nên view được tính từ đầu ngày
+const refreshTrendingSQL = `
+INSERT INTO story_trending_scores (story_id, time_window, score, views, ratings, bookmarks, comments, computed_at)
+SELECT s.id, @window,
+	COALESCE(v.score, 0) * @w_view + COALESCE(r.score, 0) * @w_rating +
+	COALESCE(b.score, 0) * @w_bookmark + COALESCE(c.score, 0) * @w_comment,
+	COALESCE(v.cnt, 0), COALESCE(r.cnt, 0), COALESCE(b.cnt, 0), COALESCE(c.cnt, 0), NOW()
+FROM stories s
+LEFT JOIN (
+	SELECT story_id, SUM(view_count) AS cnt,
+		SUM(view_count * power(0.5, EXTRACT(EPOCH FROM (NOW() - viewed_at::timestamptz)) / @half_life)) AS score
+	FROM story_views WHERE viewed_at >= @since::date AND chapter_id IS NULL GROUP BY story_id
+) v ON v.story_id = s.id
+LEFT JOIN (
+	SELECT story_id, COUNT(*) AS cnt,
+		SUM(power(0.5, EXTRACT(EPOCH FROM (NOW() - updated_at)) / @half_life)) AS score
+	FROM story_ratings WHERE updated_at >= @since GROUP BY story_id
+) r ON r.story_id = s.id
+LEFT JOIN (
+	SELECT story_id, COUNT(*) AS cnt,
+		SUM(power(0.5, EXTRACT(EPOCH FROM (NOW() - created_at)) / @half_life)) AS score
+	FROM bookmarks WHERE created_at >= @since GROUP BY story_id
+) b ON b.story_id = s.id
+LEFT JOIN (
+	SELECT story_id, COUNT(*) AS cnt,
+		SUM(power(0.5, EXTRACT(EPOCH FROM (NOW() - created_at)) / @half_life)) AS score
+	FROM comments WHERE created_at >= @since AND deleted_at IS NULL GROUP BY story_id
+) c ON c.story_id = s.id
+WHERE s.is_published = true AND s.deleted_at IS NULL
+	AND (v.cnt > 0 OR r.cnt > 0 OR b.cnt > 0 OR c.cnt > 0)`
+
+// RefreshScores - Tính lại toàn bộ điểm trending của một window (thay thế snapshot cũ)
+func (r *trendingRepository) RefreshScores(window string, since time.Time, halfLife time.Duration, weights TrendingWeights) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("time_window = ?", window).Delete(&models.StoryTrendingScore{}).Error; err != nil {
+			return err
+		}
+		return tx.Exec(refreshTrendingSQL, map[string]interface{}{
+			"window":     window,
+			"since":      since,
+			"half_life":  halfLife.Seconds(),
+			"w_view":     weights.View,
+			"w_rating":   weights.Rating,
+			"w_bookmark": weights.Bookmark,
+			"w_comment":  weights.Comment,
+		}).Error
+	})
+}
+
+// GetTrendingStories - Lấy truyện theo điểm trending
+// Nếu window chưa đủ dữ liệu thì bù bằng truyện có view_count cao nhất
+func (r *trendingRepository) GetTrendingStories(window string, limit int, showMature bool) ([]models.Story, error) {
+	var stories []models.Story
+	err := whereAudience(r.db.Preload("Genres"), "stories.age_rating", showMature).
+		Joins("JOIN story_trending_scores ts ON ts.story_id = stories.id AND ts.time_window = ?", window).
+		Where("stories.is_published = ?", true).
+		Order("ts.score DESC").Limit(limit).Find(&stories).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if len(stories) < limit {
+		excludeIDs := make([]uuid.UUID, 0, len(stories))
+		for _, story := range stories {
+			excludeIDs = append(excludeIDs, story.ID)
+		}
+
+		var fallback []models.Story
+		query := whereAudience(r.db.Preload("Genres"), "age_rating", showMature).Where("is_published = ?", true)
+		if len(excludeIDs) > 0 {
+			query = query.Where("id NOT IN ?", excludeIDs)
+		}
+		if err := query.Order("view_count DESC").Limit(limit - len(stories)).Find(&fallback).Error; err != nil {
+			return nil, err
+		}
+		stories = append(stories, fallback...)
+	}
+
+	return stories, nil
+}
@@ -0,0 +1,97 @@
+package repositories
+
+import (
+	"nekozanedex/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type VolumeRepository interface {
+	CreateVolume(volume *models.Volume) error
+	FindVolumeByID(id uuid.UUID) (*models.Volume, error)
+	FindVolumeByNumber(storyID uuid.UUID, number int) (*models.Volume, error)
+	UpdateVolume(volume *models.Volume) error
+	DeleteVolume(id uuid.UUID) error
+	GetVolumesByStory(storyID uuid.UUID) ([]models.Volume, error)
+	SetVolumeChapters(volume *models.Volume, chapterIDs []uuid.UUID) (int64, error)
+}
+
+type volumeRepository struct {
+	db *gorm.DB
+}
+
+func NewVolumeRepository(db *gorm.DB) VolumeRepository {
+	return &volumeRepository{db: db}
+}
+
+// CreateVolume - Tạo Volume
+func (r *volumeRepository) CreateVolume(volume *models.Volume) error {
+	return r.db.Create(volume).Error
+}
+
+// FindVolumeByID - Tìm Volume theo ID
+func (r *volumeRepository) FindVolumeByID(id uuid.UUID) (*models.Volume, error) {
+	var volume models.Volume
+	err := r.db.First(&volume, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &volume, nil
+}
+
+// FindVolumeByNumber - Tìm Volume theo số tập trong truyện
+func (r *volumeRepository) FindVolumeByNumber(storyID uuid.UUID, number int) (*models.Volume, error) {
+	var volume models.Volume
+	err := r.db.First(&volume, "story_id = ? AND number = ?", storyID, number).Error
+	if err != nil {
+		return nil, err
+	}
+	return &volume, nil
+}
+
+// UpdateVolume - Cập nhật Volume
+func (r *volumeRepository) UpdateVolume(volume *models.Volume) error {
+	return r.db.Omit("Chapters").Save(volume).Error
+}
+
+// DeleteVolume - Xóa Volume, chapter của tập trở về "chưa phân tập"
+func (r *volumeRepository) DeleteVolume(id uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Chapter{}).Where("volume_id = ?", id).Update("volume_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Volume{}, "id = ?", id).Error
+	})
+}
+
+// GetVolumesByStory - Các tập của truyện theo số tập
+func (r *volumeRepository) GetVolumesByStory(storyID uuid.UUID) ([]models.Volume, error) {
+	var volumes []models.Volume
+	err := r.db.Where("story_id = ?", storyID).Order("number ASC").Find(&volumes).Error
+	return volumes, err
+}
+
+// SetVolumeChapters - Thay toàn bộ chapter của tập, chỉ nhận chapter cùng truyện
+func (r *volumeRepository) SetVolumeChapters(volume *models.Volume, chapterIDs []uuid.UUID) (int64, error) {
+	var assigned int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		detach := tx.Model(&models.Chapter{}).Where("volume_id = ?", volume.ID)
+		if len(chapterIDs) > 0 {
+			detach = detach.Where("id NOT IN ?", chapterIDs)
+		}
+		if err := detach.Update("volume_id", nil).Error; err != nil {
+			return err
+		}
+		if len(chapterIDs) == 0 {
+			return nil
+		}
+
+		result := tx.Model(&models.Chapter{}).
+			Where("id IN ? AND story_id = ?", chapterIDs, volume.StoryID).
+			Update("volume_id", volume.ID)
+		assigned = result.RowsAffected
+		return result.Error
+	})
+	return assigned, err
+}
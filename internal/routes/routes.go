@@ -4,6 +4,7 @@ import (
 	"nekozanedex/internal/config"
 	"nekozanedex/internal/handlers"
 	"nekozanedex/internal/middleware"
+	"nekozanedex/internal/models"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
@@ -25,6 +26,17 @@ type Handlers struct {
 	UserSettings   *handlers.UserSettingsHandler
 	Centrifugo     *handlers.CentrifugoHandler
 	StoryRating    *handlers.StoryRatingHandler
+	Search         *handlers.SearchHandler
+	Ranking        *handlers.RankingHandler
+	Analytics      *handlers.AnalyticsHandler
+	Recommendation *handlers.RecommendationHandler
+	Person         *handlers.PersonHandler
+	Tag            *handlers.TagHandler
+	Group          *handlers.GroupHandler
+	Role           *handlers.RoleHandler
+	Volume         *handlers.VolumeHandler
+	Export         *handlers.ExportHandler
+	Import         *handlers.ImportHandler
 }
 
 func SetupRoutes(r *gin.Engine, cfg *config.Config, h *Handlers) {
@@ -49,6 +61,13 @@ func SetupRoutes(r *gin.Engine, cfg *config.Config, h *Handlers) {
 	// Swagger API Documentation
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	// Người xem (không bắt buộc đăng nhập) + setting hiển thị truyện mature/adult
+	// Dùng cho các route public trả về truyện để lọc nhất quán
+	viewer := []gin.HandlerFunc{
+		middleware.OptionalAuthMiddleware(cfg),
+		middleware.MatureContentMiddleware(h.UserSettings.ShowMatureContent),
+	}
+
 	// API routes với General Rate Limiting (100 req/min)
 	api := r.Group("/api")
 	api.Use(middleware.GeneralRateLimiter())
@@ -77,6 +96,7 @@ func SetupRoutes(r *gin.Engine, cfg *config.Config, h *Handlers) {
 
 		// ============ STORY ROUTES (Public) ============
 		stories := api.Group("/stories")
+		stories.Use(viewer...)
 		{
 			stories.GET("", h.Story.GetStories)
 			stories.GET("/latest", h.Story.GetLatestStories)
@@ -86,6 +106,65 @@ func SetupRoutes(r *gin.Engine, cfg *config.Config, h *Handlers) {
 			stories.GET("/:slug", h.Story.GetStoryBySlug)
 			stories.GET("/:slug/chapters", h.Chapter.GetChaptersByStory)
 			stories.GET("/:slug/chapters/:number", h.Chapter.GetChapterByNumber)
+			stories.GET("/:slug/chapters/:number/navigation", h.Chapter.GetChapterNavigation)
+			if h.Recommendation != nil {
+				stories.GET("/:slug/similar", h.Recommendation.GetSimilarStories)
+			}
+			if h.Export != nil {
+				stories.GET("/:slug/export.epub", middleware.ExportRateLimiter(), h.Export.ExportEPUB)
+
+				// CBZ cho người đã đăng nhập, giới hạn lượt tải theo user (dùng chung cho 2 route)
+				download := []gin.HandlerFunc{middleware.AuthMiddleware(cfg), middleware.DownloadRateLimiter()}
+				stories.GET("/:slug/download.cbz", append(download, h.Export.DownloadStoryCBZ)...)
+				stories.GET("/:slug/chapters/:number/download.cbz", append(download, h.Export.DownloadChapterCBZ)...)
+			}
+		}
+
+		// ============ SEARCH ROUTES (Public) ============
+		if h.Search != nil {
+			api.GET("/search/suggest", append(viewer, h.Search.Suggest)...)
+		}
+
+		// ============ RECOMMENDATION ROUTES (Optional auth, khách nhận trending) ============
+		if h.Recommendation != nil {
+			api.GET("/recommendations", append(viewer, h.Recommendation.GetRecommendations)...)
+		}
+
+		// ============ PEOPLE ROUTES (Public) ============
+		if h.Person != nil {
+			api.GET("/people/:slug", append(viewer, h.Person.GetPersonBySlug)...)
+		}
+
+		// ============ GROUP ROUTES (Nhóm dịch) ============
+		if h.Group != nil {
+			groups := api.Group("/groups")
+			{
+				groups.GET("", h.Group.GetGroups)
+				groups.GET("/:slug", append(viewer, h.Group.GetGroupBySlug)...)
+				groups.GET("/:slug/releases", append(viewer, h.Group.GetGroupReleases)...)
+			}
+
+			// Thành viên nhóm quản lý chapter của truyện được giao, quyền theo role trong nhóm
+			groupsAuth := api.Group("/groups")
+			groupsAuth.Use(middleware.AuthMiddleware(cfg))
+			groupsAuth.Use(middleware.CSRFMiddleware(csrfCfg))
+			{
+				groupsAuth.GET("/mine", h.Group.GetMyGroups)
+				groupsAuth.PUT("/:slug", h.Group.UpdateGroup)
+				groupsAuth.PUT("/:slug/members/:userId", h.Group.SetGroupMember)
+				groupsAuth.DELETE("/:slug/members/:userId", h.Group.RemoveGroupMember)
+				groupsAuth.GET("/:slug/stories/:storyId/chapters", h.Group.GetGroupStoryChapters)
+				groupsAuth.POST("/:slug/stories/:storyId/chapters", h.Group.CreateGroupChapter)
+				groupsAuth.PUT("/:slug/chapters/:chapterId", h.Group.UpdateGroupChapter)
+				groupsAuth.DELETE("/:slug/chapters/:chapterId", h.Group.DeleteGroupChapter)
+				groupsAuth.POST("/:slug/chapters/:chapterId/publish", h.Group.PublishGroupChapter)
+				groupsAuth.POST("/:slug/chapters/:chapterId/schedule", h.Group.ScheduleGroupChapter)
+			}
+		}
+
+		// ============ RANKING ROUTES (Public) ============
+		if h.Ranking != nil {
+			api.GET("/rankings/:type/:period", append(viewer, h.Ranking.GetRankings)...)
 		}
 
 		// ============ STORY RATING ROUTES ============
@@ -98,7 +177,7 @@ func SetupRoutes(r *gin.Engine, cfg *config.Config, h *Handlers) {
 				// Authenticated: rate, get my rating, delete my rating
 				ratingsAuth := ratings.Group("")
 				ratingsAuth.Use(middleware.AuthMiddleware(cfg))
-				ratingsAuth.Use(middleware.RoleMiddleware("reader", "admin"))
+				ratingsAuth.Use(middleware.RequirePermission(models.PermRatingsWrite))
 				{
 					ratingsAuth.POST("", h.StoryRating.RateStory)
 					ratingsAuth.GET("/my", h.StoryRating.GetMyRating)
@@ -107,16 +186,35 @@ func SetupRoutes(r *gin.Engine, cfg *config.Config, h *Handlers) {
 			}
 		}
 
+		// ============ TAG ROUTES (Community tags) ============
+		if h.Tag != nil {
+			api.GET("/tags", h.Tag.SearchTags)
+
+			storyTags := api.Group("/tags/story/:storyId")
+			{
+				storyTags.GET("", middleware.OptionalAuthMiddleware(cfg), h.Tag.GetStoryTags)
+
+				storyTagsAuth := storyTags.Group("")
+				storyTagsAuth.Use(middleware.AuthMiddleware(cfg))
+				storyTagsAuth.Use(middleware.RequirePermission(models.PermTagsVote))
+				storyTagsAuth.Use(middleware.MatureContentMiddleware(h.UserSettings.ShowMatureContent))
+				{
+					storyTagsAuth.POST("", middleware.CommentRateLimiter(), h.Tag.ProposeTag)
+					storyTagsAuth.POST("/:tagId/vote", middleware.LikeRateLimiter(), h.Tag.VoteTag)
+				}
+			}
+		}
+
 		// ============ GENRE ROUTES ============
 		genres := api.Group("/genres")
 		{
 			genres.GET("", h.Story.GetAllGenres)
-			genres.GET("/:genre/stories", h.Story.GetStoriesByGenre)
+			genres.GET("/:genre/stories", append(viewer, h.Story.GetStoriesByGenre)...)
 		}
 
 		// ============ COMMENT ROUTES ============
 		comments := api.Group("/comments")
-		comments.Use(middleware.OptionalAuthMiddleware(cfg))
+		comments.Use(viewer...)
 		{
 			comments.GET("/story/:storyId", h.Comment.GetCommentsByStory)
 			comments.GET("/chapter/:chapterId", h.Comment.GetCommentsByChapter)
@@ -124,23 +222,24 @@ func SetupRoutes(r *gin.Engine, cfg *config.Config, h *Handlers) {
 
 		commentsAuth := api.Group("/comments")
 		commentsAuth.Use(middleware.AuthMiddleware(cfg))
-		commentsAuth.Use(middleware.RoleMiddleware("reader", "admin")) // Reader hoặc Admin
+		commentsAuth.Use(middleware.RequirePermission(models.PermCommentsWrite))
 		{
-			commentsAuth.POST("/:commentId/reply", middleware.CommentRateLimiter(), h.Comment.ReplyComment)
+			commentsAuth.POST("/:commentId/reply", middleware.MatureContentMiddleware(h.UserSettings.ShowMatureContent), middleware.CommentRateLimiter(), h.Comment.ReplyComment)
 			commentsAuth.POST("/:commentId/like", middleware.LikeRateLimiter(), h.Comment.ToggleLike)
-			commentsAuth.POST("/:commentId/pin", h.Comment.TogglePin)
 			commentsAuth.POST("/:commentId/report", h.Comment.ReportComment)
 			commentsAuth.PUT("/:commentId", h.Comment.UpdateComment)
 			commentsAuth.DELETE("/:commentId", h.Comment.DeleteComment)
 		}
 
+		// Kiểm duyệt: ghim bình luận (moderator)
+		api.POST("/comments/:commentId/pin", middleware.AuthMiddleware(cfg), middleware.RequirePermission(models.PermCommentsModerate), h.Comment.TogglePin)
+
 		// Story comments (authenticated)
-		api.POST("/stories/:storyId/comments", middleware.AuthMiddleware(cfg), middleware.CommentRateLimiter(), h.Comment.CreateComment)
+		api.POST("/stories/:storyId/comments", middleware.AuthMiddleware(cfg), middleware.RequirePermission(models.PermCommentsWrite), middleware.MatureContentMiddleware(h.UserSettings.ShowMatureContent), middleware.CommentRateLimiter(), h.Comment.CreateComment)
 
 		// ============ BOOKMARK ROUTES (Reader + Admin) ============
 		bookmarks := api.Group("/bookmarks")
 		bookmarks.Use(middleware.AuthMiddleware(cfg))
-		bookmarks.Use(middleware.RoleMiddleware("reader", "admin"))
 		{
 			bookmarks.GET("", h.Bookmark.GetMyBookmarks)
 			bookmarks.POST("/:storyId", h.Bookmark.AddBookmark)
@@ -151,7 +250,6 @@ func SetupRoutes(r *gin.Engine, cfg *config.Config, h *Handlers) {
 		// ============ NOTIFICATION ROUTES (Reader + Admin) ============
 		notifications := api.Group("/notifications")
 		notifications.Use(middleware.AuthMiddleware(cfg))
-		notifications.Use(middleware.RoleMiddleware("reader", "admin"))
 		{
 			notifications.GET("", h.Notification.GetMyNotifications)
 			notifications.GET("/unread-count", h.Notification.GetUnreadCount)
@@ -163,7 +261,6 @@ func SetupRoutes(r *gin.Engine, cfg *config.Config, h *Handlers) {
 		if h.ReadingHistory != nil {
 			readingHistory := api.Group("/reading-history")
 			readingHistory.Use(middleware.AuthMiddleware(cfg))
-			readingHistory.Use(middleware.RoleMiddleware("reader", "admin"))
 			{
 				readingHistory.POST("", h.ReadingHistory.SaveProgress)
 				readingHistory.GET("", h.ReadingHistory.GetHistory)
@@ -178,47 +275,101 @@ func SetupRoutes(r *gin.Engine, cfg *config.Config, h *Handlers) {
 		if h.UserSettings != nil {
 			settings := api.Group("/settings")
 			settings.Use(middleware.AuthMiddleware(cfg))
-			settings.Use(middleware.RoleMiddleware("reader", "admin"))
 			{
 				settings.GET("", h.UserSettings.GetMySettings)
 				settings.PUT("", h.UserSettings.UpdateMySettings)
 			}
 		}
 
-		// ============ ADMIN ROUTES (Admin Only) ============
+		// ============ ADMIN ROUTES (theo quyền của role) ============
 		admin := api.Group("/admin")
 		admin.Use(middleware.AuthMiddleware(cfg))
 		admin.Use(middleware.CSRFMiddleware(csrfCfg))
-		admin.Use(middleware.RoleMiddleware("admin")) // Chỉ Admin
+		// Chặn mặc định: phải có ít nhất một quyền quản trị, từng nhóm route bên dưới thu hẹp thêm
+		admin.Use(middleware.RequirePermission(models.AdminPermissions...))
 		{
+			// Xem truyện: cả người quản lý chapter cũng cần để tìm truyện
+			admin.GET("/stories", middleware.RequirePermission(models.PermStoriesManage, models.PermChaptersManage), h.Story.GetAllStoriesAdmin)
+			admin.GET("/stories/:id", middleware.RequirePermission(models.PermStoriesManage, models.PermChaptersManage), h.Story.GetStoryByID)
+
 			// Admin Stories
 			adminStories := admin.Group("/stories")
+			adminStories.Use(middleware.RequirePermission(models.PermStoriesManage))
 			{
-				adminStories.GET("", h.Story.GetAllStoriesAdmin)
-				adminStories.GET("/:id", h.Story.GetStoryByID)
 				adminStories.POST("", h.Story.CreateStory)
 				adminStories.PUT("/:id", h.Story.UpdateStory)
 				adminStories.DELETE("/:id", h.Story.DeleteStory)
 
-				// Admin Chapters (nested under stories)
-				adminStories.GET("/:id/chapters", h.Chapter.GetChaptersByStoryAdmin)
-				adminStories.POST("/:id/chapters", h.Chapter.CreateChapter)
-				adminStories.POST("/:id/chapters/bulk", h.Chapter.BulkImportChapters)
+				// Admin Story Slug (ghim slug, lịch sử slug)
+				adminStories.PUT("/:id/slug", h.Story.PinStorySlug)
+				adminStories.GET("/:id/slug-history", h.Story.GetSlugHistory)
+
+				// Admin Story Relations (sequel, adaptation...)
+				adminStories.GET("/:id/relations", h.Story.GetStoryRelations)
+				adminStories.POST("/:id/relations", h.Story.SetStoryRelation)
+				adminStories.DELETE("/:id/relations/:relatedId", h.Story.DeleteStoryRelation)
+			}
+
+			// Admin Chapters (nested under stories)
+			adminStoryChapters := admin.Group("/stories/:id/chapters")
+			adminStoryChapters.Use(middleware.RequirePermission(models.PermChaptersManage))
+			{
+				adminStoryChapters.GET("", h.Chapter.GetChaptersByStoryAdmin)
+				adminStoryChapters.POST("", h.Chapter.CreateChapter)
+				adminStoryChapters.POST("/bulk", h.Chapter.BulkImportChapters)
+				if h.Import != nil {
+					adminStoryChapters.POST("/import/epub", h.Import.ImportEPUBChapters)
+					adminStoryChapters.POST("/import/archive", middleware.RequirePermission(models.PermMediaUpload), h.Import.ImportArchiveChapters)
+				}
+			}
+
+			// Admin Import (tạo truyện + chapters từ file)
+			if h.Import != nil {
+				admin.POST("/import/epub", middleware.RequirePermission(models.PermStoriesManage), h.Import.ImportEPUBStory)
+				admin.GET("/import/jobs/:jobId", middleware.RequirePermission(models.PermChaptersManage), h.Import.GetImportJob)
 			}
 
 			// Admin Chapters
 			adminChapters := admin.Group("/chapters")
+			adminChapters.Use(middleware.RequirePermission(models.PermChaptersManage))
 			{
 				adminChapters.GET("/:id", h.Chapter.GetChapterByID)
 				adminChapters.PUT("/:id", h.Chapter.UpdateChapter)
 				adminChapters.DELETE("/:id", h.Chapter.DeleteChapter)
 				adminChapters.POST("/:id/publish", h.Chapter.PublishChapter)
 				adminChapters.POST("/:id/schedule", h.Chapter.ScheduleChapter)
+				adminChapters.POST("/:id/move", h.Chapter.MoveChapter)
+
+				// Lịch sử revision, diff, khôi phục
+				adminChapters.GET("/:id/revisions", h.Chapter.GetChapterRevisions)
+				adminChapters.GET("/:id/revisions/diff", h.Chapter.DiffChapterRevisions)
+				adminChapters.GET("/:id/revisions/:number", h.Chapter.GetChapterRevision)
+				adminChapters.POST("/:id/revisions/:number/restore", h.Chapter.RestoreChapterRevision)
+			}
+
+			// Admin Volumes (tập/arc, gán chapter vào tập)
+			if h.Volume != nil {
+				adminStoryVolumes := admin.Group("/stories/:id/volumes")
+				adminStoryVolumes.Use(middleware.RequirePermission(models.PermChaptersManage))
+				{
+					adminStoryVolumes.GET("", h.Volume.GetStoryVolumes)
+					adminStoryVolumes.POST("", h.Volume.CreateVolume)
+				}
+
+				adminVolumes := admin.Group("/volumes")
+				adminVolumes.Use(middleware.RequirePermission(models.PermChaptersManage))
+				{
+					adminVolumes.PUT("/:id", h.Volume.UpdateVolume)
+					adminVolumes.DELETE("/:id", h.Volume.DeleteVolume)
+					adminVolumes.PUT("/:id/chapters", h.Volume.SetVolumeChapters)
+					adminVolumes.POST("/:id/cover", middleware.RequirePermission(models.PermMediaUpload), h.Volume.UploadVolumeCover)
+				}
 			}
 
 			// Admin Media (Cloudinary uploads for stories/chapters)
 			if h.Upload != nil {
 				adminMedia := admin.Group("/media")
+				adminMedia.Use(middleware.RequirePermission(models.PermMediaUpload))
 				{
 					adminMedia.POST("", h.Upload.UploadSingleImage)
 					adminMedia.POST("/chapter", h.Upload.UploadChapterImages)
@@ -229,6 +380,7 @@ func SetupRoutes(r *gin.Engine, cfg *config.Config, h *Handlers) {
 			// Admin Genres
 			if h.Genre != nil {
 				adminGenres := admin.Group("/genres")
+				adminGenres.Use(middleware.RequirePermission(models.PermGenresManage))
 				{
 					adminGenres.POST("", h.Genre.CreateGenre)
 					adminGenres.PUT("/:id", h.Genre.UpdateGenre)
@@ -236,9 +388,63 @@ func SetupRoutes(r *gin.Engine, cfg *config.Config, h *Handlers) {
 				}
 			}
 
+			// Admin People (tác giả, họa sĩ, dịch giả)
+			if h.Person != nil {
+				adminPeople := admin.Group("/people")
+				adminPeople.Use(middleware.RequirePermission(models.PermPeopleManage))
+				{
+					adminPeople.GET("", h.Person.GetAllPeopleAdmin)
+					adminPeople.POST("", h.Person.CreatePerson)
+					adminPeople.POST("/migrate", h.Person.MigrateStoryCredits)
+					adminPeople.GET("/:id", h.Person.GetPersonByID)
+					adminPeople.PUT("/:id", h.Person.UpdatePerson)
+					adminPeople.DELETE("/:id", h.Person.DeletePerson)
+					adminPeople.POST("/:id/merge", h.Person.MergePerson)
+				}
+				admin.PUT("/stories/:id/people", middleware.RequirePermission(models.PermPeopleManage), h.Person.SetStoryPeople)
+			}
+
+			// Admin Tags (merge, alias, ban)
+			if h.Tag != nil {
+				adminTags := admin.Group("/tags")
+				adminTags.Use(middleware.RequirePermission(models.PermTagsManage))
+				{
+					adminTags.GET("", h.Tag.GetAllTagsAdmin)
+					adminTags.POST("", h.Tag.CreateTag)
+					adminTags.PUT("/:id", h.Tag.UpdateTag)
+					adminTags.POST("/:id/aliases", h.Tag.AddTagAlias)
+					adminTags.POST("/:id/merge", h.Tag.MergeTag)
+					adminTags.POST("/:id/ban", h.Tag.BanTag)
+					adminTags.POST("/:id/unban", h.Tag.UnbanTag)
+				}
+			}
+
+			// Admin Groups (nhóm dịch, giao truyện cho nhóm)
+			if h.Group != nil {
+				manageGroups := middleware.RequirePermission(models.PermGroupsManage)
+				admin.POST("/groups", manageGroups, h.Group.CreateGroup)
+				admin.DELETE("/groups/:id", manageGroups, h.Group.DeleteGroup)
+				admin.PUT("/stories/:id/group", manageGroups, h.Group.AssignStoryGroup)
+			}
+
+			// Admin Analytics (lượt xem, người đọc, nguồn truy cập)
+			if h.Analytics != nil {
+				adminAnalytics := admin.Group("/analytics")
+				adminAnalytics.Use(middleware.RequirePermission(models.PermAnalyticsView))
+				{
+					adminAnalytics.GET("/overview", h.Analytics.GetOverview)
+					adminAnalytics.GET("/stories/:id", h.Analytics.GetStoryAnalytics)
+					adminAnalytics.GET("/chapters/:id", h.Analytics.GetChapterAnalytics)
+					adminAnalytics.GET("/referrers", h.Analytics.GetTopReferrers)
+					adminAnalytics.GET("/top-stories", h.Analytics.GetTopStories)
+				}
+				admin.GET("/stories/:id/funnel", middleware.RequirePermission(models.PermAnalyticsView), h.Analytics.GetStoryFunnel)
+			}
+
 			// Admin Users
 			if h.User != nil {
 				adminUsers := admin.Group("/users")
+				adminUsers.Use(middleware.RequirePermission(models.PermUsersManage))
 				{
 					adminUsers.GET("", h.User.GetAllUsersAdmin)
 					adminUsers.PUT("/:id", h.User.AdminUpdateUser)
@@ -248,8 +454,22 @@ func SetupRoutes(r *gin.Engine, cfg *config.Config, h *Handlers) {
 				}
 			}
 
-			// Admin Comment Reports
+			// Admin Roles (role = tập quyền)
+			if h.Role != nil {
+				adminRoles := admin.Group("/roles")
+				adminRoles.Use(middleware.RequirePermission(models.PermRolesManage))
+				{
+					adminRoles.GET("", h.Role.GetRoles)
+					adminRoles.GET("/permissions", h.Role.GetPermissions)
+					adminRoles.POST("", h.Role.CreateRole)
+					adminRoles.PUT("/:id", h.Role.UpdateRole)
+					adminRoles.DELETE("/:id", h.Role.DeleteRole)
+				}
+			}
+
+			// Admin Comment Reports (moderator)
 			adminReports := admin.Group("/comments/reports")
+			adminReports.Use(middleware.RequirePermission(models.PermCommentsModerate))
 			{
 				adminReports.GET("", h.Comment.GetReports)
 				adminReports.PUT("/:reportId", h.Comment.ResolveReport)
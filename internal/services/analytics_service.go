@@ -0,0 +1,302 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"nekozanedex/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// rawViewRetention - Thời gian giữ raw story_views, cũ hơn chỉ còn trong bảng daily
+	rawViewRetention = 90 * 24 * time.Hour
+	// analyticsDefaultRange - Khoảng mặc định khi không truyền from/to
+	analyticsDefaultRange = 30 * 24 * time.Hour
+	// analyticsMaxDays - Khoảng tối đa cho một truy vấn analytics
+	analyticsMaxDays  = 366
+	analyticsTopLimit = 10
+	// funnelFlaggedDropOffs - Số chapter có lượng người bỏ đọc lớn nhất được đánh dấu
+	funnelFlaggedDropOffs = 3
+)
+
+// AnalyticsTotals - Tổng của chuỗi theo ngày
+// UniqueReaders chỉ có khi khoảng còn nằm trong thời gian giữ raw (đếm chính xác, không cộng dồn theo ngày)
+type AnalyticsTotals struct {
+	Views          int64  `json:"views"`
+	LoggedInViews  int64  `json:"logged_in_views"`
+	AnonymousViews int64  `json:"anonymous_views"`
+	UniqueReaders  *int64 `json:"unique_readers"`
+}
+
+// AnalyticsReport - Kết quả analytics cho một khoảng ngày
+type AnalyticsReport struct {
+	From         string                       `json:"from"`
+	To           string                       `json:"to"`
+	Totals       AnalyticsTotals              `json:"totals"`
+	Daily        []repositories.DailyViews    `json:"daily"`
+	TopReferrers []repositories.ReferrerStat  `json:"top_referrers,omitempty"`
+	TopStories   []repositories.TopViewedItem `json:"top_stories,omitempty"`
+	TopChapters  []repositories.TopViewedItem `json:"top_chapters,omitempty"`
+}
+
+// FunnelChapter - Một điểm trên đường retention của truyện
+type FunnelChapter struct {
+	repositories.FunnelStep
+	Retention   float64 `json:"retention"`     // readers / readers của chapter đầu
+	DropOff     int64   `json:"drop_off"`      // Số người dừng ở chapter trước
+	DropOffRate float64 `json:"drop_off_rate"` // drop_off / readers của chapter trước
+	Flagged     bool    `json:"flagged"`       // Thuộc nhóm bỏ đọc lớn nhất
+}
+
+// StoryFunnel - Funnel bỏ đọc theo chapter của truyện
+type StoryFunnel struct {
+	StoryID         uuid.UUID       `json:"story_id"`
+	ComputedAt      *time.Time      `json:"computed_at"`
+	Readers         int64           `json:"readers"`
+	Chapters        []FunnelChapter `json:"chapters"`
+	LargestDropOffs []int           `json:"largest_drop_offs"` // chapter_number được đánh dấu, giảm dần theo drop_off
+}
+
+type AnalyticsService interface {
+	ResolveRange(from, to *time.Time) (time.Time, time.Time, error)
+	GetOverview(from, to time.Time) (*AnalyticsReport, error)
+	GetStoryAnalytics(storyID uuid.UUID, from, to time.Time) (*AnalyticsReport, error)
+	GetChapterAnalytics(chapterID uuid.UUID, from, to time.Time) (*AnalyticsReport, error)
+	GetTopReferrers(storyID *uuid.UUID, from, to time.Time, limit int) ([]repositories.ReferrerStat, error)
+	GetTopStories(from, to time.Time, limit int) ([]repositories.TopViewedItem, error)
+	GetStoryFunnel(storyID uuid.UUID) (*StoryFunnel, error)
+
+	// Scheduler methods
+	RollupViews(fullBackfill bool) error
+	RefreshChapterFunnels() error
+}
+
+type analyticsService struct {
+	analyticsRepo repositories.AnalyticsRepository
+	storyRepo     repositories.StoryRepository
+	chapterRepo   repositories.ChapterRepository
+}
+
+func NewAnalyticsService(
+	analyticsRepo repositories.AnalyticsRepository,
+	storyRepo repositories.StoryRepository,
+	chapterRepo repositories.ChapterRepository,
+) AnalyticsService {
+	return &analyticsService{
+		analyticsRepo: analyticsRepo,
+		storyRepo:     storyRepo,
+		chapterRepo:   chapterRepo,
+	}
+}
+
+// ResolveRange - Chuẩn hóa khoảng ngày (mặc định 30 ngày gần nhất, tối đa 366 ngày)
+func (s *analyticsService) ResolveRange(from, to *time.Time) (time.Time, time.Time, error) {
+	end := truncateToDate(time.Now())
+	if to != nil {
+		end = truncateToDate(*to)
+	}
+	start := end.Add(-analyticsDefaultRange)
+	if from != nil {
+		start = truncateToDate(*from)
+	}
+
+	if start.After(end) {
+		return start, end, errors.New("from phải trước hoặc bằng to")
+	}
+	if end.Sub(start) > analyticsMaxDays*24*time.Hour {
+		return start, end, errors.New("khoảng thời gian tối đa là 366 ngày")
+	}
+	return start, end, nil
+}
+
+// GetOverview - Lượt xem toàn site, top truyện và top nguồn truy cập
+func (s *analyticsService) GetOverview(from, to time.Time) (*AnalyticsReport, error) {
+	daily, err := s.analyticsRepo.GetSiteDailyViews(from, to)
+	if err != nil {
+		return nil, err
+	}
+	report := newAnalyticsReport(from, to, daily)
+
+	if report.Totals.UniqueReaders, err = s.uniqueReaders(nil, nil, from, to); err != nil {
+		return nil, err
+	}
+	if report.TopStories, err = s.analyticsRepo.GetTopStories(from, to, analyticsTopLimit); err != nil {
+		return nil, err
+	}
+	if report.TopReferrers, err = s.analyticsRepo.GetTopReferrers(nil, from, to, analyticsTopLimit); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// GetStoryAnalytics - Lượt xem trang truyện, top chapter và nguồn truy cập của truyện
+func (s *analyticsService) GetStoryAnalytics(storyID uuid.UUID, from, to time.Time) (*AnalyticsReport, error) {
+	if _, err := s.storyRepo.FindStoryByID(storyID); err != nil {
+		return nil, errors.New("truyện không tồn tại")
+	}
+
+	daily, err := s.analyticsRepo.GetStoryDailyViews(storyID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	report := newAnalyticsReport(from, to, daily)
+
+	if report.Totals.UniqueReaders, err = s.uniqueReaders(&storyID, nil, from, to); err != nil {
+		return nil, err
+	}
+	if report.TopChapters, err = s.analyticsRepo.GetTopChapters(storyID, from, to, analyticsTopLimit); err != nil {
+		return nil, err
+	}
+	if report.TopReferrers, err = s.analyticsRepo.GetTopReferrers(&storyID, from, to, analyticsTopLimit); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// GetChapterAnalytics - Lượt đọc chapter theo ngày
+func (s *analyticsService) GetChapterAnalytics(chapterID uuid.UUID, from, to time.Time) (*AnalyticsReport, error) {
+	if _, err := s.chapterRepo.FindByID(chapterID); err != nil {
+		return nil, errors.New("chapter không tồn tại")
+	}
+
+	daily, err := s.analyticsRepo.GetChapterDailyViews(chapterID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	report := newAnalyticsReport(from, to, daily)
+
+	if report.Totals.UniqueReaders, err = s.uniqueReaders(nil, &chapterID, from, to); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// GetTopReferrers - Nguồn truy cập nhiều nhất (toàn site hoặc một truyện)
+func (s *analyticsService) GetTopReferrers(storyID *uuid.UUID, from, to time.Time, limit int) ([]repositories.ReferrerStat, error) {
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	return s.analyticsRepo.GetTopReferrers(storyID, from, to, limit)
+}
+
+// GetTopStories - Truyện nhiều lượt xem nhất trong khoảng
+func (s *analyticsService) GetTopStories(from, to time.Time, limit int) ([]repositories.TopViewedItem, error) {
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	return s.analyticsRepo.GetTopStories(from, to, limit)
+}
+
+// RollupViews - Tổng hợp raw views vào bảng daily rồi xóa raw quá hạn
+// fullBackfill = true (lần chạy đầu) tổng hợp lại toàn bộ raw còn giữ, sau đó chỉ cần hôm qua và hôm nay
+func (s *analyticsService) RollupViews(fullBackfill bool) error {
+	today := truncateToDate(time.Now())
+	cutoff := today.Add(-rawViewRetention)
+
+	since := today.AddDate(0, 0, -1)
+	if fullBackfill {
+		since = cutoff
+	}
+	if err := s.analyticsRepo.RollupViews(since); err != nil {
+		return err
+	}
+
+	_, err := s.analyticsRepo.PruneRawViews(cutoff)
+	return err
+}
+
+// GetStoryFunnel - Đường retention theo chapter (đọc từ bảng đã tính sẵn) và các điểm bỏ đọc lớn nhất
+func (s *analyticsService) GetStoryFunnel(storyID uuid.UUID) (*StoryFunnel, error) {
+	if _, err := s.storyRepo.FindStoryByID(storyID); err != nil {
+		return nil, errors.New("truyện không tồn tại")
+	}
+
+	steps, err := s.analyticsRepo.GetChapterFunnel(storyID)
+	if err != nil {
+		return nil, err
+	}
+
+	funnel := &StoryFunnel{
+		StoryID:         storyID,
+		Chapters:        make([]FunnelChapter, len(steps)),
+		LargestDropOffs: []int{},
+	}
+	if len(steps) == 0 {
+		return funnel, nil
+	}
+	funnel.ComputedAt = &steps[0].ComputedAt
+	funnel.Readers = steps[0].Readers
+
+	for i, step := range steps {
+		chapter := FunnelChapter{FunnelStep: step}
+		if funnel.Readers > 0 {
+			chapter.Retention = float64(step.Readers) / float64(funnel.Readers)
+		}
+		if i > 0 {
+			prev := steps[i-1].Readers
+			chapter.DropOff = prev - step.Readers
+			if prev > 0 {
+				chapter.DropOffRate = float64(chapter.DropOff) / float64(prev)
+			}
+		}
+		funnel.Chapters[i] = chapter
+	}
+
+	// Đánh dấu các chapter mất nhiều người đọc nhất so với chapter trước
+	order := make([]int, 0, len(steps))
+	for i := range funnel.Chapters {
+		if funnel.Chapters[i].DropOff > 0 {
+			order = append(order, i)
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return funnel.Chapters[order[a]].DropOff > funnel.Chapters[order[b]].DropOff
+	})
+	if len(order) > funnelFlaggedDropOffs {
+		order = order[:funnelFlaggedDropOffs]
+	}
+	for _, i := range order {
+		funnel.Chapters[i].Flagged = true
+		funnel.LargestDropOffs = append(funnel.LargestDropOffs, funnel.Chapters[i].ChapterNumber)
+	}
+
+	return funnel, nil
+}
+
+// RefreshChapterFunnels - Tính lại funnel bỏ đọc cho tất cả truyện
+func (s *analyticsService) RefreshChapterFunnels() error {
+	return s.analyticsRepo.RefreshChapterFunnels()
+}
+
+// uniqueReaders - Đếm người đọc duy nhất từ raw, nil nếu khoảng vượt quá thời gian giữ raw
+func (s *analyticsService) uniqueReaders(storyID, chapterID *uuid.UUID, from, to time.Time) (*int64, error) {
+	if from.Before(truncateToDate(time.Now()).Add(-rawViewRetention)) {
+		return nil, nil
+	}
+	count, err := s.analyticsRepo.CountUniqueReaders(storyID, chapterID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return &count, nil
+}
+
+// newAnalyticsReport - Tạo report và cộng tổng từ chuỗi theo ngày
+func newAnalyticsReport(from, to time.Time, daily []repositories.DailyViews) *AnalyticsReport {
+	report := &AnalyticsReport{
+		From:  from.Format("2006-01-02"),
+		To:    to.Format("2006-01-02"),
+		Daily: daily,
+	}
+	if report.Daily == nil {
+		report.Daily = []repositories.DailyViews{}
+	}
+	for _, d := range daily {
+		report.Totals.Views += d.Views
+		report.Totals.LoggedInViews += d.LoggedInViews
+		report.Totals.AnonymousViews += d.AnonymousViews
+	}
+	return report
+}
@@ -23,11 +23,13 @@ type AuthService interface {
 	UpdateProfile(userID uuid.UUID, username, avatarURL *string) (*models.User, error)
 	ChangePassword(userID uuid.UUID, oldPassword, newPassword string) error
 	GetActiveSessions(userID uuid.UUID) ([]models.RefreshToken, error)
+	GetPermissions(role string) []string
 }
 
 type authService struct {
 	userRepo         repositories.UserRepository
 	refreshTokenRepo repositories.RefreshTokenRepository
+	roleRepo         repositories.RoleRepository
 	cfg              *config.Config
 }
 
@@ -36,11 +38,13 @@ type authService struct {
 func NewAuthService(
 	userRepo repositories.UserRepository,
 	refreshTokenRepo repositories.RefreshTokenRepository,
+	roleRepo repositories.RoleRepository,
 	cfg *config.Config,
 ) AuthService {
 	return &authService{
 		userRepo:         userRepo,
 		refreshTokenRepo: refreshTokenRepo,
+		roleRepo:         roleRepo,
 		cfg:              cfg,
 	}
 }
@@ -89,7 +93,7 @@ func (s *authService) Register(email, username, password string) (*models.User,
 		Email:        email,
 		Username:     username,
 		PasswordHash: hashedPassword,
-		Role:         "reader",
+		Role:         models.RoleReader,
 		IsActive:     true,
 	}
 
@@ -277,6 +281,15 @@ func (s *authService) GetActiveSessions(userID uuid.UUID) ([]models.RefreshToken
 	return s.refreshTokenRepo.GetActiveByUser(userID)
 }
 
+// GetPermissions - Quyền của role, role không tồn tại thì không có quyền nào
+func (s *authService) GetPermissions(role string) []string {
+	r, err := s.roleRepo.FindRoleByName(role)
+	if err != nil {
+		return []string{}
+	}
+	return r.GetPermissions()
+}
+
 // Helper: Generate tokens và lưu refresh token vào DB
 func (s *authService) generateAndStoreTokens(user *models.User, userAgent, ipAddress string) (*utils.TokenPair, error) {
 	// Generate access token
@@ -284,6 +297,7 @@ func (s *authService) generateAndStoreTokens(user *models.User, userAgent, ipAdd
 		user.ID,
 		user.Username,
 		user.Role,
+		s.GetPermissions(user.Role), // Đổi quyền của role có hiệu lực từ lần refresh kế tiếp
 		s.cfg.Jwt.AccessSecret,
 		s.cfg.Jwt.AccessExpireSeconds, // In seconds
 	)
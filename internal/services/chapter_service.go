@@ -1,49 +1,121 @@
 package services
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 
 	"nekozanedex/internal/models"
 	"nekozanedex/internal/repositories"
+	"nekozanedex/internal/utils"
+	"nekozanedex/pkg/richtext"
 
 	"github.com/google/uuid"
 )
 
+// ChapterRevisionDiff - Diff theo dòng giữa hai revision của chapter
+type ChapterRevisionDiff struct {
+	ChapterID    uuid.UUID        `json:"chapter_id"`
+	From         int              `json:"from"`
+	To           int              `json:"to"`
+	TitleFrom    string           `json:"title_from"`
+	TitleTo      string           `json:"title_to"`
+	Content      []utils.DiffLine `json:"content"`
+	ContentStats utils.DiffStats  `json:"content_stats"`
+	Images       []utils.DiffLine `json:"images"` // Mỗi dòng là một URL ảnh
+	ImageStats   utils.DiffStats  `json:"image_stats"`
+}
+
+// VolumeChapters - Chapter của một tập; Volume nil là nhóm chapter chưa phân tập
+type VolumeChapters struct {
+	Volume   *models.Volume   `json:"volume"`
+	Chapters []models.Chapter `json:"chapters"`
+}
+
+// ChapterNavItem - Thông tin tối thiểu để vẽ nút chapter trước/sau
+type ChapterNavItem struct {
+	ID            uuid.UUID `json:"id"`
+	ChapterNumber int       `json:"chapter_number"`
+	ChapterLabel  *string   `json:"chapter_label"`
+	ChapterType   string    `json:"chapter_type"`
+	Title         string    `json:"title"`
+}
+
+// ReaderProgress - Tiến độ đọc của user đăng nhập trong truyện
+type ReaderProgress struct {
+	LastReadChapterID *uuid.UUID `json:"last_read_chapter_id"`
+	ScrollPosition    int        `json:"scroll_position"` // Vị trí đã lưu của chapter đang xem, 0 nếu chưa có
+	NextUnread        bool       `json:"next_unread"`
+}
+
+// ChapterNavigation - Chapter hiện tại kèm chapter trước/sau theo Ordering (chỉ chapter đã phát hành)
+type ChapterNavigation struct {
+	Chapter  ChapterNavItem  `json:"chapter"`
+	Prev     *ChapterNavItem `json:"prev"`
+	Next     *ChapterNavItem `json:"next"`
+	Progress *ReaderProgress `json:"progress,omitempty"` // nil khi chưa đăng nhập
+}
+
 type ChapterService interface {
 	// Public methods
-	GetChapterByNumber(storySlug string, chapterNumber int) (*models.Chapter, error)
-	GetChaptersByStory(storySlug string) ([]models.Chapter, error)
-	GetChaptersByStoryPaginated(storySlug string, page, limit int) ([]models.Chapter, int64, error)
+	// showMature: người xem đã bật hiển thị truyện mature/adult, nếu không trả ContentWarningError
+	GetChapterByNumber(storySlug string, chapterNumber int, showMature bool) (*models.Chapter, error)
+	GetChapterNavigation(storySlug string, chapterNumber int, userID *uuid.UUID, showMature bool) (*ChapterNavigation, error)
+	GetChaptersByStory(storySlug string, showMature bool) ([]models.Chapter, error)
+	GetChaptersByVolume(storySlug string, showMature bool) ([]VolumeChapters, error) // Nhóm theo tập, chưa phân tập ở cuối
+	GetChaptersByStoryPaginated(storySlug string, page, limit int, showMature bool) ([]models.Chapter, int64, error)
+	GetChaptersByStoryCursor(storySlug, cursor string, limit int, showMature bool) ([]models.Chapter, string, error)
+	RecordChapterView(chapter *models.Chapter, userID *uuid.UUID, ipAddress string, referrer *string) error
 
 	// Admin methods
 	CreateChapter(storyID uuid.UUID, chapter *models.Chapter) error
-	UpdateChapter(id uuid.UUID, chapter *models.Chapter) error
+	UpdateChapter(id uuid.UUID, chapter *models.Chapter, editorID *uuid.UUID) error // Lưu revision sau mỗi lần sửa
 	DeleteChapter(id uuid.UUID) error
 	GetChapterByID(id uuid.UUID) (*models.Chapter, error)
 	GetChaptersByStoryAdmin(storyID uuid.UUID) ([]models.Chapter, error) // All chapters including drafts
 	PublishChapter(id uuid.UUID) error
 	ScheduleChapter(id uuid.UUID, scheduledAt time.Time) error
 	BulkImportChapters(storyID uuid.UUID, chapters []models.Chapter) error
+	InsertChapterAfter(storyID, afterID uuid.UUID, chapter *models.Chapter) error // Chèn extra giữa hai chapter
+	MoveChapter(id uuid.UUID, beforeID, afterID *uuid.UUID) (*models.Chapter, error)
+
+	// Revision methods
+	GetRevisions(chapterID uuid.UUID) ([]models.ChapterRevision, error)
+	GetRevision(chapterID uuid.UUID, number int) (*models.ChapterRevision, error)
+	DiffRevisions(chapterID uuid.UUID, from, to int) (*ChapterRevisionDiff, error)
+	RestoreRevision(chapterID uuid.UUID, number int, editorID *uuid.UUID) (*models.ChapterRevision, error)
 	
 	// Scheduler methods
 	PublishScheduledChapters() (int, error) // Returns count of published chapters
 }
 
 type chapterService struct {
-	chapterRepo repositories.ChapterRepository
-	storyRepo   repositories.StoryRepository
+	chapterRepo   repositories.ChapterRepository
+	storyRepo     repositories.StoryRepository
+	storyViewRepo repositories.StoryViewRepository
+	revisionRepo  repositories.ChapterRevisionRepository
+	volumeRepo    repositories.VolumeRepository
+	historyRepo   repositories.ReadingHistoryRepository
 }
 
 func NewChapterService(
 	chapterRepo repositories.ChapterRepository,
 	storyRepo repositories.StoryRepository,
+	storyViewRepo repositories.StoryViewRepository,
+	revisionRepo repositories.ChapterRevisionRepository,
+	volumeRepo repositories.VolumeRepository,
+	historyRepo repositories.ReadingHistoryRepository,
 ) ChapterService {
 	return &chapterService{
-		chapterRepo: chapterRepo,
-		storyRepo:   storyRepo,
+		chapterRepo:   chapterRepo,
+		storyRepo:     storyRepo,
+		storyViewRepo: storyViewRepo,
+		revisionRepo:  revisionRepo,
+		volumeRepo:    volumeRepo,
+		historyRepo:   historyRepo,
 	}
 }
 
@@ -53,6 +125,9 @@ func (s *chapterService) CreateChapter(storyID uuid.UUID, chapter *models.Chapte
 	if strings.TrimSpace(chapter.Title) == "" {
 		return errors.New("tiêu đề chapter không được để trống")
 	}
+	if err := prepareChapterContent(chapter); err != nil {
+		return err
+	}
 
 	// Check story exists
 	story, err := s.storyRepo.FindStoryByID(storyID)
@@ -61,8 +136,21 @@ func (s *chapterService) CreateChapter(storyID uuid.UUID, chapter *models.Chapte
 	}
 
 	// Set chapter info
+	// Số chapter không bao giờ dùng lại (kể cả sau khi xóa), được gán khi tạo; vị trí đọc do Ordering quyết định
 	chapter.StoryID = storyID
-	chapter.ChapterNumber = story.TotalChapters + 1
+	if chapter.Ordering <= 0 {
+		maxOrdering, err := s.chapterRepo.MaxOrdering(storyID)
+		if err != nil {
+			return err
+		}
+		chapter.Ordering = maxOrdering + 1
+	}
+	if chapter.GroupID == nil {
+		chapter.GroupID = story.GroupID // Ghi công cho nhóm phụ trách truyện
+	}
+	if err := s.checkVolume(storyID, chapter.VolumeID); err != nil {
+		return err
+	}
 	
 	// Calculate page count from images
 	chapter.PageCount = countImages(chapter.Images)
@@ -75,36 +163,235 @@ func (s *chapterService) CreateChapter(storyID uuid.UUID, chapter *models.Chapte
 		return err
 	}
 
-	// Update story total chapters
-	story.TotalChapters++
+	return s.syncTotalChapters(story)
+}
+
+// InsertChapterAfter - Tạo chapter nằm ngay sau afterID (vd. extra giữa chapter 3 và 4)
+func (s *chapterService) InsertChapterAfter(storyID, afterID uuid.UUID, chapter *models.Chapter) error {
+	after, err := s.chapterRepo.FindByID(afterID)
+	if err != nil || after.StoryID != storyID {
+		return errors.New("chapter đứng trước không tồn tại trong truyện này")
+	}
+
+	chapter.Ordering, err = s.orderingAround(after, uuid.Nil, true)
+	if err != nil {
+		return err
+	}
+	return s.CreateChapter(storyID, chapter)
+}
+
+// MoveChapter - Di chuyển chapter tới trước beforeID hoặc sau afterID (chỉ đổi Ordering, giữ số chapter)
+func (s *chapterService) MoveChapter(id uuid.UUID, beforeID, afterID *uuid.UUID) (*models.Chapter, error) {
+	if (beforeID == nil) == (afterID == nil) {
+		return nil, errors.New("cần chỉ định đúng một trong before_id hoặc after_id")
+	}
+
+	chapter, err := s.chapterRepo.FindByID(id)
+	if err != nil {
+		return nil, errors.New("chapter không tồn tại")
+	}
+
+	anchorID, after := beforeID, false
+	if afterID != nil {
+		anchorID, after = afterID, true
+	}
+	if *anchorID == id {
+		return nil, errors.New("không thể di chuyển chapter so với chính nó")
+	}
+	anchor, err := s.chapterRepo.FindByID(*anchorID)
+	if err != nil || anchor.StoryID != chapter.StoryID {
+		return nil, errors.New("chapter mốc không tồn tại trong truyện này")
+	}
+
+	ordering, err := s.orderingAround(anchor, chapter.ID, after)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.chapterRepo.UpdateOrdering(chapter.ID, ordering); err != nil {
+		return nil, err
+	}
+	chapter.Ordering = ordering
+	return chapter, nil
+}
+
+// minOrderingGap - Khoảng cách nhỏ nhất giữa hai ordering trước khi phải đánh lại 1, 2, 3...
+const minOrderingGap = 1e-6
+
+// orderingAround - Ordering nằm giữa anchor và chapter liền sau (after) hoặc liền trước nó
+// excludeID: chapter đang được di chuyển, bỏ qua khi tìm chapter liền kề
+func (s *chapterService) orderingAround(anchor *models.Chapter, excludeID uuid.UUID, after bool) (float64, error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		neighbor, err := s.chapterRepo.NeighborOrdering(anchor.StoryID, anchor.Ordering, excludeID, after)
+		if err != nil {
+			return 0, err
+		}
+
+		if neighbor == nil {
+			if after {
+				return anchor.Ordering + 1, nil
+			}
+			// Trước chapter đầu tiên: chèn giữa 0 và anchor, ordering luôn > 0
+			neighbor = new(float64)
+		}
+		if gap := *neighbor - anchor.Ordering; gap > minOrderingGap || gap < -minOrderingGap {
+			return (anchor.Ordering + *neighbor) / 2, nil
+		}
+
+		// Hết chỗ chèn: đánh lại ordering của truyện rồi tính lại
+		if err := s.chapterRepo.NormalizeOrdering(anchor.StoryID); err != nil {
+			return 0, err
+		}
+		refreshed, err := s.chapterRepo.FindByID(anchor.ID)
+		if err != nil {
+			return 0, err
+		}
+		anchor = refreshed
+	}
+	return 0, errors.New("không thể tính vị trí chapter")
+}
+
+// syncTotalChapters - Đếm lại số chapter thay vì tăng/giảm thủ công
+func (s *chapterService) syncTotalChapters(story *models.Story) error {
+	total, err := s.chapterRepo.CountByStory(story.ID)
+	if err != nil {
+		return err
+	}
+	story.TotalChapters = total
 	story.UpdatedAt = time.Now()
 	return s.storyRepo.UpdateStory(story)
 }
 
-// UpdateChapter - Cập nhật chapter (Admin)
-func (s *chapterService) UpdateChapter(id uuid.UUID, updatedChapter *models.Chapter) error {
-	existingChapter, err := s.chapterRepo.FindByID(id)
+// UpdateChapter - Cập nhật chapter (Admin), lưu revision nếu tiêu đề/nội dung/ảnh thay đổi
+func (s *chapterService) UpdateChapter(id uuid.UUID, updatedChapter *models.Chapter, editorID *uuid.UUID) error {
+	current, err := s.chapterRepo.FindByID(id)
 	if err != nil {
 		return errors.New("chapter không tồn tại")
 	}
+	if updatedChapter.VolumeID != nil {
+		if err := s.checkVolume(current.StoryID, updatedChapter.VolumeID); err != nil {
+			return err
+		}
+	}
+
+	// Thay đổi được áp lên bản đọc lại dưới khóa dòng, before là trạng thái ngay trước lần sửa này
+	return s.revisionRepo.SaveChapterWithRevision(id, func(existingChapter *models.Chapter) (*models.ChapterRevision, error) {
+		before := models.NewChapterRevision(existingChapter, nil)
+
+		if updatedChapter.Title != "" {
+			existingChapter.Title = updatedChapter.Title
+		}
+		// Update new fields
+		existingChapter.ChapterLabel = updatedChapter.ChapterLabel
+		existingChapter.ChapterType = updatedChapter.ChapterType
+		if updatedChapter.Ordering > 0 {
+			existingChapter.Ordering = updatedChapter.Ordering // Không gửi ordering thì giữ vị trí cũ
+		}
+		if updatedChapter.VolumeID != nil {
+			existingChapter.VolumeID = updatedChapter.VolumeID // Bỏ khỏi tập qua PUT /admin/volumes/:id/chapters
+		}
+
+		// Always update content (can be empty for manga chapters)
+		// Đổi nội dung phải gửi kèm định dạng, tránh văn bản thuần bị lọc như HTML theo định dạng cũ
+		if updatedChapter.Content != existingChapter.Content && updatedChapter.ContentFormat == "" {
+			return nil, errors.New("content_format là bắt buộc khi sửa nội dung (plain, markdown, html)")
+		}
+		existingChapter.Content = updatedChapter.Content
+		if updatedChapter.ContentFormat != "" {
+			existingChapter.ContentFormat = updatedChapter.ContentFormat
+		}
+		if updatedChapter.Notes != nil {
+			existingChapter.Notes = updatedChapter.Notes
+		}
+		if err := prepareChapterContent(existingChapter); err != nil {
+			return nil, err
+		}
+		if updatedChapter.Images != nil {
+			existingChapter.Images = updatedChapter.Images
+			existingChapter.PageCount = countImages(updatedChapter.Images)
+		}
+		existingChapter.UpdatedAt = time.Now()
+
+		// Chỉ đổi nhãn/thứ tự: không cần revision mới
+		if before.Title != existingChapter.Title || before.Content != existingChapter.Content ||
+			before.ContentFormat != existingChapter.ContentFormat || !bytes.Equal(before.Notes, existingChapter.Notes) ||
+			!bytes.Equal(before.Images, existingChapter.Images) {
+			return models.NewChapterRevision(existingChapter, editorID), nil
+		}
+		return nil, nil
+	})
+}
+
+// GetRevisions - Lịch sử revision của chapter, mới nhất trước (Admin)
+func (s *chapterService) GetRevisions(chapterID uuid.UUID) ([]models.ChapterRevision, error) {
+	if _, err := s.chapterRepo.FindByID(chapterID); err != nil {
+		return nil, errors.New("chapter không tồn tại")
+	}
+	return s.revisionRepo.GetRevisions(chapterID)
+}
 
-	if updatedChapter.Title != "" {
-		existingChapter.Title = updatedChapter.Title
+// GetRevision - Nội dung đầy đủ của một revision (Admin)
+func (s *chapterService) GetRevision(chapterID uuid.UUID, number int) (*models.ChapterRevision, error) {
+	revision, err := s.revisionRepo.FindRevision(chapterID, number)
+	if err != nil {
+		return nil, errors.New("revision không tồn tại")
 	}
-	// Update new fields
-	existingChapter.ChapterLabel = updatedChapter.ChapterLabel
-	existingChapter.ChapterType = updatedChapter.ChapterType
-	existingChapter.Ordering = updatedChapter.Ordering
-	
-	// Always update content (can be empty for manga chapters)
-	existingChapter.Content = updatedChapter.Content
-	if updatedChapter.Images != nil {
-		existingChapter.Images = updatedChapter.Images
-		existingChapter.PageCount = countImages(updatedChapter.Images)
+	return revision, nil
+}
+
+// DiffRevisions - So sánh nội dung + danh sách ảnh của hai revision theo dòng (Admin)
+func (s *chapterService) DiffRevisions(chapterID uuid.UUID, from, to int) (*ChapterRevisionDiff, error) {
+	oldRev, err := s.revisionRepo.FindRevision(chapterID, from)
+	if err != nil {
+		return nil, errors.New("revision không tồn tại")
+	}
+	newRev, err := s.revisionRepo.FindRevision(chapterID, to)
+	if err != nil {
+		return nil, errors.New("revision không tồn tại")
+	}
+
+	diff := &ChapterRevisionDiff{
+		ChapterID: chapterID,
+		From:      from,
+		To:        to,
+		TitleFrom: oldRev.Title,
+		TitleTo:   newRev.Title,
+	}
+	diff.Content, diff.ContentStats = utils.DiffLines(utils.SplitLines(oldRev.Content), utils.SplitLines(newRev.Content))
+	diff.Images, diff.ImageStats = utils.DiffLines(oldRev.GetImagesSlice(), newRev.GetImagesSlice())
+	return diff, nil
+}
+
+// RestoreRevision - Khôi phục nội dung revision cũ, lưu thành revision mới (không xóa lịch sử)
+func (s *chapterService) RestoreRevision(chapterID uuid.UUID, number int, editorID *uuid.UUID) (*models.ChapterRevision, error) {
+	if _, err := s.chapterRepo.FindByID(chapterID); err != nil {
+		return nil, errors.New("chapter không tồn tại")
+	}
+	revision, err := s.revisionRepo.FindRevision(chapterID, number)
+	if err != nil {
+		return nil, errors.New("revision không tồn tại")
 	}
-	existingChapter.UpdatedAt = time.Now()
 
-	return s.chapterRepo.Update(existingChapter)
+	var restored *models.ChapterRevision
+	err = s.revisionRepo.SaveChapterWithRevision(chapterID, func(chapter *models.Chapter) (*models.ChapterRevision, error) {
+		chapter.Title = revision.Title
+		chapter.Content = revision.Content
+		chapter.ContentFormat = revision.ContentFormat // Revision cũ chưa có định dạng -> plain
+		chapter.Notes = revision.Notes
+		if err := prepareChapterContent(chapter); err != nil {
+			return nil, err
+		}
+		chapter.Images = revision.Images
+		chapter.PageCount = countImages(revision.Images)
+		chapter.UpdatedAt = time.Now()
+
+		restored = models.NewChapterRevision(chapter, editorID)
+		restored.RestoredFrom = &number
+		return restored, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return restored, nil
 }
 
 // DeleteChapter - Xóa chapter (Admin)
@@ -121,9 +408,7 @@ func (s *chapterService) DeleteChapter(id uuid.UUID) error {
 	// Update story total chapters
 	story, _ := s.storyRepo.FindStoryByID(chapter.StoryID)
 	if story != nil {
-		story.TotalChapters--
-		story.UpdatedAt = time.Now()
-		_ = s.storyRepo.UpdateStory(story)
+		_ = s.syncTotalChapters(story)
 	}
 
 	return nil
@@ -135,43 +420,183 @@ func (s *chapterService) GetChapterByID(id uuid.UUID) (*models.Chapter, error) {
 	if err != nil {
 		return nil, errors.New("chapter không tồn tại")
 	}
+	ensureChapterRendered(chapter) // Xem trước bản render khi soạn
 	return chapter, nil
 }
 
 // GetChapterByNumber - Lấy chapter theo số (Public)
-func (s *chapterService) GetChapterByNumber(storySlug string, chapterNumber int) (*models.Chapter, error) {
-	story, err := s.storyRepo.FindStoryBySlug(storySlug)
+func (s *chapterService) GetChapterByNumber(storySlug string, chapterNumber int, showMature bool) (*models.Chapter, error) {
+	chapter, err := s.findPublishedChapter(storySlug, chapterNumber, showMature)
+	if err != nil {
+		return nil, err
+	}
+
+	// Increment view count
+	_ = s.chapterRepo.IncrementViewCount(chapter.ID)
+
+	ensureChapterRendered(chapter)
+	return chapter, nil
+}
+
+// GetChapterNavigation - Chapter trước/sau theo Ordering và tiến độ đọc của user (Public, không tính view)
+func (s *chapterService) GetChapterNavigation(storySlug string, chapterNumber int, userID *uuid.UUID, showMature bool) (*ChapterNavigation, error) {
+	chapter, err := s.findPublishedChapter(storySlug, chapterNumber, showMature)
+	if err != nil {
+		return nil, err
+	}
+
+	prev, err := s.chapterRepo.FindAdjacentPublished(chapter, false)
+	if err != nil {
+		return nil, err
+	}
+	next, err := s.chapterRepo.FindAdjacentPublished(chapter, true)
+	if err != nil {
+		return nil, err
+	}
+
+	nav := &ChapterNavigation{
+		Chapter: toNavItem(*chapter),
+		Prev:    toNavItemPtr(prev),
+		Next:    toNavItemPtr(next),
+	}
+	if userID == nil {
+		return nav, nil
+	}
+
+	// Lịch sử đọc lưu chapter đọc gần nhất của truyện: next chưa đọc nếu nằm sau chapter đó
+	nav.Progress = &ReaderProgress{NextUnread: next != nil}
+	history, err := s.historyRepo.GetByUserAndStory(*userID, chapter.StoryID)
+	if err != nil {
+		return nav, nil // Chưa đọc truyện này
+	}
+	lastReadID := history.ChapterID
+	nav.Progress.LastReadChapterID = &lastReadID
+	if history.ChapterID == chapter.ID {
+		nav.Progress.ScrollPosition = history.ScrollPosition
+	}
+	if next != nil && history.Chapter.ID != uuid.Nil {
+		nav.Progress.NextUnread = next.ID != history.ChapterID && (next.Ordering > history.Chapter.Ordering ||
+			(next.Ordering == history.Chapter.Ordering && next.ChapterNumber > history.Chapter.ChapterNumber))
+	}
+	return nav, nil
+}
+
+// findPublishedChapter - Chapter đã publish theo số, kiểm tra quyền xem nội dung mature
+func (s *chapterService) findPublishedChapter(storySlug string, chapterNumber int, showMature bool) (*models.Chapter, error) {
+	story, err := s.storyRepo.FindStoryRefBySlug(storySlug)
 	if err != nil {
 		return nil, errors.New("truyện không tồn tại")
 	}
+	if err := checkContentAccess(story, showMature); err != nil {
+		return nil, err
+	}
 
 	chapter, err := s.chapterRepo.FindByStoryAndNumber(story.ID, chapterNumber)
 	if err != nil {
 		return nil, errors.New("chapter không tồn tại")
 	}
+	return chapter, nil
+}
+
+func toNavItem(chapter models.Chapter) ChapterNavItem {
+	return ChapterNavItem{
+		ID:            chapter.ID,
+		ChapterNumber: chapter.ChapterNumber,
+		ChapterLabel:  chapter.ChapterLabel,
+		ChapterType:   chapter.ChapterType,
+		Title:         chapter.Title,
+	}
+}
 
-	// Increment view count
-	_ = s.chapterRepo.IncrementViewCount(chapter.ID)
+func toNavItemPtr(chapter *models.Chapter) *ChapterNavItem {
+	if chapter == nil {
+		return nil
+	}
+	item := toNavItem(*chapter)
+	return &item
+}
 
-	return chapter, nil
+// RecordChapterView - Ghi lượt đọc chapter cho analytics (1 lượt / user hoặc IP / 24h)
+func (s *chapterService) RecordChapterView(chapter *models.Chapter, userID *uuid.UUID, ipAddress string, referrer *string) error {
+	hasViewed, err := s.storyViewRepo.HasViewedChapterRecently(chapter.ID, userID, ipAddress, 24*time.Hour)
+	if err != nil || hasViewed {
+		return err
+	}
+
+	chapterID := chapter.ID
+	return s.storyViewRepo.RecordView(&models.StoryView{
+		StoryID:   chapter.StoryID,
+		ChapterID: &chapterID,
+		UserID:    userID,
+		IPAddress: ipAddress,
+		Referrer:  referrer,
+	})
 }
 
 // GetChaptersByStory - Lấy danh sách chapters của truyện (Public - only published)
-func (s *chapterService) GetChaptersByStory(storySlug string) ([]models.Chapter, error) {
-	story, err := s.storyRepo.FindStoryBySlug(storySlug)
+func (s *chapterService) GetChaptersByStory(storySlug string, showMature bool) ([]models.Chapter, error) {
+	story, err := s.storyRepo.FindStoryRefBySlug(storySlug)
 	if err != nil {
 		return nil, errors.New("truyện không tồn tại")
 	}
+	if err := checkContentAccess(story, showMature); err != nil {
+		return nil, err
+	}
 
 	return s.chapterRepo.GetByStory(story.ID, true)
 }
 
+// GetChaptersByVolume - Chapters đã publish nhóm theo tập (Public)
+func (s *chapterService) GetChaptersByVolume(storySlug string, showMature bool) ([]VolumeChapters, error) {
+	story, err := s.storyRepo.FindStoryRefBySlug(storySlug)
+	if err != nil {
+		return nil, errors.New("truyện không tồn tại")
+	}
+	if err := checkContentAccess(story, showMature); err != nil {
+		return nil, err
+	}
+
+	volumes, err := s.volumeRepo.GetVolumesByStory(story.ID)
+	if err != nil {
+		return nil, err
+	}
+	chapters, err := s.chapterRepo.GetByStory(story.ID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]VolumeChapters, len(volumes))
+	index := make(map[uuid.UUID]int, len(volumes))
+	for i := range volumes {
+		groups[i] = VolumeChapters{Volume: &volumes[i], Chapters: []models.Chapter{}}
+		index[volumes[i].ID] = i
+	}
+	var ungrouped []models.Chapter
+	for _, chapter := range chapters {
+		if chapter.VolumeID != nil {
+			if i, ok := index[*chapter.VolumeID]; ok {
+				groups[i].Chapters = append(groups[i].Chapters, chapter)
+				continue
+			}
+		}
+		ungrouped = append(ungrouped, chapter)
+	}
+	if len(ungrouped) > 0 {
+		groups = append(groups, VolumeChapters{Chapters: ungrouped})
+	}
+
+	return groups, nil
+}
+
 // GetChaptersByStoryPaginated - Lấy chapters với phân trang (Public)
-func (s *chapterService) GetChaptersByStoryPaginated(storySlug string, page, limit int) ([]models.Chapter, int64, error) {
-	story, err := s.storyRepo.FindStoryBySlug(storySlug)
+func (s *chapterService) GetChaptersByStoryPaginated(storySlug string, page, limit int, showMature bool) ([]models.Chapter, int64, error) {
+	story, err := s.storyRepo.FindStoryRefBySlug(storySlug)
 	if err != nil {
 		return nil, 0, errors.New("truyện không tồn tại")
 	}
+	if err := checkContentAccess(story, showMature); err != nil {
+		return nil, 0, err
+	}
 
 	if page < 1 {
 		page = 1
@@ -184,6 +609,23 @@ func (s *chapterService) GetChaptersByStoryPaginated(storySlug string, page, lim
 	return s.chapterRepo.GetByStoryPaginated(story.ID, true, offset, limit)
 }
 
+// GetChaptersByStoryCursor - Lấy chapters theo cursor (Public)
+func (s *chapterService) GetChaptersByStoryCursor(storySlug, cursor string, limit int, showMature bool) ([]models.Chapter, string, error) {
+	story, err := s.storyRepo.FindStoryRefBySlug(storySlug)
+	if err != nil {
+		return nil, "", errors.New("truyện không tồn tại")
+	}
+	if err := checkContentAccess(story, showMature); err != nil {
+		return nil, "", err
+	}
+
+	if limit < 1 || limit > 100 {
+		limit = 100
+	}
+
+	return s.chapterRepo.GetByStoryCursor(story.ID, true, cursor, limit)
+}
+
 // GetChaptersByStoryAdmin - Lấy tất cả chapters (Admin - including drafts)
 func (s *chapterService) GetChaptersByStoryAdmin(storyID uuid.UUID) ([]models.Chapter, error) {
 	_, err := s.storyRepo.FindStoryByID(storyID)
@@ -233,24 +675,152 @@ func (s *chapterService) BulkImportChapters(storyID uuid.UUID, chapters []models
 		return errors.New("truyện không tồn tại")
 	}
 
-	startNumber := story.TotalChapters + 1
+	maxOrdering, err := s.chapterRepo.MaxOrdering(storyID)
+	if err != nil {
+		return err
+	}
+
+	for i := range chapters {
+		if err := s.checkVolume(storyID, chapters[i].VolumeID); err != nil {
+			return err
+		}
+		if err := prepareChapterContent(&chapters[i]); err != nil {
+			return fmt.Errorf("chapter %d: %w", i+1, err)
+		}
+	}
 
 	for i := range chapters {
 		chapters[i].StoryID = storyID
-		chapters[i].ChapterNumber = startNumber + i
+		chapters[i].Ordering = maxOrdering + float64(i+1)
+		if chapters[i].GroupID == nil {
+			chapters[i].GroupID = story.GroupID
+		}
 		chapters[i].PageCount = countImages(chapters[i].Images)
 		chapters[i].CreatedAt = time.Now()
 		chapters[i].UpdatedAt = time.Now()
+	}
+	if err := s.chapterRepo.CreateBatch(chapters); err != nil {
+		return err
+	}
 
-		if err := s.chapterRepo.Create(&chapters[i]); err != nil {
-			return err
+	// Update story total
+	return s.syncTotalChapters(story)
+}
+
+// prepareChapterContent - Chuẩn hóa định dạng, lọc HTML theo allowlist, kiểm tra ghi chú và render sẵn trước khi lưu
+// Markdown giữ nguyên bản gốc để sửa tiếp, HTML thô trong Markdown bị bỏ khi render
+func prepareChapterContent(chapter *models.Chapter) error {
+	if chapter.ContentFormat == "" {
+		chapter.ContentFormat = models.ContentFormatPlain
+	}
+	if !models.IsValidContentFormat(chapter.ContentFormat) {
+		return errors.New("content_format không hợp lệ (plain, markdown, html)")
+	}
+	if chapter.ContentFormat == models.ContentFormatHTML {
+		chapter.Content = richtext.Sanitize(chapter.Content)
+	}
+
+	if err := prepareChapterNotes(chapter); err != nil {
+		return err
+	}
+	renderChapterContent(chapter)
+	return nil
+}
+
+// prepareChapterNotes - Kiểm tra id, loại, nội dung của ghi chú
+func prepareChapterNotes(chapter *models.Chapter) error {
+	if chapter.Notes == nil {
+		return nil
+	}
+	var notes []models.ChapterNote
+	if err := json.Unmarshal(chapter.Notes, &notes); err != nil {
+		return errors.New("danh sách ghi chú không hợp lệ")
+	}
+	seen := make(map[string]bool, len(notes))
+	for i := range notes {
+		note := &notes[i]
+		note.ID = strings.TrimSpace(note.ID)
+		if !richtext.NoteIDPattern.MatchString(note.ID) {
+			return errors.New("id ghi chú chỉ gồm chữ, số, - và _ (tối đa 50 ký tự)")
 		}
+		if seen[note.ID] {
+			return fmt.Errorf("id ghi chú %q bị trùng", note.ID)
+		}
+		seen[note.ID] = true
+
+		if note.Type == "" {
+			note.Type = models.NoteTypeTranslator
+		}
+		if !models.IsValidNoteType(note.Type) {
+			return errors.New("loại ghi chú không hợp lệ (translator, footnote)")
+		}
+		note.Content = strings.TrimSpace(note.Content)
+		if chapter.ContentFormat == models.ContentFormatHTML {
+			note.Content = richtext.Sanitize(note.Content)
+		}
+		if note.Content == "" {
+			return fmt.Errorf("ghi chú %q không có nội dung", note.ID)
+		}
+		note.HTML = ""
 	}
+	return chapter.SetNotes(notes)
+}
 
-	// Update story total
-	story.TotalChapters += len(chapters)
-	story.UpdatedAt = time.Now()
-	return s.storyRepo.UpdateStory(story)
+// ensureChapterRendered - Chapter lưu trước khi có bản render sẵn thì render khi đọc
+func ensureChapterRendered(chapter *models.Chapter) {
+	if chapter.Content != "" && chapter.ContentHTML == "" {
+		renderChapterContent(chapter)
+		return
+	}
+	for _, note := range chapter.GetNotes() {
+		if note.HTML == "" {
+			renderChapterContent(chapter)
+			return
+		}
+	}
+}
+
+// renderChapterContent - Điền ContentHTML và HTML của từng ghi chú, marker [^id] thành <sup> trỏ tới ghi chú
+func renderChapterContent(chapter *models.Chapter) {
+	notes := chapter.GetNotes()
+	ids := make([]string, len(notes))
+	for i := range notes {
+		ids[i] = notes[i].ID
+		notes[i].HTML = renderContent(chapter.ContentFormat, notes[i].Content)
+	}
+	if len(notes) > 0 {
+		_ = chapter.SetNotes(notes)
+	}
+	chapter.ContentHTML = ""
+	if chapter.Content != "" {
+		chapter.ContentHTML = richtext.LinkNotes(renderContent(chapter.ContentFormat, chapter.Content), ids)
+	}
+}
+
+// renderContent - Nội dung theo định dạng -> HTML đã lọc
+func renderContent(format, content string) string {
+	switch format {
+	case models.ContentFormatMarkdown:
+		if rendered, err := richtext.MarkdownToHTML(content); err == nil {
+			return rendered
+		}
+		return richtext.PlainToHTML(content)
+	case models.ContentFormatHTML:
+		return richtext.Sanitize(content) // Lọc lại: dữ liệu cũ lưu trước khi có sanitizer
+	}
+	return richtext.PlainToHTML(content)
+}
+
+// checkVolume - Tập được gán phải thuộc cùng truyện với chapter
+func (s *chapterService) checkVolume(storyID uuid.UUID, volumeID *uuid.UUID) error {
+	if volumeID == nil {
+		return nil
+	}
+	volume, err := s.volumeRepo.FindVolumeByID(*volumeID)
+	if err != nil || volume.StoryID != storyID {
+		return errors.New("tập không tồn tại trong truyện này")
+	}
+	return nil
 }
 
 // PublishScheduledChapters - Auto-publish chapters that have reached their scheduled time
@@ -20,12 +20,15 @@ func sanitizeCommentContent(content string) string {
 }
 
 type CommentService interface {
-	CreateComment(userID, storyID uuid.UUID, chapterID *uuid.UUID, content string) (*models.Comment, error)
-	ReplyComment(userID, parentID uuid.UUID, content string) (*models.Comment, error)
+	CreateComment(userID, storyID uuid.UUID, chapterID *uuid.UUID, content string, showMature bool) (*models.Comment, error)
+	ReplyComment(userID, parentID uuid.UUID, content string, showMature bool) (*models.Comment, error)
 	UpdateComment(userID, commentID uuid.UUID, content string) (*models.Comment, error)
-	DeleteComment(userID, commentID uuid.UUID, isAdmin bool) error
-	GetCommentsByStory(storyID uuid.UUID, page, limit int, sortBy string) ([]models.Comment, int64, error)
-	GetCommentsByChapter(chapterID uuid.UUID, page, limit int, sortBy string) ([]models.Comment, int64, error)
+	DeleteComment(userID, commentID uuid.UUID, canModerate bool) error
+	// showMature: comment của truyện mature/adult trả ContentWarningError nếu người xem chưa bật hiển thị
+	GetCommentsByStory(storyID uuid.UUID, page, limit int, sortBy string, showMature bool) ([]models.Comment, int64, error)
+	GetCommentsByChapter(chapterID uuid.UUID, page, limit int, sortBy string, showMature bool) ([]models.Comment, int64, error)
+	GetCommentsByStoryCursor(storyID uuid.UUID, cursor string, limit int, sortBy string, showMature bool) ([]models.Comment, string, error)
+	GetCommentsByChapterCursor(chapterID uuid.UUID, cursor string, limit int, sortBy string, showMature bool) ([]models.Comment, string, error)
 	UpdateLikeCount(commentID uuid.UUID, count int) error
 	TogglePin(commentID uuid.UUID, isPinned bool) error
 	FindCommentByID(id uuid.UUID) (*models.Comment, error)
@@ -50,7 +53,7 @@ func NewCommentService(
 }
 
 // CreateComment - Tạo comment mới
-func (s *commentService) CreateComment(userID, storyID uuid.UUID, chapterID *uuid.UUID, content string) (*models.Comment, error) {
+func (s *commentService) CreateComment(userID, storyID uuid.UUID, chapterID *uuid.UUID, content string, showMature bool) (*models.Comment, error) {
 	// Validate and sanitize content
 	content = strings.TrimSpace(content)
 	if content == "" {
@@ -65,10 +68,13 @@ func (s *commentService) CreateComment(userID, storyID uuid.UUID, chapterID *uui
 	content = sanitizeCommentContent(content)
 
 	// Check story exists
-	_, err := s.storyRepo.FindStoryByID(storyID)
+	story, err := s.storyRepo.FindStoryByID(storyID)
 	if err != nil {
 		return nil, errors.New("truyện không tồn tại")
 	}
+	if err := checkContentAccess(story, showMature); err != nil {
+		return nil, err
+	}
 
 	// Check chapter exists (if provided)
 	if chapterID != nil {
@@ -100,7 +106,7 @@ func (s *commentService) CreateComment(userID, storyID uuid.UUID, chapterID *uui
 }
 
 // ReplyComment - Trả lời comment
-func (s *commentService) ReplyComment(userID, parentID uuid.UUID, content string) (*models.Comment, error) {
+func (s *commentService) ReplyComment(userID, parentID uuid.UUID, content string, showMature bool) (*models.Comment, error) {
 	// Validate and sanitize content
 	content = strings.TrimSpace(content)
 	if content == "" {
@@ -121,6 +127,15 @@ func (s *commentService) ReplyComment(userID, parentID uuid.UUID, content string
 		return nil, errors.New("không thể reply một reply")
 	}
 
+	// Cùng kiểm tra nội dung người lớn như comment gốc
+	story, err := s.storyRepo.FindStoryByID(parentComment.StoryID)
+	if err != nil {
+		return nil, errors.New("truyện không tồn tại")
+	}
+	if err := checkContentAccess(story, showMature); err != nil {
+		return nil, err
+	}
+
 	reply := &models.Comment{
 		UserID:     userID,
 		StoryID:    parentComment.StoryID,
@@ -185,14 +200,14 @@ func (s *commentService) UpdateComment(userID, commentID uuid.UUID, content stri
 }
 
 // DeleteComment - Xóa comment
-func (s *commentService) DeleteComment(userID, commentID uuid.UUID, isAdmin bool) error {
+func (s *commentService) DeleteComment(userID, commentID uuid.UUID, canModerate bool) error {
 	comment, err := s.commentRepo.FindCommentByID(commentID)
 	if err != nil {
 		return errors.New("comment không tồn tại")
 	}
 
-	// Only owner or admin can delete
-	if comment.UserID != userID && !isAdmin {
+	// Only owner or moderator can delete
+	if comment.UserID != userID && !canModerate {
 		return errors.New("bạn không có quyền xóa comment này")
 	}
 
@@ -200,15 +215,61 @@ func (s *commentService) DeleteComment(userID, commentID uuid.UUID, isAdmin bool
 }
 
 // GetCommentsByStory - Lấy comments của truyện
-func (s *commentService) GetCommentsByStory(storyID uuid.UUID, page, limit int, sortBy string) ([]models.Comment, int64, error) {
+func (s *commentService) GetCommentsByStory(storyID uuid.UUID, page, limit int, sortBy string, showMature bool) ([]models.Comment, int64, error) {
+	if err := s.checkStoryAccess(storyID, showMature); err != nil {
+		return nil, 0, err
+	}
 	return s.commentRepo.GetCommentsByStory(storyID, page, limit, sortBy)
 }
 
 // GetCommentsByChapter - Lấy comments của chapter
-func (s *commentService) GetCommentsByChapter(chapterID uuid.UUID, page, limit int, sortBy string) ([]models.Comment, int64, error) {
+func (s *commentService) GetCommentsByChapter(chapterID uuid.UUID, page, limit int, sortBy string, showMature bool) ([]models.Comment, int64, error) {
+	if err := s.checkChapterAccess(chapterID, showMature); err != nil {
+		return nil, 0, err
+	}
 	return s.commentRepo.GetCommentsByChapter(chapterID, page, limit, sortBy)
 }
 
+// GetCommentsByStoryCursor - Lấy comments của story theo cursor
+func (s *commentService) GetCommentsByStoryCursor(storyID uuid.UUID, cursor string, limit int, sortBy string, showMature bool) ([]models.Comment, string, error) {
+	if err := s.checkStoryAccess(storyID, showMature); err != nil {
+		return nil, "", err
+	}
+	return s.commentRepo.GetCommentsByStoryCursor(storyID, cursor, limit, sortBy)
+}
+
+// GetCommentsByChapterCursor - Lấy comments của chapter theo cursor
+func (s *commentService) GetCommentsByChapterCursor(chapterID uuid.UUID, cursor string, limit int, sortBy string, showMature bool) ([]models.Comment, string, error) {
+	if err := s.checkChapterAccess(chapterID, showMature); err != nil {
+		return nil, "", err
+	}
+	return s.commentRepo.GetCommentsByChapterCursor(chapterID, cursor, limit, sortBy)
+}
+
+// checkStoryAccess - Chặn comment của truyện mature/adult với người xem chưa bật hiển thị
+func (s *commentService) checkStoryAccess(storyID uuid.UUID, showMature bool) error {
+	if showMature {
+		return nil
+	}
+	story, err := s.storyRepo.FindStoryByID(storyID)
+	if err != nil {
+		return nil // Truyện không tồn tại: giữ hành vi cũ (danh sách rỗng)
+	}
+	return checkContentAccess(story, showMature)
+}
+
+// checkChapterAccess - Giống checkStoryAccess nhưng theo chapter
+func (s *commentService) checkChapterAccess(chapterID uuid.UUID, showMature bool) error {
+	if showMature {
+		return nil
+	}
+	chapter, err := s.chapterRepo.FindByID(chapterID)
+	if err != nil {
+		return nil
+	}
+	return checkContentAccess(&chapter.Story, showMature)
+}
+
 // UpdateLikeCount - Update cached like count for a comment
 func (s *commentService) UpdateLikeCount(commentID uuid.UUID, count int) error {
 	return s.commentRepo.UpdateLikeCount(commentID, count)
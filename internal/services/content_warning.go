@@ -0,0 +1,22 @@
+package services
+
+import "nekozanedex/internal/models"
+
+// ContentWarningError - Truyện mature/adult mà người xem chưa bật hiển thị nội dung người lớn
+// Client dựa vào lỗi này (403, code CONTENT_WARNING) để hiện cảnh báo thay vì "không tìm thấy"
+type ContentWarningError struct {
+	StorySlug string `json:"slug"`
+	AgeRating string `json:"age_rating"`
+}
+
+func (e *ContentWarningError) Error() string {
+	return "truyện có nội dung dành cho người lớn, hãy bật hiển thị nội dung người lớn trong cài đặt"
+}
+
+// checkContentAccess - Trả về ContentWarningError nếu người xem không được xem truyện
+func checkContentAccess(story *models.Story, showMature bool) error {
+	if story.IsMature() && !showMature {
+		return &ContentWarningError{StorySlug: story.Slug, AgeRating: story.AgeRating}
+	}
+	return nil
+}
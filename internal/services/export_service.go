@@ -0,0 +1,682 @@
+package services
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"nekozanedex/internal/models"
+	"nekozanedex/internal/repositories"
+	"nekozanedex/pkg/cbz"
+	"nekozanedex/pkg/epub"
+	"nekozanedex/pkg/richtext"
+
+	"github.com/google/uuid"
+)
+
+const (
+	exportCacheTTL      = 6 * time.Hour
+	exportCacheMaxSize  = 256 * 1024 * 1024 // Tổng dung lượng EPUB giữ trong cache, vượt thì bỏ bản ít dùng nhất
+	exportCacheMaxFile  = 32 * 1024 * 1024  // File lớn hơn không cache
+	exportCoverMaxLen   = 10 * 1024 * 1024
+	downloadMaxChapters = 10 // Mỗi lần tải CBZ, cùng rate limit theo user để chống cào dữ liệu
+	downloadMaxPageLen  = 30 * 1024 * 1024
+)
+
+var (
+	// ErrNoTextChapters - Khoảng chapter không có chapter dạng chữ để xuất EPUB
+	ErrNoTextChapters = errors.New("không có chapter dạng chữ để xuất")
+	// ErrNoImageChapters - Khoảng chapter không có chapter dạng ảnh để tải CBZ
+	ErrNoImageChapters = errors.New("không có chapter dạng ảnh để tải")
+	// ErrTooManyChapters - Khoảng chapter vượt quá giới hạn mỗi lần tải
+	ErrTooManyChapters = fmt.Errorf("mỗi lần tải tối đa %d chapter", downloadMaxChapters)
+)
+
+// ExportFile - File đã dựng, ETag là fingerprint của dữ liệu nguồn
+type ExportFile struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+	ETag        string
+	ModTime     time.Time
+}
+
+// CBZDownload - Bản tải CBZ đã kiểm tra quyền, trang được tải và ghi dạng stream bởi WriteCBZ
+type CBZDownload struct {
+	Filename    string
+	ContentType string
+	story       *models.Story
+	chapters    []models.Chapter
+	volumes     map[uuid.UUID]int // volumeID -> số tập
+	nested      bool              // Nhiều chapter: ZIP chứa mỗi chapter một file .cbz
+}
+
+type ExportService interface {
+	// from/to: số chapter đầu/cuối (0 = không giới hạn), khoảng tính theo thứ tự đọc
+	ExportEPUB(storySlug string, from, to int, showMature bool) (*ExportFile, error)
+
+	// CBZ cho đọc offline (manga): một chapter, hoặc ZIP gồm nhiều file .cbz
+	PrepareChapterCBZ(storySlug string, number int, showMature bool) (*CBZDownload, error)
+	PrepareStoryCBZ(storySlug string, from, to int, showMature bool) (*CBZDownload, error)
+	WriteCBZ(ctx context.Context, w io.Writer, download *CBZDownload) error
+}
+
+// exportCache - LRU giới hạn theo tổng dung lượng, key là fingerprint (ETag) của bản dựng
+type exportCache struct {
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // Đầu danh sách = dùng gần nhất
+	size    int
+}
+
+type cachedExport struct {
+	file      *ExportFile
+	expiresAt time.Time
+}
+
+func newExportCache() *exportCache {
+	return &exportCache{entries: make(map[string]*list.Element), order: list.New()}
+}
+
+func (c *exportCache) get(key string) (*ExportFile, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*cachedExport)
+	if time.Now().After(entry.expiresAt) {
+		c.remove(element)
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return entry.file, true
+}
+
+func (c *exportCache) put(file *ExportFile) {
+	if len(file.Data) > exportCacheMaxFile {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[file.ETag]; ok {
+		c.remove(element)
+	}
+	c.entries[file.ETag] = c.order.PushFront(&cachedExport{file: file, expiresAt: time.Now().Add(exportCacheTTL)})
+	c.size += len(file.Data)
+	for c.size > exportCacheMaxSize {
+		c.remove(c.order.Back())
+	}
+}
+
+// removeExpired - Xóa các bản dựng hết hạn
+func (c *exportCache) removeExpired() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for element := c.order.Front(); element != nil; {
+		next := element.Next()
+		if now.After(element.Value.(*cachedExport).expiresAt) {
+			c.remove(element)
+		}
+		element = next
+	}
+}
+
+func (c *exportCache) remove(element *list.Element) {
+	entry := c.order.Remove(element).(*cachedExport)
+	delete(c.entries, entry.file.ETag)
+	c.size -= len(entry.file.Data)
+}
+
+type exportService struct {
+	storyRepo   repositories.StoryRepository
+	chapterRepo repositories.ChapterRepository
+	volumeRepo  repositories.VolumeRepository
+	httpClient  *http.Client
+	cache       *exportCache
+}
+
+func NewExportService(storyRepo repositories.StoryRepository, chapterRepo repositories.ChapterRepository, volumeRepo repositories.VolumeRepository) ExportService {
+	s := &exportService{
+		storyRepo:   storyRepo,
+		chapterRepo: chapterRepo,
+		volumeRepo:  volumeRepo,
+		httpClient:  &http.Client{Timeout: 15 * time.Second},
+		cache:       newExportCache(),
+	}
+	go s.cleanupCache()
+	return s
+}
+
+// ExportEPUB - Dựng EPUB từ nội dung chữ của các chapter đã publish
+// Fingerprint (truyện, tập, updated_at của từng chapter) tính từ truy vấn nhẹ không kèm nội dung,
+// nội dung chapter chỉ được tải khi cache chưa có bản dựng
+func (s *exportService) ExportEPUB(storySlug string, from, to int, showMature bool) (*ExportFile, error) {
+	story, err := s.storyRepo.FindStoryMetaBySlug(storySlug)
+	if err != nil {
+		return nil, errors.New("truyện không tồn tại")
+	}
+	if err := checkContentAccess(story, showMature); err != nil {
+		return nil, err
+	}
+
+	stamps, err := s.chapterRepo.GetPublishedStamps(story.ID)
+	if err != nil {
+		return nil, err
+	}
+	stamps, err = chapterRange(stamps, func(stamp repositories.ChapterStamp) int { return stamp.ChapterNumber }, from, to)
+	if err != nil {
+		return nil, err
+	}
+	filename := exportFilename(story.Slug, from, to, stamps[0].ChapterNumber, stamps[len(stamps)-1].ChapterNumber) + ".epub"
+	textStamps := make([]repositories.ChapterStamp, 0, len(stamps))
+	for _, stamp := range stamps {
+		if stamp.HasText {
+			textStamps = append(textStamps, stamp)
+		}
+	}
+	if len(textStamps) == 0 {
+		return nil, ErrNoTextChapters
+	}
+
+	volumes, err := s.volumeRepo.GetVolumesByStory(story.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	etag, modTime := exportFingerprint(story, filename, textStamps, volumes)
+	if file, ok := s.cache.get(etag); ok {
+		return file, nil
+	}
+
+	ids := make([]uuid.UUID, len(textStamps))
+	for i, stamp := range textStamps {
+		ids[i] = stamp.ID
+	}
+	textChapters, err := s.chapterRepo.FindByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	book := &epub.Book{
+		Identifier:  "urn:uuid:" + story.ID.String(),
+		Title:       story.Title,
+		AltTitles:   storyAltTitles(story),
+		Language:    "vi",
+		Authors:     storyPeopleNames(story, models.PersonRoleAuthor, story.AuthorName),
+		Translators: storyPeopleNames(story, models.PersonRoleTranslator, story.Translator),
+		Subjects:    make([]string, 0, len(story.Genres)),
+		Publisher:   "NekoZaneDex",
+		Modified:    modTime,
+		Cover:       s.fetchCover(story.CoverImageURL),
+		Sections:    epubSections(textChapters, volumes),
+	}
+	for _, genre := range story.Genres {
+		book.Subjects = append(book.Subjects, genre.Name)
+	}
+	if story.Description != nil {
+		book.Description = *story.Description
+	}
+
+	var buf bytes.Buffer
+	if err := epub.Write(&buf, book); err != nil {
+		return nil, err
+	}
+
+	file := &ExportFile{
+		Filename:    filename,
+		ContentType: "application/epub+zip",
+		Data:        buf.Bytes(),
+		ETag:        etag,
+		ModTime:     modTime,
+	}
+	s.cache.put(file)
+	return file, nil
+}
+
+// PrepareChapterCBZ - Kiểm tra chapter đã publish và có ảnh
+func (s *exportService) PrepareChapterCBZ(storySlug string, number int, showMature bool) (*CBZDownload, error) {
+	if number <= 0 {
+		return nil, errors.New("số chapter không hợp lệ") // 0 trong chapterRange nghĩa là không giới hạn
+	}
+	story, err := s.storyRepo.FindStoryMetaBySlug(storySlug)
+	if err != nil {
+		return nil, errors.New("truyện không tồn tại")
+	}
+	if err := checkContentAccess(story, showMature); err != nil {
+		return nil, err
+	}
+
+	stamps, err := s.publishedStamps(story.ID, number, number)
+	if err != nil {
+		return nil, err
+	}
+	download, err := s.prepareCBZ(story, stamps)
+	if err != nil {
+		return nil, err
+	}
+	download.Filename = cbzChapterFilename(story.Slug, download.chapters[0]) + ".cbz"
+	download.ContentType = "application/vnd.comicbook+zip"
+	return download, nil
+}
+
+// PrepareStoryCBZ - Nhiều chapter (tối đa downloadMaxChapters), bỏ qua chapter dạng chữ
+func (s *exportService) PrepareStoryCBZ(storySlug string, from, to int, showMature bool) (*CBZDownload, error) {
+	story, err := s.storyRepo.FindStoryMetaBySlug(storySlug)
+	if err != nil {
+		return nil, errors.New("truyện không tồn tại")
+	}
+	if err := checkContentAccess(story, showMature); err != nil {
+		return nil, err
+	}
+
+	stamps, err := s.publishedStamps(story.ID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	download, err := s.prepareCBZ(story, stamps)
+	if err != nil {
+		return nil, err
+	}
+	download.Filename = exportFilename(story.Slug, from, to, stamps[0].ChapterNumber, stamps[len(stamps)-1].ChapterNumber) + ".zip"
+	download.ContentType = "application/zip"
+	download.nested = true
+	return download, nil
+}
+
+// publishedStamps - Chapter đã publish trong khoảng from..to, chưa tải nội dung/ảnh
+func (s *exportService) publishedStamps(storyID uuid.UUID, from, to int) ([]repositories.ChapterStamp, error) {
+	stamps, err := s.chapterRepo.GetPublishedStamps(storyID)
+	if err != nil {
+		return nil, err
+	}
+	return chapterRange(stamps, func(stamp repositories.ChapterStamp) int { return stamp.ChapterNumber }, from, to)
+}
+
+// prepareCBZ - Chỉ tải đầy đủ các chapter có ảnh trong khoảng, sau khi đã kiểm tra giới hạn số chapter
+func (s *exportService) prepareCBZ(story *models.Story, stamps []repositories.ChapterStamp) (*CBZDownload, error) {
+	ids := make([]uuid.UUID, 0, len(stamps))
+	for _, stamp := range stamps {
+		if stamp.HasImages {
+			ids = append(ids, stamp.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, ErrNoImageChapters
+	}
+	if len(ids) > downloadMaxChapters {
+		return nil, ErrTooManyChapters
+	}
+
+	chapters, err := s.chapterRepo.FindByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	download := &CBZDownload{story: story, chapters: chapters}
+
+	volumes, err := s.volumeRepo.GetVolumesByStory(story.ID)
+	if err != nil {
+		return nil, err
+	}
+	download.volumes = make(map[uuid.UUID]int, len(volumes))
+	for _, volume := range volumes {
+		download.volumes[volume.ID] = volume.Number
+	}
+	return download, nil
+}
+
+// WriteCBZ - Tải từng trang và ghi thẳng vào w (không giữ cả file trong bộ nhớ)
+// Lỗi giữa chừng làm file bị cắt cụt, client nhận file ZIP hỏng thay vì thiếu trang
+func (s *exportService) WriteCBZ(ctx context.Context, w io.Writer, download *CBZDownload) error {
+	if !download.nested {
+		return s.writeChapterCBZ(ctx, w, download, download.chapters[0])
+	}
+
+	archive := cbz.NewWriter(w)
+	for _, chapter := range download.chapters {
+		entry, err := archive.CreateEntry(cbzChapterFilename(download.story.Slug, chapter)+".cbz", chapter.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		if err := s.writeChapterCBZ(ctx, entry, download, chapter); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+func (s *exportService) writeChapterCBZ(ctx context.Context, w io.Writer, download *CBZDownload, chapter models.Chapter) error {
+	pages := chapter.GetImagesSlice()
+	archive := cbz.NewWriter(w)
+	if err := archive.WriteComicInfo(comicInfo(download.story, chapter, download.volumes, len(pages))); err != nil {
+		return err
+	}
+
+	for i, url := range pages {
+		entry, err := archive.CreateEntry(fmt.Sprintf("%04d%s", i+1, pageExtension(url)), chapter.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		if err := s.copyPage(ctx, entry, url); err != nil {
+			return fmt.Errorf("chapter %d trang %d: %w", chapter.ChapterNumber, i+1, err)
+		}
+	}
+	return archive.Close()
+}
+
+func (s *exportService) copyPage(ctx context.Context, w io.Writer, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("tải ảnh lỗi: HTTP %d", resp.StatusCode)
+	}
+
+	n, err := io.Copy(w, io.LimitReader(resp.Body, downloadMaxPageLen+1))
+	if err != nil {
+		return err
+	}
+	if n > downloadMaxPageLen {
+		return errors.New("ảnh quá lớn")
+	}
+	return nil
+}
+
+// fetchCover - Tải ảnh bìa, lỗi thì bỏ qua ảnh bìa thay vì hỏng cả file
+func (s *exportService) fetchCover(url *string) *epub.Image {
+	if url == nil || *url == "" {
+		return nil
+	}
+	resp, err := s.httpClient.Get(*url)
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, exportCoverMaxLen+1))
+	if err != nil || len(data) > exportCoverMaxLen {
+		return nil
+	}
+	mediaType := http.DetectContentType(data)
+	switch mediaType {
+	case "image/jpeg", "image/png", "image/gif", "image/webp":
+		return &epub.Image{Data: data, MediaType: mediaType}
+	}
+	return nil
+}
+
+// cleanupCache - Xóa các bản dựng hết hạn (chạy background)
+func (s *exportService) cleanupCache() {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.cache.removeExpired()
+	}
+}
+
+// chapterRange - Cắt danh sách (đã sắp theo ordering) từ chapter số from tới chapter số to
+func chapterRange[T any](chapters []T, number func(T) int, from, to int) ([]T, error) {
+	start, end := 0, len(chapters)-1
+	for i, chapter := range chapters {
+		if from > 0 && number(chapter) == from {
+			start = i
+		}
+		if to > 0 && number(chapter) == to {
+			end = i
+		}
+	}
+	if from > 0 && (len(chapters) == 0 || number(chapters[start]) != from) {
+		return nil, fmt.Errorf("chapter %d không tồn tại", from)
+	}
+	if to > 0 && (len(chapters) == 0 || number(chapters[end]) != to) {
+		return nil, fmt.Errorf("chapter %d không tồn tại", to)
+	}
+	if start > end {
+		return nil, errors.New("khoảng chapter không hợp lệ")
+	}
+	return chapters[start : end+1], nil
+}
+
+// exportFingerprint - Hash dữ liệu nguồn của bản dựng (kèm tên file) và thời điểm sửa gần nhất
+func exportFingerprint(story *models.Story, filename string, chapters []repositories.ChapterStamp, volumes []models.Volume) (string, time.Time) {
+	h := sha256.New()
+	modTime := story.UpdatedAt
+	fmt.Fprintf(h, "file:%s\n", filename)
+	fmt.Fprintf(h, "story:%s:%d:%s\n", story.ID, story.UpdatedAt.UnixNano(), stringValue(story.CoverImageURL))
+	for _, genre := range story.Genres {
+		fmt.Fprintf(h, "genre:%s\n", genre.Name)
+	}
+	for _, person := range story.People {
+		if person.Person != nil {
+			fmt.Fprintf(h, "person:%s:%s\n", person.Role, person.Person.Name)
+		}
+	}
+	for _, volume := range volumes {
+		fmt.Fprintf(h, "volume:%s:%d\n", volume.ID, volume.UpdatedAt.UnixNano())
+		if volume.UpdatedAt.After(modTime) {
+			modTime = volume.UpdatedAt
+		}
+	}
+	for _, chapter := range chapters {
+		fmt.Fprintf(h, "chapter:%s:%d:%v\n", chapter.ID, chapter.UpdatedAt.UnixNano(), chapter.VolumeID)
+		if chapter.UpdatedAt.After(modTime) {
+			modTime = chapter.UpdatedAt
+		}
+	}
+	return `"` + hex.EncodeToString(h.Sum(nil))[:32] + `"`, modTime
+}
+
+// epubSections - Gom các chapter liên tiếp cùng tập thành một mục trong mục lục
+func epubSections(chapters []models.Chapter, volumes []models.Volume) []epub.Section {
+	volumeTitles := make(map[uuid.UUID]string, len(volumes))
+	for _, volume := range volumes {
+		title := "Tập " + strconv.Itoa(volume.Number)
+		if volume.Title != nil && *volume.Title != "" {
+			title += ": " + *volume.Title
+		}
+		volumeTitles[volume.ID] = title
+	}
+
+	var sections []epub.Section
+	for _, chapter := range chapters {
+		sectionTitle := ""
+		if chapter.VolumeID != nil {
+			sectionTitle = volumeTitles[*chapter.VolumeID]
+		}
+		if len(sections) == 0 || sections[len(sections)-1].Title != sectionTitle {
+			sections = append(sections, epub.Section{Title: sectionTitle})
+		}
+		last := &sections[len(sections)-1]
+		last.Chapters = append(last.Chapters, epub.Chapter{
+			Title: chapterDisplayTitle(chapter),
+			Body:  epub.TextToXHTML(chapterText(chapter)),
+		})
+	}
+	return sections
+}
+
+// chapterText - Nội dung dạng chữ cho EPUB: Markdown/HTML bỏ định dạng, marker ghi chú thành [n], ghi chú đặt cuối chapter
+func chapterText(chapter models.Chapter) string {
+	notes := chapter.GetNotes()
+	if chapter.ContentFormat != models.ContentFormatMarkdown && chapter.ContentFormat != models.ContentFormatHTML && len(notes) == 0 {
+		return chapter.Content
+	}
+
+	index := make(map[string]int, len(notes))
+	for i, note := range notes {
+		index[note.ID] = i + 1
+	}
+	rendered := richtext.ReplaceNoteMarkers(renderContent(chapter.ContentFormat, chapter.Content), func(id string) (string, bool) {
+		n, ok := index[id]
+		return "[" + strconv.Itoa(n) + "]", ok
+	})
+	_, text := epub.ExtractText([]byte("<body>" + rendered + "</body>"))
+
+	for i, note := range notes {
+		_, noteText := epub.ExtractText([]byte("<body>" + renderContent(chapter.ContentFormat, note.Content) + "</body>"))
+		text += fmt.Sprintf("\n[%d] %s", i+1, strings.ReplaceAll(noteText, "\n", " "))
+	}
+	return text
+}
+
+// chapterDisplayTitle - "Chương <nhãn hoặc số>: <tiêu đề>"
+func chapterDisplayTitle(chapter models.Chapter) string {
+	label := strconv.Itoa(chapter.ChapterNumber)
+	if chapter.ChapterLabel != nil && *chapter.ChapterLabel != "" {
+		label = *chapter.ChapterLabel
+	}
+	if chapter.Title == "" {
+		return "Chương " + label
+	}
+	return "Chương " + label + ": " + chapter.Title
+}
+
+// storyAltTitles - Tên gốc và tên phụ của truyện
+func storyAltTitles(story *models.Story) []string {
+	var titles []string
+	if story.OriginalTitle != nil && *story.OriginalTitle != "" {
+		titles = append(titles, *story.OriginalTitle)
+	}
+	var alt []string
+	if len(story.AltTitles) > 0 && json.Unmarshal(story.AltTitles, &alt) == nil {
+		for _, title := range alt {
+			if strings.TrimSpace(title) != "" {
+				titles = append(titles, title)
+			}
+		}
+	}
+	return titles
+}
+
+// storyPeopleNames - Người đã liên kết theo vai trò, không có thì dùng trường text của truyện
+func storyPeopleNames(story *models.Story, role string, fallback *string) []string {
+	var names []string
+	for _, person := range story.People {
+		if person.Role == role && person.Person != nil {
+			names = append(names, person.Person.Name)
+		}
+	}
+	if len(names) == 0 && fallback != nil && *fallback != "" {
+		names = append(names, *fallback)
+	}
+	return names
+}
+
+// exportFilename - slug, hoặc slug_c<đầu>-<cuối> khi xuất một khoảng chapter
+func exportFilename(slug string, from, to, first, last int) string {
+	if from == 0 && to == 0 {
+		return slug
+	}
+	return fmt.Sprintf("%s_c%d-%d", slug, first, last)
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// comicInfo - ComicInfo.xml từ metadata truyện và chapter
+func comicInfo(story *models.Story, chapter models.Chapter, volumes map[uuid.UUID]int, pageCount int) *cbz.ComicInfo {
+	info := &cbz.ComicInfo{
+		Title:       chapter.Title,
+		Series:      story.Title,
+		Number:      strconv.Itoa(chapter.ChapterNumber),
+		Summary:     stringValue(story.Description),
+		Writer:      strings.Join(storyPeopleNames(story, models.PersonRoleAuthor, story.AuthorName), ", "),
+		Penciller:   strings.Join(storyPeopleNames(story, models.PersonRoleArtist, story.ArtistName), ", "),
+		Translator:  strings.Join(storyPeopleNames(story, models.PersonRoleTranslator, story.Translator), ", "),
+		Publisher:   "NekoZaneDex",
+		PageCount:   pageCount,
+		LanguageISO: "vi",
+		AgeRating:   comicAgeRating(story.AgeRating),
+	}
+	if chapter.ChapterLabel != nil && *chapter.ChapterLabel != "" {
+		info.Number = *chapter.ChapterLabel
+	}
+	if chapter.VolumeID != nil {
+		info.Volume = volumes[*chapter.VolumeID]
+	}
+	if alt := storyAltTitles(story); len(alt) > 0 {
+		info.AlternateSeries = alt[0]
+	}
+	if story.Status == "completed" {
+		info.Count = story.TotalChapters
+	}
+
+	genres := make([]string, 0, len(story.Genres))
+	for _, genre := range story.Genres {
+		genres = append(genres, genre.Name)
+	}
+	info.Genre = strings.Join(genres, ", ")
+
+	published := chapter.CreatedAt
+	if chapter.PublishedAt != nil {
+		published = *chapter.PublishedAt
+	}
+	info.Year, info.Month, info.Day = published.Year(), int(published.Month()), published.Day()
+
+	if story.Country != nil && *story.Country == "JP" {
+		info.Manga = cbz.MangaYesRightToLeft
+	}
+	return info
+}
+
+// comicAgeRating - Age rating của truyện -> giá trị ComicInfo
+func comicAgeRating(rating string) string {
+	switch rating {
+	case models.AgeRatingEveryone:
+		return "Everyone"
+	case models.AgeRatingTeen:
+		return "Teen"
+	case models.AgeRatingMature:
+		return "Mature 17+"
+	case models.AgeRatingAdult:
+		return "Adults Only 18+"
+	}
+	return ""
+}
+
+// cbzChapterFilename - slug_c0001 (đệm số để trình đọc sắp đúng thứ tự)
+func cbzChapterFilename(slug string, chapter models.Chapter) string {
+	return fmt.Sprintf("%s_c%04d", slug, chapter.ChapterNumber)
+}
+
+// pageExtension - Đuôi file ảnh theo URL, mặc định .jpg
+func pageExtension(url string) string {
+	url, _, _ = strings.Cut(url, "?")
+	ext := strings.ToLower(path.Ext(url))
+	switch ext {
+	case ".jpg", ".jpeg", ".png", ".webp", ".gif":
+		return ext
+	}
+	return ".jpg"
+}
@@ -0,0 +1,372 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"nekozanedex/internal/models"
+	"nekozanedex/internal/repositories"
+
+	"github.com/google/uuid"
+	"github.com/gosimple/slug"
+)
+
+// ErrGroupForbidden - Người dùng không phải thành viên hoặc role không đủ quyền
+var ErrGroupForbidden = errors.New("bạn không có quyền thực hiện thao tác này trong nhóm")
+
+// GroupProfile - Trang public của nhóm dịch: thông tin, thành viên, truyện phụ trách
+type GroupProfile struct {
+	Group   *models.Group  `json:"group"`
+	Stories []models.Story `json:"stories"`
+}
+
+// GroupInput - Thông tin nhóm khi tạo/cập nhật
+type GroupInput struct {
+	Name        string  `json:"name"`
+	Description *string `json:"description"`
+	AvatarURL   *string `json:"avatar_url"`
+	Website     *string `json:"website"`
+}
+
+type GroupService interface {
+	// Public
+	GetGroups(query string, page, limit int) ([]models.Group, int64, error)
+	GetGroupProfile(slug string, showMature bool) (*GroupProfile, error)
+	GetGroupReleases(slug string, page, limit int, showMature bool) ([]models.Chapter, int64, error)
+
+	// Member
+	GetMyGroups(userID uuid.UUID) ([]models.GroupMember, error)
+	UpdateGroup(slug string, actorID uuid.UUID, input GroupInput) (*models.Group, error)
+	SetMember(slug string, actorID, userID uuid.UUID, role string) (*models.GroupMember, error)
+	RemoveMember(slug string, actorID, userID uuid.UUID) error
+	GetStoryChapters(slug string, actorID, storyID uuid.UUID) ([]models.Chapter, error)
+	CreateChapter(slug string, actorID, storyID uuid.UUID, chapter *models.Chapter) error
+	UpdateChapter(slug string, actorID, chapterID uuid.UUID, chapter *models.Chapter) error
+	DeleteChapter(slug string, actorID, chapterID uuid.UUID) error
+	PublishChapter(slug string, actorID, chapterID uuid.UUID) error
+	ScheduleChapter(slug string, actorID, chapterID uuid.UUID, scheduledAt time.Time) error
+
+	// Admin
+	CreateGroup(ownerID uuid.UUID, input GroupInput) (*models.Group, error)
+	DeleteGroup(id uuid.UUID) error
+	AssignStory(storyID uuid.UUID, groupID *uuid.UUID) error
+}
+
+type groupService struct {
+	groupRepo      repositories.GroupRepository
+	storyRepo      repositories.StoryRepository
+	userRepo       repositories.UserRepository
+	chapterService ChapterService
+}
+
+func NewGroupService(
+	groupRepo repositories.GroupRepository,
+	storyRepo repositories.StoryRepository,
+	userRepo repositories.UserRepository,
+	chapterService ChapterService,
+) GroupService {
+	return &groupService{
+		groupRepo:      groupRepo,
+		storyRepo:      storyRepo,
+		userRepo:       userRepo,
+		chapterService: chapterService,
+	}
+}
+
+// GetGroups - Danh sách nhóm dịch (Public)
+func (s *groupService) GetGroups(query string, page, limit int) ([]models.Group, int64, error) {
+	return s.groupRepo.GetAllGroups(query, page, limit)
+}
+
+// GetGroupProfile - Thông tin nhóm + truyện đã publish nhóm phụ trách (Public)
+func (s *groupService) GetGroupProfile(slug string, showMature bool) (*GroupProfile, error) {
+	group, err := s.groupRepo.FindGroupBySlug(slug)
+	if err != nil {
+		return nil, errors.New("không tìm thấy nhóm dịch")
+	}
+
+	stories, err := s.groupRepo.GetGroupStories(group.ID, true, showMature)
+	if err != nil {
+		return nil, err
+	}
+	return &GroupProfile{Group: group, Stories: stories}, nil
+}
+
+// GetGroupReleases - Chapter mới nhất nhóm đã phát hành (Public)
+func (s *groupService) GetGroupReleases(slug string, page, limit int, showMature bool) ([]models.Chapter, int64, error) {
+	group, err := s.groupRepo.FindGroupBySlug(slug)
+	if err != nil {
+		return nil, 0, errors.New("không tìm thấy nhóm dịch")
+	}
+	return s.groupRepo.GetGroupReleases(group.ID, page, limit, showMature)
+}
+
+// GetMyGroups - Các nhóm người dùng đang tham gia kèm role
+func (s *groupService) GetMyGroups(userID uuid.UUID) ([]models.GroupMember, error) {
+	return s.groupRepo.GetUserGroups(userID)
+}
+
+// UpdateGroup - Cập nhật thông tin nhóm (owner), giữ nguyên slug
+func (s *groupService) UpdateGroup(slug string, actorID uuid.UUID, input GroupInput) (*models.Group, error) {
+	group, err := s.authorize(slug, actorID, models.GroupRoleOwner)
+	if err != nil {
+		return nil, err
+	}
+
+	if name := strings.TrimSpace(input.Name); name != "" {
+		group.Name = name
+	}
+	// Trường không gửi thì giữ nguyên
+	if input.Description != nil {
+		group.Description = input.Description
+	}
+	if input.AvatarURL != nil {
+		group.AvatarURL = input.AvatarURL
+	}
+	if input.Website != nil {
+		group.Website = input.Website
+	}
+
+	if err := s.groupRepo.UpdateGroup(group); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+// SetMember - Thêm thành viên hoặc đổi role (owner)
+func (s *groupService) SetMember(slug string, actorID, userID uuid.UUID, role string) (*models.GroupMember, error) {
+	if !models.IsValidGroupRole(role) {
+		return nil, errors.New("role không hợp lệ (owner, editor, uploader)")
+	}
+
+	group, err := s.authorize(slug, actorID, models.GroupRoleOwner)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.userRepo.FindUserByID(userID); err != nil {
+		return nil, errors.New("người dùng không tồn tại")
+	}
+
+	// Không để nhóm mất owner cuối cùng khi hạ role
+	if existing, err := s.groupRepo.GetMember(group.ID, userID); err == nil &&
+		existing.Role == models.GroupRoleOwner && role != models.GroupRoleOwner {
+		if err := s.ensureAnotherOwner(group.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	member := &models.GroupMember{GroupID: group.ID, UserID: userID, Role: role}
+	if err := s.groupRepo.UpsertMember(member); err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+// RemoveMember - Xóa thành viên (owner) hoặc tự rời nhóm
+func (s *groupService) RemoveMember(slug string, actorID, userID uuid.UUID) error {
+	minRole := models.GroupRoleOwner
+	if actorID == userID {
+		minRole = models.GroupRoleUploader
+	}
+
+	group, err := s.authorize(slug, actorID, minRole)
+	if err != nil {
+		return err
+	}
+
+	member, err := s.groupRepo.GetMember(group.ID, userID)
+	if err != nil {
+		return errors.New("thành viên không tồn tại")
+	}
+	if member.Role == models.GroupRoleOwner {
+		if err := s.ensureAnotherOwner(group.ID); err != nil {
+			return err
+		}
+	}
+
+	return s.groupRepo.RemoveMember(group.ID, userID)
+}
+
+// GetStoryChapters - Tất cả chapter (kể cả bản nháp) của truyện nhóm phụ trách
+func (s *groupService) GetStoryChapters(slug string, actorID, storyID uuid.UUID) ([]models.Chapter, error) {
+	group, err := s.authorize(slug, actorID, models.GroupRoleUploader)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkStory(group, storyID); err != nil {
+		return nil, err
+	}
+	return s.chapterService.GetChaptersByStoryAdmin(storyID)
+}
+
+// CreateChapter - Tạo chapter cho truyện của nhóm (uploader trở lên), ghi công cho nhóm
+func (s *groupService) CreateChapter(slug string, actorID, storyID uuid.UUID, chapter *models.Chapter) error {
+	group, err := s.authorize(slug, actorID, models.GroupRoleUploader)
+	if err != nil {
+		return err
+	}
+	if err := s.checkStory(group, storyID); err != nil {
+		return err
+	}
+
+	chapter.GroupID = &group.ID
+	return s.chapterService.CreateChapter(storyID, chapter)
+}
+
+// UpdateChapter - Sửa chapter của truyện nhóm phụ trách (uploader trở lên)
+func (s *groupService) UpdateChapter(slug string, actorID, chapterID uuid.UUID, chapter *models.Chapter) error {
+	if _, err := s.authorizeChapter(slug, actorID, chapterID, models.GroupRoleUploader); err != nil {
+		return err
+	}
+	return s.chapterService.UpdateChapter(chapterID, chapter, &actorID)
+}
+
+// DeleteChapter - Xóa chapter (editor trở lên)
+func (s *groupService) DeleteChapter(slug string, actorID, chapterID uuid.UUID) error {
+	if _, err := s.authorizeChapter(slug, actorID, chapterID, models.GroupRoleEditor); err != nil {
+		return err
+	}
+	return s.chapterService.DeleteChapter(chapterID)
+}
+
+// PublishChapter - Xuất bản chapter (editor trở lên)
+func (s *groupService) PublishChapter(slug string, actorID, chapterID uuid.UUID) error {
+	if _, err := s.authorizeChapter(slug, actorID, chapterID, models.GroupRoleEditor); err != nil {
+		return err
+	}
+	return s.chapterService.PublishChapter(chapterID)
+}
+
+// ScheduleChapter - Hẹn giờ xuất bản chapter (editor trở lên)
+func (s *groupService) ScheduleChapter(slug string, actorID, chapterID uuid.UUID, scheduledAt time.Time) error {
+	if _, err := s.authorizeChapter(slug, actorID, chapterID, models.GroupRoleEditor); err != nil {
+		return err
+	}
+	return s.chapterService.ScheduleChapter(chapterID, scheduledAt)
+}
+
+// CreateGroup - Tạo nhóm mới (Admin), slug tự sinh từ tên
+func (s *groupService) CreateGroup(ownerID uuid.UUID, input GroupInput) (*models.Group, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, errors.New("tên nhóm không được để trống")
+	}
+	if _, err := s.userRepo.FindUserByID(ownerID); err != nil {
+		return nil, errors.New("người dùng không tồn tại")
+	}
+
+	groupSlug, err := s.uniqueSlug(name)
+	if err != nil {
+		return nil, err
+	}
+
+	group := &models.Group{
+		Name:        name,
+		Slug:        groupSlug,
+		Description: input.Description,
+		AvatarURL:   input.AvatarURL,
+		Website:     input.Website,
+	}
+	if err := s.groupRepo.CreateGroup(group, ownerID); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+// DeleteGroup - Xóa nhóm (Admin), các truyện của nhóm trở về không có nhóm
+func (s *groupService) DeleteGroup(id uuid.UUID) error {
+	if _, err := s.groupRepo.FindGroupByID(id); err != nil {
+		return errors.New("không tìm thấy nhóm dịch")
+	}
+	return s.groupRepo.DeleteGroup(id)
+}
+
+// AssignStory - Giao truyện cho nhóm (Admin), nil để gỡ
+func (s *groupService) AssignStory(storyID uuid.UUID, groupID *uuid.UUID) error {
+	if _, err := s.storyRepo.FindStoryByID(storyID); err != nil {
+		return errors.New("truyện không tồn tại")
+	}
+	if groupID != nil {
+		if _, err := s.groupRepo.FindGroupByID(*groupID); err != nil {
+			return errors.New("không tìm thấy nhóm dịch")
+		}
+	}
+	return s.groupRepo.AssignStory(storyID, groupID)
+}
+
+// authorize - Lấy nhóm theo slug và kiểm tra actor có role >= minRole
+func (s *groupService) authorize(slug string, actorID uuid.UUID, minRole string) (*models.Group, error) {
+	group, err := s.groupRepo.FindGroupBySlug(slug)
+	if err != nil {
+		return nil, errors.New("không tìm thấy nhóm dịch")
+	}
+
+	member, err := s.groupRepo.GetMember(group.ID, actorID)
+	if err != nil || !models.GroupRoleAtLeast(member.Role, minRole) {
+		return nil, ErrGroupForbidden
+	}
+	return group, nil
+}
+
+// authorizeChapter - Giống authorize, thêm kiểm tra chapter thuộc truyện nhóm phụ trách
+func (s *groupService) authorizeChapter(slug string, actorID, chapterID uuid.UUID, minRole string) (*models.Group, error) {
+	group, err := s.authorize(slug, actorID, minRole)
+	if err != nil {
+		return nil, err
+	}
+
+	chapter, err := s.chapterService.GetChapterByID(chapterID)
+	if err != nil {
+		return nil, err
+	}
+	if chapter.Story.GroupID == nil || *chapter.Story.GroupID != group.ID {
+		return nil, ErrGroupForbidden
+	}
+	return group, nil
+}
+
+// checkStory - Truyện phải được giao cho nhóm thì thành viên mới được quản lý chapter
+func (s *groupService) checkStory(group *models.Group, storyID uuid.UUID) error {
+	story, err := s.storyRepo.FindStoryByID(storyID)
+	if err != nil {
+		return errors.New("truyện không tồn tại")
+	}
+	if story.GroupID == nil || *story.GroupID != group.ID {
+		return ErrGroupForbidden
+	}
+	return nil
+}
+
+// ensureAnotherOwner - Nhóm phải còn ít nhất một owner khác
+func (s *groupService) ensureAnotherOwner(groupID uuid.UUID) error {
+	owners, err := s.groupRepo.CountOwners(groupID)
+	if err != nil {
+		return err
+	}
+	if owners <= 1 {
+		return errors.New("nhóm phải có ít nhất một owner")
+	}
+	return nil
+}
+
+func (s *groupService) uniqueSlug(name string) (string, error) {
+	base := slug.Make(name)
+	if base == "" {
+		base = "group"
+	}
+
+	candidate := base
+	for i := 2; i < 1000; i++ {
+		taken, err := s.groupRepo.IsSlugTaken(candidate)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s-%d", base, i)
+	}
+	return "", errors.New("không thể tạo slug cho nhóm")
+}
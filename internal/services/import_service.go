@@ -0,0 +1,768 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"nekozanedex/internal/models"
+	"nekozanedex/internal/repositories"
+	"nekozanedex/internal/utils"
+	"nekozanedex/pkg/epub"
+	pkgutils "nekozanedex/pkg/utils"
+
+	"github.com/google/uuid"
+	"github.com/gosimple/slug"
+)
+
+const (
+	importExcerptLen  = 200
+	importTitleMaxLen = 255
+
+	ArchiveMaxUploadSize     = 500 * 1024 * 1024 // File upload CBZ/ZIP
+	archiveMaxPageSize       = 30 * 1024 * 1024
+	archiveMaxInnerSize      = 200 * 1024 * 1024  // Mỗi CBZ lồng bên trong
+	archiveMaxTotalSize      = 1024 * 1024 * 1024 // Tổng dung lượng giải nén (trang + CBZ lồng)
+	archiveMaxEntries        = 5000               // Tổng số file, kể cả file bị bỏ qua
+	archiveMaxPages          = 2000
+	archiveUploadWorkers     = 4
+	archiveMaxConcurrentJobs = 2
+	importJobTTL             = 24 * time.Hour
+)
+
+var (
+	// ErrImportBusy - Đã đủ số job import archive chạy cùng lúc
+	ErrImportBusy = errors.New("đang có quá nhiều job import, vui lòng thử lại sau")
+	// ErrImportFailed - Lỗi phía server khi ghi dữ liệu import (không phải do file)
+	ErrImportFailed = errors.New("import thất bại")
+)
+
+// Trạng thái job import
+const (
+	ImportJobPending   = "pending"
+	ImportJobRunning   = "running"
+	ImportJobCompleted = "completed" // Mọi chapter đã được tạo
+	ImportJobPartial   = "partial"   // Một số chapter lỗi (thiếu trang), các chapter còn lại đã được tạo
+	ImportJobFailed    = "failed"    // Không tạo được chapter nào
+)
+
+// ImportChapterPreview - Chapter sẽ được tạo khi import
+type ImportChapterPreview struct {
+	Title      string   `json:"title"`
+	Source     []string `json:"source"` // File trong EPUB
+	Paragraphs int      `json:"paragraphs"`
+	Characters int      `json:"characters"`
+	Excerpt    string   `json:"excerpt"`
+}
+
+// EPUBImportResult - Kết quả import (hoặc xem trước khi DryRun)
+type EPUBImportResult struct {
+	DryRun          bool                   `json:"dry_run"`
+	Story           *models.Story          `json:"story"` // Truyện đích, hoặc truyện sẽ tạo từ metadata
+	StoryCreated    bool                   `json:"story_created"`
+	Genres          []string               `json:"genres"`           // Thể loại khớp với dc:subject
+	UnknownSubjects []string               `json:"unknown_subjects"` // dc:subject không khớp thể loại nào
+	HasCover        bool                   `json:"has_cover"`
+	Chapters        []ImportChapterPreview `json:"chapters"`
+	ChapterIDs      []uuid.UUID            `json:"chapter_ids,omitempty"` // Chapter đã tạo (không có khi DryRun)
+	Warnings        []string               `json:"warnings"`
+}
+
+// ImportFileError - Lỗi của một file trong archive
+type ImportFileError struct {
+	File  string `json:"file"`
+	Error string `json:"error"`
+}
+
+// ImportJobChapter - Tiến độ của một chapter trong archive
+type ImportJobChapter struct {
+	Title        string     `json:"title"`
+	Pages        int        `json:"pages"`
+	Uploaded     int        `json:"uploaded"`
+	ChapterID    *uuid.UUID `json:"chapter_id,omitempty"`    // Có khi chapter đã được tạo
+	MissingPages []int      `json:"missing_pages,omitempty"` // Trang (từ 1) lỗi, chapter không được tạo
+	Error        string     `json:"error,omitempty"`
+}
+
+// ImportJob - Job import CBZ/ZIP chạy nền, client poll để xem tiến độ
+type ImportJob struct {
+	ID             uuid.UUID          `json:"id"`
+	StoryID        uuid.UUID          `json:"story_id"`
+	CreatedBy      uuid.UUID          `json:"created_by"` // Chỉ người tạo (hoặc admin "*") xem được job
+	Filename       string             `json:"filename"`
+	Status         string             `json:"status"`
+	TotalFiles     int                `json:"total_files"`
+	ProcessedFiles int                `json:"processed_files"`
+	Progress       int                `json:"progress"` // Phần trăm
+	Chapters       []ImportJobChapter `json:"chapters"`
+	Errors         []ImportFileError  `json:"errors"`
+	Error          string             `json:"error,omitempty"`
+	CreatedAt      time.Time          `json:"created_at"`
+	FinishedAt     *time.Time         `json:"finished_at,omitempty"`
+}
+
+type ImportService interface {
+	// storyID nil: tạo truyện mới (nháp) từ metadata EPUB; dryRun: chỉ trả preview, không ghi DB
+	ImportEPUB(data []byte, storyID *uuid.UUID, dryRun bool) (*EPUBImportResult, error)
+
+	// CBZ/ZIP: một chapter, hoặc nhiều chapter dạng thư mục / file .cbz lồng bên trong (một cấp)
+	// file được chép ra file tạm nên có thể là multipart.File; title: tiêu đề khi archive chỉ có một chapter (rỗng = tên file)
+	StartArchiveImport(storyID, userID uuid.UUID, file io.Reader, filename, title string) (*ImportJob, error)
+	// viewAll: người xem có quyền "*", xem được job của người khác
+	GetImportJob(id, userID uuid.UUID, viewAll bool) (*ImportJob, error)
+}
+
+type importService struct {
+	storyRepo      repositories.StoryRepository
+	genreRepo      repositories.GenreRepository
+	storyService   StoryService
+	chapterService ChapterService
+	uploadService  UploadService // nil khi chưa cấu hình Cloudinary (bỏ qua ảnh bìa)
+	jobs           sync.Map      // uuid -> *importJob
+	archiveSlots   chan struct{} // Giới hạn số job archive chạy cùng lúc
+}
+
+// importJob - ImportJob kèm khóa, chỉ đọc ra ngoài qua snapshot
+type importJob struct {
+	mu    sync.Mutex
+	state ImportJob
+}
+
+// archivePage - Một trang ảnh trong archive, đọc khi upload
+type archivePage struct {
+	name string // Đường dẫn hiển thị trong lỗi (kèm tên CBZ lồng nếu có)
+	file *zip.File
+}
+
+type archiveChapter struct {
+	title string
+	pages []archivePage
+}
+
+// importArchive - Archive upload và các CBZ lồng, đều nằm trên đĩa tới khi job xong
+type importArchive struct {
+	files    []*os.File
+	chapters []archiveChapter
+	entries  int
+	pages    int
+	size     uint64 // Tổng dung lượng giải nén đã tính
+}
+
+func NewImportService(
+	storyRepo repositories.StoryRepository,
+	genreRepo repositories.GenreRepository,
+	storyService StoryService,
+	chapterService ChapterService,
+	uploadService UploadService,
+) ImportService {
+	s := &importService{
+		storyRepo:      storyRepo,
+		genreRepo:      genreRepo,
+		storyService:   storyService,
+		chapterService: chapterService,
+		uploadService:  uploadService,
+		archiveSlots:   make(chan struct{}, archiveMaxConcurrentJobs),
+	}
+	go s.cleanupJobs()
+	return s
+}
+
+// ImportEPUB - Đọc spine thành chapters (nháp), thêm vào truyện có sẵn hoặc truyện mới
+func (s *importService) ImportEPUB(data []byte, storyID *uuid.UUID, dryRun bool) (*EPUBImportResult, error) {
+	doc, err := epub.Read(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &EPUBImportResult{
+		DryRun:          dryRun,
+		HasCover:        doc.Cover != nil,
+		Genres:          []string{},
+		UnknownSubjects: []string{},
+		Warnings:        []string{},
+	}
+
+	var genreIDs []uuid.UUID
+	if storyID != nil {
+		result.Story, err = s.storyRepo.FindStoryByID(*storyID)
+		if err != nil {
+			return nil, errors.New("truyện không tồn tại")
+		}
+	} else {
+		result.Story, err = storyFromEPUB(doc.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		genreIDs, err = s.matchGenres(doc.Metadata.Subjects, result)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrImportFailed, err)
+		}
+	}
+
+	chapters := make([]models.Chapter, 0, len(doc.Chapters))
+	for _, parsed := range doc.Chapters {
+		title := truncateRunes(parsed.Title, importTitleMaxLen)
+		if strings.TrimSpace(parsed.Text) == "" {
+			result.Warnings = append(result.Warnings, "Chapter \""+title+"\" không có nội dung chữ")
+		}
+		chapters = append(chapters, models.Chapter{Title: title, Content: parsed.Text})
+		result.Chapters = append(result.Chapters, ImportChapterPreview{
+			Title:      title,
+			Source:     parsed.Files,
+			Paragraphs: strings.Count(parsed.Text, "\n") + 1,
+			Characters: utf8.RuneCountInString(parsed.Text),
+			Excerpt:    truncateRunes(parsed.Text, importExcerptLen),
+		})
+	}
+
+	if dryRun {
+		return result, nil
+	}
+
+	if storyID == nil {
+		if err := s.storyService.CreateStory(result.Story); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrImportFailed, err)
+		}
+		result.StoryCreated = true
+	}
+	// Chapters được tạo trong một transaction, lỗi thì xóa hẳn truyện vừa tạo để thử lại không bị trùng truyện
+	if err := s.chapterService.BulkImportChapters(result.Story.ID, chapters); err != nil {
+		if result.StoryCreated {
+			if purgeErr := s.storyRepo.PurgeStory(result.Story.ID); purgeErr != nil {
+				log.Printf("Không xóa được truyện import dở %s: %v", result.Story.ID, purgeErr)
+			}
+		}
+		return nil, fmt.Errorf("%w: %v", ErrImportFailed, err)
+	}
+	for _, chapter := range chapters {
+		result.ChapterIDs = append(result.ChapterIDs, chapter.ID)
+	}
+	// Đọc lại để có total_chapters mới (tránh ghi đè khi lưu ảnh bìa)
+	if story, err := s.storyRepo.FindStoryByID(result.Story.ID); err == nil {
+		result.Story = story
+	}
+
+	if result.StoryCreated {
+		s.finishStory(result, doc.Cover, genreIDs)
+	}
+	return result, nil
+}
+
+// finishStory - Gắn thể loại và upload ảnh bìa cho truyện mới (lỗi chỉ là cảnh báo)
+func (s *importService) finishStory(result *EPUBImportResult, cover *epub.Image, genreIDs []uuid.UUID) {
+	story := result.Story
+	if len(genreIDs) > 0 {
+		if err := s.storyRepo.UpdateStoryGenres(story.ID, genreIDs); err != nil {
+			result.Warnings = append(result.Warnings, "Không gắn được thể loại: "+err.Error())
+		}
+	}
+
+	if cover == nil {
+		return
+	}
+	if s.uploadService == nil {
+		result.Warnings = append(result.Warnings, "Upload service chưa được cấu hình, bỏ qua ảnh bìa")
+		return
+	}
+	url, err := s.uploadService.UploadImageBytes(cover.Data, "cover"+coverExtension(cover.MediaType), "manga/"+story.Slug)
+	if err != nil {
+		result.Warnings = append(result.Warnings, "Không upload được ảnh bìa: "+err.Error())
+		return
+	}
+	story.CoverImageURL = &url
+	if err := s.storyRepo.UpdateStory(story); err != nil {
+		result.Warnings = append(result.Warnings, "Không lưu được ảnh bìa: "+err.Error())
+	}
+}
+
+// matchGenres - Khớp dc:subject với thể loại có sẵn theo slug hoặc tên
+func (s *importService) matchGenres(subjects []string, result *EPUBImportResult) ([]uuid.UUID, error) {
+	if len(subjects) == 0 {
+		return nil, nil
+	}
+	genres, err := s.genreRepo.GetAllGenres()
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []uuid.UUID
+	seen := make(map[uuid.UUID]bool)
+	for _, subject := range subjects {
+		matched := false
+		for _, genre := range genres {
+			if genre.Slug == slug.Make(subject) || strings.EqualFold(genre.Name, subject) {
+				matched = true
+				if !seen[genre.ID] {
+					seen[genre.ID] = true
+					ids = append(ids, genre.ID)
+					result.Genres = append(result.Genres, genre.Name)
+				}
+				break
+			}
+		}
+		if !matched {
+			result.UnknownSubjects = append(result.UnknownSubjects, subject)
+		}
+	}
+	return ids, nil
+}
+
+// storyFromEPUB - Truyện nháp từ metadata EPUB
+func storyFromEPUB(meta epub.Metadata) (*models.Story, error) {
+	if strings.TrimSpace(meta.Title) == "" {
+		return nil, errors.New("EPUB không có tiêu đề, hãy chọn truyện có sẵn để import")
+	}
+
+	story := &models.Story{
+		Title:       truncateRunes(meta.Title, 255),
+		Status:      "ongoing",
+		IsPublished: false,
+	}
+	if len(meta.AltTitles) > 0 {
+		if err := story.SetAltTitles(meta.AltTitles); err != nil {
+			return nil, err
+		}
+	}
+	if len(meta.Authors) > 0 {
+		author := truncateRunes(strings.Join(meta.Authors, ", "), 100)
+		story.AuthorName = &author
+	}
+	if len(meta.Translators) > 0 {
+		translator := truncateRunes(strings.Join(meta.Translators, ", "), 100)
+		story.Translator = &translator
+	}
+	if meta.Description != "" {
+		description := meta.Description
+		story.Description = &description
+	}
+	return story, nil
+}
+
+func coverExtension(mediaType string) string {
+	switch mediaType {
+	case "image/png":
+		return ".png"
+	case "image/gif":
+		return ".gif"
+	case "image/webp":
+		return ".webp"
+	}
+	return ".jpg"
+}
+
+// truncateRunes - Cắt chuỗi theo số ký tự (không cắt giữa ký tự UTF-8)
+func truncateRunes(s string, limit int) string {
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	return string([]rune(s)[:limit])
+}
+
+// StartArchiveImport - Chép archive ra file tạm, kiểm tra giới hạn rồi upload + tạo chapter (nháp) trong goroutine
+func (s *importService) StartArchiveImport(storyID, userID uuid.UUID, file io.Reader, filename, title string) (*ImportJob, error) {
+	if s.uploadService == nil {
+		return nil, errors.New("upload service chưa được cấu hình")
+	}
+	story, err := s.storyRepo.FindStoryByID(storyID)
+	if err != nil {
+		return nil, errors.New("truyện không tồn tại")
+	}
+
+	select {
+	case s.archiveSlots <- struct{}{}:
+	default:
+		return nil, ErrImportBusy
+	}
+	release := func() { <-s.archiveSlots }
+
+	defaultTitle := strings.TrimSpace(title)
+	if defaultTitle == "" {
+		defaultTitle = strings.TrimSuffix(path.Base(filename), path.Ext(filename))
+	}
+	archive, err := openImportArchive(file, defaultTitle)
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	job := &importJob{state: ImportJob{
+		ID:        uuid.New(),
+		StoryID:   storyID,
+		CreatedBy: userID,
+		Filename:  filename,
+		Status:    ImportJobPending,
+		Errors:    []ImportFileError{},
+		CreatedAt: time.Now(),
+	}}
+	for _, chapter := range archive.chapters {
+		job.state.TotalFiles += len(chapter.pages)
+		job.state.Chapters = append(job.state.Chapters, ImportJobChapter{Title: chapter.title, Pages: len(chapter.pages)})
+	}
+	s.jobs.Store(job.state.ID, job)
+
+	go func() {
+		defer release()
+		defer archive.close()
+		s.runArchiveImport(job, story, archive.chapters)
+	}()
+	return job.snapshot(), nil
+}
+
+// GetImportJob - Tiến độ job import, job của người khác được báo như không tồn tại
+func (s *importService) GetImportJob(id, userID uuid.UUID, viewAll bool) (*ImportJob, error) {
+	value, ok := s.jobs.Load(id)
+	if !ok {
+		return nil, errors.New("job import không tồn tại hoặc đã hết hạn")
+	}
+	job := value.(*importJob).snapshot()
+	if !viewAll && job.CreatedBy != userID {
+		return nil, errors.New("job import không tồn tại hoặc đã hết hạn")
+	}
+	return job, nil
+}
+
+// runArchiveImport - Chapter chỉ được tạo khi mọi trang upload thành công, thiếu trang thì báo lỗi kèm số trang
+func (s *importService) runArchiveImport(job *importJob, story *models.Story, chapters []archiveChapter) {
+	created := 0
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[ImportService] archive job %s panic: %v", job.state.ID, r)
+			job.finish(created, len(chapters), fmt.Sprintf("lỗi không mong muốn: %v", r))
+		}
+	}()
+	job.update(func(state *ImportJob) { state.Status = ImportJobRunning })
+
+	for ci, chapter := range chapters {
+		folder := fmt.Sprintf("manga/%s/imports/%s/%02d", story.Slug, job.state.ID.String()[:8], ci+1)
+		urls, missing := s.uploadPages(job, ci, chapter.pages, folder)
+		if len(missing) > 0 {
+			s.discardUploads(urls)
+			job.update(func(state *ImportJob) {
+				state.Chapters[ci].MissingPages = missing
+				state.Chapters[ci].Error = "thiếu trang " + joinInts(missing) + ", chapter không được tạo"
+			})
+			continue
+		}
+
+		imagesJSON, _ := json.Marshal(urls)
+		record := &models.Chapter{Title: truncateRunes(chapter.title, importTitleMaxLen), Images: imagesJSON}
+		if err := s.chapterService.CreateChapter(story.ID, record); err != nil {
+			s.discardUploads(urls)
+			job.update(func(state *ImportJob) { state.Chapters[ci].Error = err.Error() })
+			continue
+		}
+		created++
+		job.update(func(state *ImportJob) { state.Chapters[ci].ChapterID = &record.ID })
+	}
+
+	job.finish(created, len(chapters), "")
+}
+
+// uploadPages - Xử lý + upload các trang song song, giữ thứ tự; trả về URL đã upload và số trang (từ 1) bị lỗi
+func (s *importService) uploadPages(job *importJob, chapterIndex int, pages []archivePage, folder string) ([]string, []int) {
+	urls := make([]string, len(pages))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < archiveUploadWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				url, err := s.uploadPage(pages[i], i, folder)
+				job.update(func(state *ImportJob) {
+					state.ProcessedFiles++
+					if err != nil {
+						state.Errors = append(state.Errors, ImportFileError{File: pages[i].name, Error: err.Error()})
+						return
+					}
+					state.Chapters[chapterIndex].Uploaded++
+				})
+				urls[i] = url
+			}
+		}()
+	}
+	for i := range pages {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	var uploaded []string
+	var missing []int
+	for i, url := range urls {
+		if url == "" {
+			missing = append(missing, i+1)
+			continue
+		}
+		uploaded = append(uploaded, url)
+	}
+	return uploaded, missing
+}
+
+func (s *importService) uploadPage(page archivePage, index int, folder string) (string, error) {
+	data, err := readZipEntry(page.file, archiveMaxPageSize)
+	if err != nil {
+		return "", err
+	}
+	processed, err := pkgutils.ProcessChapterImageBytes(data, path.Base(page.name))
+	if err != nil {
+		return "", err
+	}
+	return s.uploadService.UploadImageBytes(processed.Data, fmt.Sprintf("%03d.jpg", index+1), folder)
+}
+
+// discardUploads - Xóa ảnh đã upload của chapter không được tạo
+func (s *importService) discardUploads(urls []string) {
+	for _, url := range urls {
+		if publicID := extractCloudinaryPublicID(url); publicID != "" {
+			if err := s.uploadService.DeleteImage(publicID); err != nil {
+				log.Printf("[ImportService] delete %s failed: %v", publicID, err)
+			}
+		}
+	}
+}
+
+// cleanupJobs - Xóa job đã xong quá importJobTTL (chạy background)
+func (s *importService) cleanupJobs() {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		cutoff := time.Now().Add(-importJobTTL)
+		s.jobs.Range(func(key, value interface{}) bool {
+			snapshot := value.(*importJob).snapshot()
+			if snapshot.FinishedAt != nil && snapshot.FinishedAt.Before(cutoff) {
+				s.jobs.Delete(key)
+			}
+			return true
+		})
+	}
+}
+
+func (j *importJob) update(fn func(state *ImportJob)) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	fn(&j.state)
+}
+
+// finish - Kết thúc job: completed khi tạo đủ chapter, partial khi thiếu, failed khi không tạo được chapter nào
+// Job dừng giữa chừng vì lỗi nhưng đã tạo được chapter vẫn là partial (kèm lỗi)
+func (j *importJob) finish(created, total int, errMessage string) {
+	j.update(func(state *ImportJob) {
+		now := time.Now()
+		state.FinishedAt = &now
+		state.Error = errMessage
+		switch {
+		case created == 0:
+			state.Status = ImportJobFailed
+			if state.Error == "" {
+				state.Error = "không tạo được chapter nào"
+			}
+		case created < total || errMessage != "":
+			state.Status = ImportJobPartial
+		default:
+			state.Status = ImportJobCompleted
+		}
+	})
+}
+
+func (j *importJob) snapshot() *ImportJob {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	snapshot := j.state
+	snapshot.Chapters = append([]ImportJobChapter(nil), j.state.Chapters...)
+	snapshot.Errors = append([]ImportFileError{}, j.state.Errors...)
+	if snapshot.TotalFiles > 0 {
+		snapshot.Progress = snapshot.ProcessedFiles * 100 / snapshot.TotalFiles
+	}
+	return &snapshot
+}
+
+// openImportArchive - Chép upload ra file tạm rồi đọc cấu trúc, kiểm tra giới hạn số file/trang/dung lượng trước khi tạo job
+func openImportArchive(file io.Reader, defaultTitle string) (*importArchive, error) {
+	archive := &importArchive{}
+	tmp, size, err := archive.spool(file, ArchiveMaxUploadSize)
+	if err != nil {
+		archive.close()
+		return nil, err
+	}
+
+	archive.chapters, err = archive.parse(tmp, size, defaultTitle, true)
+	if err != nil {
+		archive.close()
+		return nil, err
+	}
+	if len(archive.chapters) == 0 {
+		archive.close()
+		return nil, errors.New("archive không có ảnh nào (jpg, png, webp, gif)")
+	}
+	return archive, nil
+}
+
+// spool - Chép r ra file tạm (xóa khi close), lỗi nếu vượt limit
+func (a *importArchive) spool(r io.Reader, limit int64) (*os.File, int64, error) {
+	tmp, err := os.CreateTemp("", "nekozanedex-import-*.zip")
+	if err != nil {
+		return nil, 0, err
+	}
+	a.files = append(a.files, tmp)
+
+	size, err := io.Copy(tmp, io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, 0, err
+	}
+	if size > limit {
+		return nil, 0, fmt.Errorf("file quá lớn (tối đa %dMB)", limit/(1024*1024))
+	}
+	return tmp, size, nil
+}
+
+func (a *importArchive) close() {
+	for _, f := range a.files {
+		f.Close()
+		os.Remove(f.Name())
+	}
+	a.files = nil
+}
+
+// reserve - Cộng dung lượng giải nén (theo header zip, archive/zip báo lỗi nếu dữ liệu thật lớn hơn)
+func (a *importArchive) reserve(size uint64) error {
+	a.size += size
+	if a.size > archiveMaxTotalSize {
+		return fmt.Errorf("tổng dung lượng giải nén vượt quá %dMB", archiveMaxTotalSize/(1024*1024))
+	}
+	return nil
+}
+
+// parse - Gom ảnh theo thư mục (mỗi thư mục một chapter), file .cbz/.zip lồng (chỉ ở cấp ngoài cùng) cũng là một chapter
+// Trang và chapter được sắp theo thứ tự tên tự nhiên (page2 trước page10)
+func (a *importArchive) parse(r io.ReaderAt, size int64, defaultTitle string, allowNested bool) ([]archiveChapter, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, errors.New("file không phải CBZ/ZIP hợp lệ")
+	}
+	a.entries += len(zr.File)
+	if a.entries > archiveMaxEntries {
+		return nil, fmt.Errorf("archive có quá nhiều file (tối đa %d)", archiveMaxEntries)
+	}
+
+	groups := make(map[string][]archivePage)
+	var inner []archiveChapter
+	for _, f := range zr.File {
+		name := f.Name
+		base := path.Base(name)
+		if f.FileInfo().IsDir() || strings.HasPrefix(name, "__MACOSX/") || strings.HasPrefix(base, ".") {
+			continue
+		}
+
+		switch strings.ToLower(path.Ext(name)) {
+		case ".jpg", ".jpeg", ".png", ".webp", ".gif":
+			if f.UncompressedSize64 > archiveMaxPageSize {
+				return nil, fmt.Errorf("%s quá lớn (tối đa %dMB mỗi trang)", name, archiveMaxPageSize/(1024*1024))
+			}
+			if a.pages++; a.pages > archiveMaxPages {
+				return nil, fmt.Errorf("archive có quá nhiều trang (tối đa %d)", archiveMaxPages)
+			}
+			if err := a.reserve(f.UncompressedSize64); err != nil {
+				return nil, err
+			}
+			dir := path.Dir(name)
+			groups[dir] = append(groups[dir], archivePage{name: name, file: f})
+		case ".cbz", ".zip":
+			if !allowNested {
+				return nil, fmt.Errorf("%s: chỉ hỗ trợ CBZ lồng một cấp", name)
+			}
+			chapters, err := a.parseInner(f)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			inner = append(inner, chapters...)
+		}
+	}
+
+	var chapters []archiveChapter
+	for dir, pages := range groups {
+		title := path.Base(dir)
+		if defaultTitle != "" && (dir == "." || (len(groups) == 1 && len(inner) == 0)) {
+			title = defaultTitle // Archive một chapter: lấy theo tên file
+		}
+		sort.Slice(pages, func(i, j int) bool { return utils.NaturalLess(pages[i].name, pages[j].name) })
+		chapters = append(chapters, archiveChapter{title: title, pages: pages})
+	}
+	chapters = append(chapters, inner...)
+
+	sort.SliceStable(chapters, func(i, j int) bool {
+		return utils.NaturalLess(chapters[i].pages[0].name, chapters[j].pages[0].name)
+	})
+	return chapters, nil
+}
+
+// parseInner - Giải nén CBZ lồng ra file tạm (không giữ trong bộ nhớ) rồi đọc như một archive không cho lồng tiếp
+func (a *importArchive) parseInner(f *zip.File) ([]archiveChapter, error) {
+	if f.UncompressedSize64 > archiveMaxInnerSize {
+		return nil, fmt.Errorf("quá lớn (tối đa %dMB)", archiveMaxInnerSize/(1024*1024))
+	}
+	if err := a.reserve(f.UncompressedSize64); err != nil {
+		return nil, err
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	tmp, size, err := a.spool(rc, archiveMaxInnerSize)
+	if err != nil {
+		return nil, err
+	}
+
+	base := path.Base(f.Name)
+	chapters, err := a.parse(tmp, size, strings.TrimSuffix(base, path.Ext(base)), false)
+	if err != nil {
+		return nil, err
+	}
+	for _, chapter := range chapters {
+		for i := range chapter.pages {
+			chapter.pages[i].name = f.Name + "/" + chapter.pages[i].name
+		}
+	}
+	return chapters, nil
+}
+
+func readZipEntry(f *zip.File, limit int64) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%s quá lớn", f.Name)
+	}
+	return data, nil
+}
+
+func joinInts(values []int) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.Itoa(v)
+	}
+	return strings.Join(parts, ", ")
+}
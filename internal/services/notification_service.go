@@ -13,6 +13,7 @@ import (
 type NotificationService interface {
 	CreateNotification(userID uuid.UUID, notifType, title string, content, link *string) error
 	GetUserNotifications(userID uuid.UUID, page, limit int) ([]models.Notification, int64, error)
+	GetUserNotificationsByCursor(userID uuid.UUID, cursor string, limit int) ([]models.Notification, string, error)
 	MarkAsRead(notificationID uuid.UUID) error
 	MarkAllAsRead(userID uuid.UUID) error
 	GetUnreadCount(userID uuid.UUID) int64
@@ -77,6 +78,11 @@ func (s *notificationService) GetUserNotifications(userID uuid.UUID, page, limit
 	return s.notificationRepo.GetNotificationsByUser(userID, page, limit)
 }
 
+// GetUserNotificationsByCursor - Lấy notifications của user theo cursor
+func (s *notificationService) GetUserNotificationsByCursor(userID uuid.UUID, cursor string, limit int) ([]models.Notification, string, error) {
+	return s.notificationRepo.GetNotificationsByUserCursor(userID, cursor, limit)
+}
+
 // MarkAsRead - Đánh dấu đã đọc
 func (s *notificationService) MarkAsRead(notificationID uuid.UUID) error {
 	return s.notificationRepo.MarkNotificationAsRead(notificationID)
@@ -0,0 +1,329 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"nekozanedex/internal/models"
+	"nekozanedex/internal/repositories"
+
+	"github.com/google/uuid"
+	"github.com/gosimple/slug"
+)
+
+const creditMigrationBatch = 100
+
+// PersonProfile - Trang public của một người: thông tin + tác phẩm theo vai trò
+type PersonProfile struct {
+	Person *models.Person            `json:"person"`
+	Works  map[string][]models.Story `json:"works"` // role -> stories
+}
+
+// StoryPersonInput - Một liên kết truyện - người khi admin gán thủ công
+type StoryPersonInput struct {
+	PersonID uuid.UUID `json:"person_id" binding:"required"`
+	Role     string    `json:"role" binding:"required"`
+}
+
+// CreditMigrationResult - Kết quả chuyển author/artist/translator dạng chuỗi sang Person
+type CreditMigrationResult struct {
+	StoriesScanned int `json:"stories_scanned"`
+	PeopleCreated  int `json:"people_created"`
+	LinksCreated   int `json:"links_created"`
+}
+
+type PersonService interface {
+	// Public
+	GetPersonProfile(slug string, showMature bool) (*PersonProfile, error)
+
+	// Admin
+	GetAllPeople(query string, page, limit int) ([]models.Person, int64, error)
+	GetPersonByID(id uuid.UUID) (*models.Person, error)
+	CreatePerson(name string, aliases []string, bio, avatarURL *string) (*models.Person, error)
+	UpdatePerson(id uuid.UUID, name string, aliases []string, bio, avatarURL *string) (*models.Person, error)
+	DeletePerson(id uuid.UUID) error
+	MergePeople(targetID, sourceID uuid.UUID) (*models.Person, error)
+	SetStoryPeople(storyID uuid.UUID, inputs []StoryPersonInput) error
+	MigrateStoryCredits() (*CreditMigrationResult, error)
+}
+
+type personService struct {
+	personRepo repositories.PersonRepository
+	storyRepo  repositories.StoryRepository
+}
+
+func NewPersonService(personRepo repositories.PersonRepository, storyRepo repositories.StoryRepository) PersonService {
+	return &personService{
+		personRepo: personRepo,
+		storyRepo:  storyRepo,
+	}
+}
+
+// GetPersonProfile - Lấy thông tin người + các truyện đã publish (Public)
+func (s *personService) GetPersonProfile(slug string, showMature bool) (*PersonProfile, error) {
+	person, err := s.personRepo.FindPersonBySlug(slug)
+	if err != nil {
+		return nil, errors.New("không tìm thấy tác giả")
+	}
+
+	works, err := s.personRepo.GetPersonWorks(person.ID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := &PersonProfile{Person: person, Works: make(map[string][]models.Story)}
+	for _, work := range works {
+		if work.Story != nil && checkContentAccess(work.Story, showMature) == nil {
+			profile.Works[work.Role] = append(profile.Works[work.Role], *work.Story)
+		}
+	}
+	return profile, nil
+}
+
+// GetAllPeople - Danh sách người (Admin)
+func (s *personService) GetAllPeople(query string, page, limit int) ([]models.Person, int64, error) {
+	return s.personRepo.GetAllPeople(query, page, limit)
+}
+
+// GetPersonByID - Lấy người theo ID (Admin)
+func (s *personService) GetPersonByID(id uuid.UUID) (*models.Person, error) {
+	person, err := s.personRepo.FindPersonByID(id)
+	if err != nil {
+		return nil, errors.New("không tìm thấy tác giả")
+	}
+	return person, nil
+}
+
+// CreatePerson - Tạo người mới, slug tự sinh từ tên
+func (s *personService) CreatePerson(name string, aliases []string, bio, avatarURL *string) (*models.Person, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("tên không được để trống")
+	}
+
+	personSlug, err := s.uniqueSlug(name)
+	if err != nil {
+		return nil, err
+	}
+
+	person := &models.Person{
+		Name:      name,
+		Slug:      personSlug,
+		Bio:       bio,
+		AvatarURL: avatarURL,
+	}
+	if err := person.SetAliases(normalizeAliases(name, aliases)); err != nil {
+		return nil, err
+	}
+
+	if err := s.personRepo.CreatePerson(person); err != nil {
+		return nil, err
+	}
+	return person, nil
+}
+
+// UpdatePerson - Cập nhật người, giữ nguyên slug để link cũ không bị hỏng
+func (s *personService) UpdatePerson(id uuid.UUID, name string, aliases []string, bio, avatarURL *string) (*models.Person, error) {
+	person, err := s.personRepo.FindPersonByID(id)
+	if err != nil {
+		return nil, errors.New("không tìm thấy tác giả")
+	}
+
+	if name = strings.TrimSpace(name); name != "" {
+		person.Name = name
+	}
+	if aliases != nil {
+		if err := person.SetAliases(normalizeAliases(person.Name, aliases)); err != nil {
+			return nil, err
+		}
+	}
+	if bio != nil {
+		person.Bio = bio
+	}
+	if avatarURL != nil {
+		person.AvatarURL = avatarURL
+	}
+
+	if err := s.personRepo.UpdatePerson(person); err != nil {
+		return nil, err
+	}
+	return person, nil
+}
+
+// DeletePerson - Xóa người (Admin)
+func (s *personService) DeletePerson(id uuid.UUID) error {
+	return s.personRepo.DeletePerson(id)
+}
+
+// MergePeople - Gộp source vào target, tên và alias của source thành alias của target
+func (s *personService) MergePeople(targetID, sourceID uuid.UUID) (*models.Person, error) {
+	if targetID == sourceID {
+		return nil, errors.New("không thể gộp một người với chính họ")
+	}
+
+	target, err := s.personRepo.FindPersonByID(targetID)
+	if err != nil {
+		return nil, errors.New("không tìm thấy tác giả đích")
+	}
+	source, err := s.personRepo.FindPersonByID(sourceID)
+	if err != nil {
+		return nil, errors.New("không tìm thấy tác giả nguồn")
+	}
+
+	aliases := append(target.GetAliases(), source.Name)
+	aliases = append(aliases, source.GetAliases()...)
+	if err := target.SetAliases(normalizeAliases(target.Name, aliases)); err != nil {
+		return nil, err
+	}
+	if target.Bio == nil {
+		target.Bio = source.Bio
+	}
+	if target.AvatarURL == nil {
+		target.AvatarURL = source.AvatarURL
+	}
+
+	// Chuyển liên kết, xóa source và lưu target trong một transaction để không mất tên source
+	if err := s.personRepo.MergePeople(target, source.ID); err != nil {
+		return nil, err
+	}
+	return target, nil
+}
+
+// SetStoryPeople - Gán lại toàn bộ người tham gia của truyện (Admin)
+func (s *personService) SetStoryPeople(storyID uuid.UUID, inputs []StoryPersonInput) error {
+	if _, err := s.storyRepo.FindStoryByID(storyID); err != nil {
+		return errors.New("truyện không tồn tại")
+	}
+
+	links := make([]models.StoryPerson, 0, len(inputs))
+	for _, input := range inputs {
+		if !models.IsValidPersonRole(input.Role) {
+			return fmt.Errorf("vai trò không hợp lệ: %s", input.Role)
+		}
+		if _, err := s.personRepo.FindPersonByID(input.PersonID); err != nil {
+			return fmt.Errorf("không tìm thấy tác giả: %s", input.PersonID)
+		}
+		links = append(links, models.StoryPerson{StoryID: storyID, PersonID: input.PersonID, Role: input.Role})
+	}
+
+	return s.personRepo.ReplaceStoryPeople(storyID, links)
+}
+
+// MigrateStoryCredits - Chuyển AuthorName/ArtistName/Translator của mọi truyện sang Person
+// Tên trùng (không phân biệt hoa thường, dấu, hoặc khớp alias) dùng chung một Person
+// Chạy lại nhiều lần an toàn: liên kết đã có được bỏ qua
+func (s *personService) MigrateStoryCredits() (*CreditMigrationResult, error) {
+	result := &CreditMigrationResult{}
+	cache := make(map[string]*models.Person)
+
+	for page := 1; ; page++ {
+		stories, _, err := s.storyRepo.GetAllStories(page, creditMigrationBatch, false, true)
+		if err != nil {
+			return result, err
+		}
+
+		for _, story := range stories {
+			result.StoriesScanned++
+			credits := map[string]*string{
+				models.PersonRoleAuthor:     story.AuthorName,
+				models.PersonRoleArtist:     story.ArtistName,
+				models.PersonRoleTranslator: story.Translator,
+			}
+			for role, value := range credits {
+				if value == nil {
+					continue
+				}
+				for _, name := range splitCredits(*value) {
+					person, created, err := s.findOrCreatePerson(name, cache)
+					if err != nil {
+						return result, err
+					}
+					if created {
+						result.PeopleCreated++
+					}
+
+					linked, err := s.personRepo.LinkStoryPerson(&models.StoryPerson{StoryID: story.ID, PersonID: person.ID, Role: role})
+					if err != nil {
+						return result, err
+					}
+					if linked {
+						result.LinksCreated++
+					}
+				}
+			}
+		}
+
+		if len(stories) < creditMigrationBatch {
+			return result, nil
+		}
+	}
+}
+
+// findOrCreatePerson - Tìm người theo tên/alias, chưa có thì tạo mới
+func (s *personService) findOrCreatePerson(name string, cache map[string]*models.Person) (*models.Person, bool, error) {
+	key := strings.ToLower(name)
+	if person, ok := cache[key]; ok {
+		return person, false, nil
+	}
+
+	if person, err := s.personRepo.FindPersonByName(name); err == nil {
+		cache[key] = person
+		return person, false, nil
+	}
+
+	person, err := s.CreatePerson(name, nil, nil, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	cache[key] = person
+	return person, true, nil
+}
+
+// uniqueSlug - Slug từ tên, thêm hậu tố -2, -3... nếu đã tồn tại
+func (s *personService) uniqueSlug(name string) (string, error) {
+	base := slug.Make(name)
+	if base == "" {
+		base = "person"
+	}
+
+	candidate := base
+	for i := 2; i < 1000; i++ {
+		if _, err := s.personRepo.FindPersonBySlug(candidate); err != nil {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s-%d", base, i)
+	}
+	return "", errors.New("không thể tạo slug cho tác giả")
+}
+
+// splitCredits - Tách chuỗi nhiều người ("A, B & C") thành từng tên
+func splitCredits(value string) []string {
+	parts := strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ';' || r == '&' || r == '/'
+	})
+
+	names := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if name := strings.TrimSpace(part); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// normalizeAliases - Bỏ alias rỗng, trùng nhau hoặc trùng tên chính
+func normalizeAliases(name string, aliases []string) []string {
+	seen := map[string]bool{strings.ToLower(name): true}
+	result := make([]string, 0, len(aliases))
+	for _, alias := range aliases {
+		alias = strings.TrimSpace(alias)
+		key := strings.ToLower(alias)
+		if alias == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, alias)
+	}
+	return result
+}
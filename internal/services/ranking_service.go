@@ -0,0 +1,109 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"nekozanedex/internal/models"
+	"nekozanedex/internal/repositories"
+)
+
+// rankingSnapshotSize - Số truyện lưu trong mỗi snapshot
+const rankingSnapshotSize = 100
+
+// rankingPeriodDurations - Độ dài cửa sổ của từng chu kỳ (all_time không giới hạn)
+var rankingPeriodDurations = map[string]time.Duration{
+	models.RankingPeriodDaily:   24 * time.Hour,
+	models.RankingPeriodWeekly:  7 * 24 * time.Hour,
+	models.RankingPeriodMonthly: 30 * 24 * time.Hour,
+}
+
+type RankingService interface {
+	GetRankings(rankingType, period string, date *time.Time, limit int, showMature bool) ([]models.StoryRanking, error)
+
+	// Scheduler methods
+	SnapshotRankings() error
+}
+
+type rankingService struct {
+	rankingRepo repositories.RankingRepository
+}
+
+func NewRankingService(rankingRepo repositories.RankingRepository) RankingService {
+	return &rankingService{rankingRepo: rankingRepo}
+}
+
+// GetRankings - Lấy bảng xếp hạng (snapshot mới nhất, hoặc snapshot của ngày date nếu có)
+func (s *rankingService) GetRankings(rankingType, period string, date *time.Time, limit int, showMature bool) ([]models.StoryRanking, error) {
+	if !models.IsValidRanking(rankingType, period) {
+		return nil, errors.New("loại hoặc chu kỳ bảng xếp hạng không hợp lệ")
+	}
+	if limit < 1 || limit > rankingSnapshotSize {
+		limit = 50
+	}
+
+	onOrBefore := truncateToDate(time.Now())
+	if date != nil {
+		onOrBefore = truncateToDate(*date)
+	}
+
+	return s.rankingRepo.GetSnapshot(rankingType, period, onOrBefore, limit, showMature)
+}
+
+// SnapshotRankings - Chụp snapshot tất cả bảng xếp hạng cho ngày hôm nay
+// Chạy lại trong ngày sẽ ghi đè snapshot hôm nay; snapshot cuối ngày trở thành lịch sử
+func (s *rankingService) SnapshotRankings() error {
+	now := time.Now()
+	today := truncateToDate(now)
+
+	for _, rankingType := range models.RankingTypes {
+		for _, period := range models.RankingPeriods {
+			var since *time.Time
+			if duration, ok := rankingPeriodDurations[period]; ok {
+				start := now.Add(-duration)
+				since = &start
+			}
+
+			entries, err := s.rankingRepo.ComputeRanking(rankingType, since, rankingSnapshotSize)
+			if err != nil {
+				return err
+			}
+
+			previousRanks, err := s.rankingRepo.GetPreviousRanks(rankingType, period, today)
+			if err != nil {
+				return err
+			}
+
+			rankings := make([]models.StoryRanking, len(entries))
+			for i, entry := range entries {
+				rank := i + 1
+				rankings[i] = models.StoryRanking{
+					RankingType:  rankingType,
+					Period:       period,
+					SnapshotDate: today,
+					Rank:         rank,
+					StoryID:      entry.StoryID,
+					Score:        entry.Score,
+				}
+				if previousRank, ok := previousRanks[entry.StoryID]; ok {
+					rankings[i].PreviousRank = &previousRank
+					rankings[i].Movement = previousRank - rank
+				} else {
+					rankings[i].IsNew = true
+				}
+			}
+
+			if err := s.rankingRepo.ReplaceSnapshot(rankingType, period, today, rankings); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// truncateToDate - Cắt về 00:00 theo múi giờ local
+func truncateToDate(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"errors"
+	"math"
+
+	"nekozanedex/internal/models"
+	"nekozanedex/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+const (
+	similarNeighbours   = 20 // Số truyện tương tự lưu cho mỗi truyện
+	similarMinCoReaders = 2  // Tối thiểu số người đọc chung để tính đồng đọc
+)
+
+// similarityWeights - Đồng đọc phản ánh hành vi thật nên nặng hơn thể loại
+var similarityWeights = repositories.SimilarityWeights{
+	Genre:  0.4,
+	CoRead: 0.6,
+}
+
+const (
+	recommendationCandidateFactor = 4   // Lấy dư ứng viên để còn chỗ đa dạng hóa
+	recommendationDiversityDecay  = 0.6 // Mỗi truyện đã chọn cùng thể loại nhân điểm với hệ số này
+)
+
+// recommendationWeights - Truyện tương tự với truyện đã thích là tín hiệu mạnh nhất
+var recommendationWeights = repositories.RecommendationWeights{
+	Genre:      1.0,
+	Neighbour:  1.5,
+	Popularity: 0.05,
+}
+
+type RecommendationService interface {
+	GetSimilarStories(storySlug string, limit int, showMature bool) ([]models.Story, error)
+	GetRecommendations(userID *uuid.UUID, limit int, showMature bool) ([]models.Story, error)
+
+	// Scheduler methods
+	RefreshSimilarStories() error
+}
+
+type recommendationService struct {
+	recommendationRepo repositories.RecommendationRepository
+	storyRepo          repositories.StoryRepository
+	trendingRepo       repositories.TrendingRepository
+}
+
+func NewRecommendationService(
+	recommendationRepo repositories.RecommendationRepository,
+	storyRepo repositories.StoryRepository,
+	trendingRepo repositories.TrendingRepository,
+) RecommendationService {
+	return &recommendationService{
+		recommendationRepo: recommendationRepo,
+		storyRepo:          storyRepo,
+		trendingRepo:       trendingRepo,
+	}
+}
+
+// GetSimilarStories - Lấy truyện tương tự theo slug (Public)
+func (s *recommendationService) GetSimilarStories(storySlug string, limit int, showMature bool) ([]models.Story, error) {
+	story, err := s.storyRepo.FindStoryRefBySlug(storySlug)
+	if err != nil {
+		return nil, errors.New("truyện không tồn tại")
+	}
+	if err := checkContentAccess(story, showMature); err != nil {
+		return nil, err
+	}
+	return s.recommendationRepo.GetSimilarStories(story.ID, limit, showMature)
+}
+
+// GetRecommendations - Gợi ý truyện cho người đọc
+// Khách hoặc người đọc chưa có lịch sử thì trả về trending 7 ngày
+func (s *recommendationService) GetRecommendations(userID *uuid.UUID, limit int, showMature bool) ([]models.Story, error) {
+	if userID == nil {
+		return s.trendingRepo.GetTrendingStories(models.TrendingWindow7d, limit, showMature)
+	}
+
+	candidates, err := s.recommendationRepo.GetRecommendationCandidates(*userID, limit*recommendationCandidateFactor, recommendationWeights, showMature)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return s.trendingRepo.GetTrendingStories(models.TrendingWindow7d, limit, showMature)
+	}
+
+	ids := make([]uuid.UUID, len(candidates))
+	for i, candidate := range candidates {
+		ids[i] = candidate.StoryID
+	}
+	stories, err := s.storyRepo.FindPublishedStoriesByIDs(ids, showMature)
+	if err != nil {
+		return nil, err
+	}
+
+	storyByID := make(map[uuid.UUID]models.Story, len(stories))
+	for _, story := range stories {
+		storyByID[story.ID] = story
+	}
+	scored := make([]scoredStory, 0, len(candidates))
+	for _, candidate := range candidates {
+		if story, ok := storyByID[candidate.StoryID]; ok {
+			scored = append(scored, scoredStory{story: story, score: candidate.Score})
+		}
+	}
+
+	return diversifyStories(scored, limit), nil
+}
+
+type scoredStory struct {
+	story models.Story
+	score float64
+}
+
+// diversifyStories - Chọn tham lam truyện có điểm cao nhất sau khi phạt trùng thể loại
+// Điểm bị trừ |điểm| * (1 - recommendationDiversityDecay^n) với n là số truyện đã chọn trùng thể loại nhiều nhất
+func diversifyStories(candidates []scoredStory, limit int) []models.Story {
+	picked := make([]models.Story, 0, limit)
+	genreCount := make(map[uuid.UUID]int)
+	used := make([]bool, len(candidates))
+
+	for len(picked) < limit {
+		best, bestScore := -1, math.Inf(-1)
+		for i, candidate := range candidates {
+			if used[i] {
+				continue
+			}
+			overlap := 0
+			for _, genre := range candidate.story.Genres {
+				if genreCount[genre.ID] > overlap {
+					overlap = genreCount[genre.ID]
+				}
+			}
+			// Phạt theo trị tuyệt đối để điểm âm bị đẩy xuống thêm, không bị kéo về 0
+			penalty := 1 - math.Pow(recommendationDiversityDecay, float64(overlap))
+			score := candidate.score - math.Abs(candidate.score)*penalty
+			if score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+		if best < 0 {
+			break
+		}
+
+		used[best] = true
+		picked = append(picked, candidates[best].story)
+		for _, genre := range candidates[best].story.Genres {
+			genreCount[genre.ID]++
+		}
+	}
+	return picked
+}
+
+// RefreshSimilarStories - Tính lại truyện tương tự cho toàn bộ catalog (chạy định kỳ)
+func (s *recommendationService) RefreshSimilarStories() error {
+	return s.recommendationRepo.RefreshSimilarities(similarNeighbours, similarMinCoReaders, similarityWeights)
+}
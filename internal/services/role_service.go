@@ -0,0 +1,188 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"nekozanedex/internal/models"
+	"nekozanedex/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,19}$`)
+
+// ErrPermissionEscalation - Người thao tác cấp/thu hồi quyền mà chính họ không có
+var ErrPermissionEscalation = errors.New("không thể cấp hoặc thu hồi quyền mà bạn không có")
+
+type RoleService interface {
+	GetRoles() ([]models.Role, error)
+	GetRole(id uuid.UUID) (*models.Role, error)
+	// grantor: quyền của người thao tác, chỉ được đặt các quyền mình đang có
+	CreateRole(name string, description *string, permissions, grantor []string) (*models.Role, error)
+	UpdateRole(id uuid.UUID, description *string, permissions, grantor []string) (*models.Role, error)
+	DeleteRole(id uuid.UUID) error
+	RoleExists(name string) bool
+	CheckRoleAssignment(currentRole, targetRole string, grantor []string) error
+	SeedDefaultRoles() (int, error)
+}
+
+type roleService struct {
+	roleRepo repositories.RoleRepository
+}
+
+func NewRoleService(roleRepo repositories.RoleRepository) RoleService {
+	return &roleService{roleRepo: roleRepo}
+}
+
+// GetRoles - Danh sách role kèm quyền
+func (s *roleService) GetRoles() ([]models.Role, error) {
+	return s.roleRepo.GetAllRoles()
+}
+
+// GetRole - Lấy role theo ID
+func (s *roleService) GetRole(id uuid.UUID) (*models.Role, error) {
+	role, err := s.roleRepo.FindRoleByID(id)
+	if err != nil {
+		return nil, errors.New("không tìm thấy role")
+	}
+	return role, nil
+}
+
+// CreateRole - Tạo role mới, tên không đổi được sau khi tạo vì users.role tham chiếu theo tên
+func (s *roleService) CreateRole(name string, description *string, permissions, grantor []string) (*models.Role, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !roleNamePattern.MatchString(name) {
+		return nil, errors.New("tên role chỉ gồm chữ thường, số, '-' hoặc '_' (2-20 ký tự)")
+	}
+	if s.RoleExists(name) {
+		return nil, errors.New("role đã tồn tại")
+	}
+
+	permissions, err := normalizePermissions(permissions, grantor)
+	if err != nil {
+		return nil, err
+	}
+
+	role := &models.Role{Name: name, Description: description}
+	if err := role.SetPermissions(permissions); err != nil {
+		return nil, err
+	}
+	if err := s.roleRepo.CreateRole(role); err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+// UpdateRole - Cập nhật mô tả + quyền; role admin luôn giữ toàn quyền
+func (s *roleService) UpdateRole(id uuid.UUID, description *string, permissions, grantor []string) (*models.Role, error) {
+	role, err := s.roleRepo.FindRoleByID(id)
+	if err != nil {
+		return nil, errors.New("không tìm thấy role")
+	}
+
+	if description != nil {
+		role.Description = description
+	}
+	if permissions != nil {
+		if role.Name == models.RoleAdmin {
+			return nil, errors.New("không thể thay đổi quyền của role admin")
+		}
+		// Thu hồi cũng cần có quyền đó (vd. bỏ "*" khỏi role)
+		if !models.CanGrantPermissions(role.GetPermissions(), grantor) {
+			return nil, ErrPermissionEscalation
+		}
+		permissions, err := normalizePermissions(permissions, grantor)
+		if err != nil {
+			return nil, err
+		}
+		if err := role.SetPermissions(permissions); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.roleRepo.UpdateRole(role); err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+// DeleteRole - Xóa role không phải hệ thống và không còn user nào giữ
+func (s *roleService) DeleteRole(id uuid.UUID) error {
+	role, err := s.roleRepo.FindRoleByID(id)
+	if err != nil {
+		return errors.New("không tìm thấy role")
+	}
+	if role.IsSystem {
+		return errors.New("không thể xóa role hệ thống")
+	}
+
+	count, err := s.roleRepo.CountUsersWithRole(role.Name)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("còn %d người dùng đang giữ role này", count)
+	}
+
+	return s.roleRepo.DeleteRole(id)
+}
+
+// RoleExists - Kiểm tra tên role có trong DB không
+func (s *roleService) RoleExists(name string) bool {
+	_, err := s.roleRepo.FindRoleByName(name)
+	return err == nil
+}
+
+// CheckRoleAssignment - Đổi role của user: role mới phải tồn tại, quyền của role cũ và mới đều nằm trong quyền của người thao tác
+func (s *roleService) CheckRoleAssignment(currentRole, targetRole string, grantor []string) error {
+	target, err := s.roleRepo.FindRoleByName(targetRole)
+	if err != nil {
+		return errors.New("role không tồn tại")
+	}
+	if !models.CanGrantPermissions(target.GetPermissions(), grantor) {
+		return ErrPermissionEscalation
+	}
+	if current, err := s.roleRepo.FindRoleByName(currentRole); err == nil && !models.CanGrantPermissions(current.GetPermissions(), grantor) {
+		return ErrPermissionEscalation
+	}
+	return nil
+}
+
+// SeedDefaultRoles - Tạo các role mặc định còn thiếu, trả về số role đã tạo
+func (s *roleService) SeedDefaultRoles() (int, error) {
+	created := 0
+	for _, role := range models.DefaultRoles() {
+		ok, err := s.roleRepo.EnsureRole(&role)
+		if err != nil {
+			return created, err
+		}
+		if ok {
+			created++
+		}
+	}
+	return created, nil
+}
+
+// normalizePermissions - Bỏ trùng, kiểm tra quyền có trong danh sách models.Permissions và người thao tác đang có quyền đó
+func normalizePermissions(permissions, grantor []string) ([]string, error) {
+	seen := make(map[string]bool, len(permissions))
+	result := make([]string, 0, len(permissions))
+	for _, p := range permissions {
+		p = strings.TrimSpace(p)
+		if p == "" || seen[p] {
+			continue
+		}
+		if !models.IsValidPermission(p) {
+			return nil, fmt.Errorf("quyền không hợp lệ: %s", p)
+		}
+		if !models.HasPermission(grantor, p) {
+			return nil, fmt.Errorf("%w: %s", ErrPermissionEscalation, p)
+		}
+		seen[p] = true
+		result = append(result, p)
+	}
+	return result, nil
+}
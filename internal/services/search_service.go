@@ -0,0 +1,164 @@
+package services
+
+import (
+	"container/list"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"nekozanedex/internal/repositories"
+)
+
+const (
+	suggestStoryLimit  = 5
+	suggestAuthorLimit = 3
+	suggestGenreLimit  = 3
+	suggestMaxQueryLen = 100
+	suggestCacheTTL    = 60 * time.Second
+	suggestCacheMax    = 5000 // Số query giữ trong cache, vượt thì bỏ query ít dùng nhất
+	suggestCacheMaxLen = 32   // Query dài hơn hiếm khi lặp lại nên không cache
+)
+
+// SearchSuggestions - Kết quả gợi ý hỗn hợp cho ô tìm kiếm
+type SearchSuggestions struct {
+	Stories []repositories.StorySuggestion  `json:"stories"`
+	Authors []repositories.AuthorSuggestion `json:"authors"`
+	Genres  []repositories.GenreSuggestion  `json:"genres"`
+}
+
+type SearchService interface {
+	Suggest(query string, showMature bool) (*SearchSuggestions, error)
+}
+
+// suggestCache - LRU giới hạn số entry, key là showMature + query đã chuẩn hóa
+type suggestCache struct {
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // Đầu danh sách = dùng gần nhất
+}
+
+type cachedSuggestions struct {
+	key       string
+	result    *SearchSuggestions
+	expiresAt time.Time
+}
+
+func newSuggestCache() *suggestCache {
+	return &suggestCache{entries: make(map[string]*list.Element), order: list.New()}
+}
+
+func (c *suggestCache) get(key string) (*SearchSuggestions, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*cachedSuggestions)
+	if time.Now().After(entry.expiresAt) {
+		c.remove(element)
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return entry.result, true
+}
+
+func (c *suggestCache) put(key string, result *SearchSuggestions) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+	c.entries[key] = c.order.PushFront(&cachedSuggestions{key: key, result: result, expiresAt: time.Now().Add(suggestCacheTTL)})
+	for c.order.Len() > suggestCacheMax {
+		c.remove(c.order.Back())
+	}
+}
+
+// removeExpired - Xóa các entry hết hạn
+func (c *suggestCache) removeExpired() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for element := c.order.Front(); element != nil; {
+		next := element.Next()
+		if now.After(element.Value.(*cachedSuggestions).expiresAt) {
+			c.remove(element)
+		}
+		element = next
+	}
+}
+
+func (c *suggestCache) remove(element *list.Element) {
+	entry := c.order.Remove(element).(*cachedSuggestions)
+	delete(c.entries, entry.key)
+}
+
+type searchService struct {
+	searchRepo repositories.SearchRepository
+	cache      *suggestCache
+}
+
+func NewSearchService(searchRepo repositories.SearchRepository) SearchService {
+	s := &searchService{searchRepo: searchRepo, cache: newSuggestCache()}
+	go s.cleanupCache()
+	return s
+}
+
+// Suggest - Gợi ý truyện, tác giả, thể loại (gọi theo từng phím gõ)
+// Kết quả của query ngắn được cache ngắn hạn vì các prefix phổ biến bị gọi lặp lại rất nhiều
+func (s *searchService) Suggest(query string, showMature bool) (*SearchSuggestions, error) {
+	query = strings.ToLower(strings.Join(strings.Fields(query), " "))
+	if query == "" {
+		return &SearchSuggestions{
+			Stories: []repositories.StorySuggestion{},
+			Authors: []repositories.AuthorSuggestion{},
+			Genres:  []repositories.GenreSuggestion{},
+		}, nil
+	}
+	if utf8.RuneCountInString(query) > suggestMaxQueryLen {
+		query = string([]rune(query)[:suggestMaxQueryLen])
+	}
+
+	cacheable := utf8.RuneCountInString(query) <= suggestCacheMaxLen
+	cacheKey := fmt.Sprintf("%t:%s", showMature, query)
+	if cacheable {
+		if result, ok := s.cache.get(cacheKey); ok {
+			return result, nil
+		}
+	}
+
+	stories, err := s.searchRepo.SuggestStories(query, suggestStoryLimit, showMature)
+	if err != nil {
+		return nil, err
+	}
+	authors, err := s.searchRepo.SuggestAuthors(query, suggestAuthorLimit, showMature)
+	if err != nil {
+		return nil, err
+	}
+	genres, err := s.searchRepo.SuggestGenres(query, suggestGenreLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SearchSuggestions{Stories: stories, Authors: authors, Genres: genres}
+	if cacheable {
+		s.cache.put(cacheKey, result)
+	}
+	return result, nil
+}
+
+// cleanupCache - Xóa các entry hết hạn (chạy background)
+func (s *searchService) cleanupCache() {
+	ticker := time.NewTicker(suggestCacheTTL)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.cache.removeExpired()
+	}
+}
@@ -15,15 +15,17 @@ import (
 
 type StoryService interface {
 	// Public methods
-	GetStoryBySlug(slug string) (*models.Story, error)
-	RecordStoryView(storyID uuid.UUID, userID *uuid.UUID, ipAddress string) error // Fair view counting
-	GetAllStories(page, limit int) ([]models.Story, int64, error)
-	GetStoriesByGenre(genreSlug string, page, limit int) ([]models.Story, int64, error)
-	GetLatestStories(limit int) ([]models.Story, error)
-	GetHotStories(limit int) ([]models.Story, error)
-	SearchStories(query string, page, limit int) ([]models.Story, int64, error)
+	// showMature: người xem đã bật hiển thị truyện mature/adult (khách luôn false)
+	GetStoryBySlug(slug string, showMature bool) (*models.Story, error)
+	RecordStoryView(storyID uuid.UUID, userID *uuid.UUID, ipAddress string, referrer *string) error // Fair view counting
+	GetAllStories(page, limit int, showMature bool) ([]models.Story, int64, error)
+	GetAllStoriesByCursor(cursor string, limit int, showMature bool) ([]models.Story, string, error)
+	GetStoriesByGenre(genreSlug string, page, limit int, showMature bool) ([]models.Story, int64, error)
+	GetLatestStories(limit int, showMature bool) ([]models.Story, error)
+	GetHotStories(window string, limit int, showMature bool) ([]models.Story, error)
+	SearchStories(query string, page, limit int, showMature bool) ([]models.Story, int64, error)
 	AdvancedSearchStories(filters *repositories.SearchFilters) ([]models.Story, int64, error)
-	GetRandomStory() (*models.Story, error)
+	GetRandomStory(showMature bool) (*models.Story, error)
 	GetAllGenres() ([]models.Genre, error)
 
 	// Admin methods
@@ -34,12 +36,45 @@ type StoryService interface {
 	GetStoryByID(id uuid.UUID) (*models.Story, error)
 	GetAllStoriesAdmin(page, limit int) ([]models.Story, int64, error)
 	SearchStoriesAdmin(query string, page, limit int) ([]models.Story, int64, error)
+	PinStorySlug(id uuid.UUID, slug string, locked bool) (*models.Story, error)
+	GetSlugHistory(id uuid.UUID) ([]models.StorySlugHistory, error)
+	GetStoryRelations(storyID uuid.UUID) ([]models.StoryRelation, error)
+	SetStoryRelation(storyID, relatedStoryID uuid.UUID, relationType string) error
+	DeleteStoryRelation(storyID, relatedStoryID uuid.UUID) error
+
+	// Scheduler methods
+	RefreshTrendingScores() error
+}
+
+// slugPattern - Slug hợp lệ: a-z, 0-9, các đoạn nối bằng "-"
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// trendingWindowConfig - Độ dài cửa sổ và half-life của từng trending window
+type trendingWindowConfig struct {
+	duration time.Duration
+	halfLife time.Duration
+}
+
+var trendingWindows = map[string]trendingWindowConfig{
+	models.TrendingWindow24h: {duration: 24 * time.Hour, halfLife: 6 * time.Hour},
+	models.TrendingWindow7d:  {duration: 7 * 24 * time.Hour, halfLife: 2 * 24 * time.Hour},
+	models.TrendingWindow30d: {duration: 30 * 24 * time.Hour, halfLife: 7 * 24 * time.Hour},
+}
+
+// trendingWeights - Bookmark/rating thể hiện mức quan tâm cao hơn một lượt xem
+var trendingWeights = repositories.TrendingWeights{
+	View:     1,
+	Rating:   3,
+	Bookmark: 5,
+	Comment:  2,
 }
 
 type storyService struct {
 	storyRepo     repositories.StoryRepository
 	genreRepo     repositories.GenreRepository
 	storyViewRepo repositories.StoryViewRepository
+	trendingRepo  repositories.TrendingRepository
+	relationRepo  repositories.StoryRelationRepository
 	uploadService UploadService
 }
 
@@ -47,12 +82,16 @@ func NewStoryService(
 	storyRepo repositories.StoryRepository,
 	genreRepo repositories.GenreRepository,
 	storyViewRepo repositories.StoryViewRepository,
+	trendingRepo repositories.TrendingRepository,
+	relationRepo repositories.StoryRelationRepository,
 	uploadService UploadService,
 ) StoryService {
 	return &storyService{
 		storyRepo:     storyRepo,
 		genreRepo:     genreRepo,
 		storyViewRepo: storyViewRepo,
+		trendingRepo:  trendingRepo,
+		relationRepo:  relationRepo,
 		uploadService: uploadService,
 	}
 }
@@ -64,8 +103,14 @@ func (s *storyService) CreateStory(story *models.Story) error {
 		return errors.New("tiêu đề không được để trống")
 	}
 
+	if story.AgeRating == "" {
+		story.AgeRating = models.AgeRatingEveryone
+	} else if !models.IsValidAgeRating(story.AgeRating) {
+		return errors.New("age rating không hợp lệ (everyone, teen, mature, adult)")
+	}
+
 	// Generate slug từ title
-	story.Slug = s.generateUniqueSlug(story.Title)
+	story.Slug = s.generateUniqueSlug(story.Title, uuid.Nil)
 
 	// Set defaults
 	story.ViewCount = 0
@@ -82,12 +127,19 @@ func (s *storyService) UpdateStory(id uuid.UUID, updatedStory *models.Story) err
 	if err != nil {
 		return errors.New("truyện không tồn tại")
 	}
+	if updatedStory.AgeRating != "" && !models.IsValidAgeRating(updatedStory.AgeRating) {
+		return errors.New("age rating không hợp lệ (everyone, teen, mature, adult)")
+	}
 
 	// Update fields
+	// Slug mới được áp dụng cùng transaction với lần lưu, slug cũ vào lịch sử (link cũ vẫn chuyển hướng được)
+	newSlug := existingStory.Slug
 	if updatedStory.Title != "" {
 		if updatedStory.Title != existingStory.Title {
 			existingStory.Title = updatedStory.Title
-			existingStory.Slug = s.generateUniqueSlug(updatedStory.Title)
+			if !existingStory.SlugLocked {
+				newSlug = s.generateUniqueSlug(updatedStory.Title, existingStory.ID)
+			}
 		}
 	}
 	
@@ -143,12 +195,57 @@ func (s *storyService) UpdateStory(id uuid.UUID, updatedStory *models.Story) err
 	if updatedStory.Status != "" {
 		existingStory.Status = updatedStory.Status
 	}
+	if updatedStory.AgeRating != "" {
+		existingStory.AgeRating = updatedStory.AgeRating
+	}
 	existingStory.IsPublished = updatedStory.IsPublished
 	existingStory.UpdatedAt = time.Now()
 
+	if newSlug != existingStory.Slug {
+		return s.storyRepo.UpdateStoryWithSlug(existingStory, newSlug)
+	}
 	return s.storyRepo.UpdateStory(existingStory)
 }
 
+// PinStorySlug - Admin đặt slug tùy chỉnh, locked = true để đổi tên không sinh slug mới (Admin)
+func (s *storyService) PinStorySlug(id uuid.UUID, newSlug string, locked bool) (*models.Story, error) {
+	story, err := s.storyRepo.FindStoryByID(id)
+	if err != nil {
+		return nil, errors.New("truyện không tồn tại")
+	}
+
+	newSlug = strings.ToLower(strings.TrimSpace(newSlug))
+	if len(newSlug) > 255 || !slugPattern.MatchString(newSlug) {
+		return nil, errors.New("slug chỉ gồm chữ thường không dấu, số và dấu gạch ngang")
+	}
+	taken, err := s.storyRepo.IsSlugTaken(newSlug, story.ID)
+	if err != nil {
+		return nil, err
+	}
+	if taken {
+		return nil, errors.New("slug đã được sử dụng")
+	}
+
+	if newSlug != story.Slug {
+		if err := s.storyRepo.ChangeStorySlug(story.ID, story.Slug, newSlug); err != nil {
+			return nil, err
+		}
+		story.Slug = newSlug
+	}
+	if story.SlugLocked != locked {
+		story.SlugLocked = locked
+		if err := s.storyRepo.UpdateStory(story); err != nil {
+			return nil, err
+		}
+	}
+	return story, nil
+}
+
+// GetSlugHistory - Các slug cũ của truyện (Admin)
+func (s *storyService) GetSlugHistory(id uuid.UUID) ([]models.StorySlugHistory, error) {
+	return s.storyRepo.GetSlugHistory(id)
+}
+
 // UpdateStoryGenres - Cập nhật thể loại của truyện (Admin)
 func (s *storyService) UpdateStoryGenres(storyID uuid.UUID, genreIDStrings []string) error {
 	// Convert string IDs to UUIDs
@@ -184,16 +281,61 @@ func (s *storyService) GetStoryByID(id uuid.UUID) (*models.Story, error) {
 
 // GetStoryBySlug - Lấy truyện theo slug (Public)
 // Note: Does NOT increment view count - call RecordStoryView separately
-func (s *storyService) GetStoryBySlug(storySlug string) (*models.Story, error) {
+// Truyện mature/adult trả về ContentWarningError nếu người xem chưa bật hiển thị
+func (s *storyService) GetStoryBySlug(storySlug string, showMature bool) (*models.Story, error) {
 	story, err := s.storyRepo.FindStoryBySlug(storySlug)
 	if err != nil {
 		return nil, errors.New("truyện không tồn tại")
 	}
+	if err := checkContentAccess(story, showMature); err != nil {
+		return nil, err
+	}
+
+	// Truyện liên quan nhóm theo loại quan hệ (chỉ truyện đã publish)
+	relations, err := s.relationRepo.GetRelations(story.ID, true)
+	if err != nil {
+		return nil, err
+	}
+	if len(relations) > 0 {
+		story.Related = make(map[string][]models.Story)
+		for _, relation := range relations {
+			if relation.RelatedStory != nil && checkContentAccess(relation.RelatedStory, showMature) == nil {
+				story.Related[relation.RelationType] = append(story.Related[relation.RelationType], *relation.RelatedStory)
+			}
+		}
+	}
 	return story, nil
 }
 
+// GetStoryRelations - Danh sách quan hệ của truyện, gồm cả truyện chưa publish (Admin)
+func (s *storyService) GetStoryRelations(storyID uuid.UUID) ([]models.StoryRelation, error) {
+	return s.relationRepo.GetRelations(storyID, false)
+}
+
+// SetStoryRelation - Liên kết hai truyện, quan hệ ngược được tạo tự động (Admin)
+func (s *storyService) SetStoryRelation(storyID, relatedStoryID uuid.UUID, relationType string) error {
+	if !models.IsValidRelationType(relationType) {
+		return errors.New("loại quan hệ không hợp lệ")
+	}
+	if storyID == relatedStoryID {
+		return errors.New("không thể liên kết truyện với chính nó")
+	}
+	if _, err := s.storyRepo.FindStoryByID(storyID); err != nil {
+		return errors.New("truyện không tồn tại")
+	}
+	if _, err := s.storyRepo.FindStoryByID(relatedStoryID); err != nil {
+		return errors.New("truyện liên quan không tồn tại")
+	}
+	return s.relationRepo.SetRelation(storyID, relatedStoryID, relationType)
+}
+
+// DeleteStoryRelation - Gỡ liên kết hai truyện (cả hai chiều) (Admin)
+func (s *storyService) DeleteStoryRelation(storyID, relatedStoryID uuid.UUID) error {
+	return s.relationRepo.DeleteRelation(storyID, relatedStoryID)
+}
+
 // RecordStoryView - Fair view counting (1 view per user/IP per 24h)
-func (s *storyService) RecordStoryView(storyID uuid.UUID, userID *uuid.UUID, ipAddress string) error {
+func (s *storyService) RecordStoryView(storyID uuid.UUID, userID *uuid.UUID, ipAddress string, referrer *string) error {
 	// Check if already viewed in last 24 hours
 	hasViewed, err := s.storyViewRepo.HasViewedRecently(storyID, userID, ipAddress, 24*time.Hour)
 	if err != nil {
@@ -210,6 +352,7 @@ func (s *storyService) RecordStoryView(storyID uuid.UUID, userID *uuid.UUID, ipA
 		StoryID:   storyID,
 		UserID:    userID,
 		IPAddress: ipAddress,
+		Referrer:  referrer,
 	}
 	if err := s.storyViewRepo.RecordView(view); err != nil {
 		return err
@@ -220,48 +363,67 @@ func (s *storyService) RecordStoryView(storyID uuid.UUID, userID *uuid.UUID, ipA
 }
 
 // GetAllStories - Lấy tất cả truyện đã publish (Public)
-func (s *storyService) GetAllStories(page, limit int) ([]models.Story, int64, error) {
-	return s.storyRepo.GetAllStories(page, limit, true)
+func (s *storyService) GetAllStories(page, limit int, showMature bool) ([]models.Story, int64, error) {
+	return s.storyRepo.GetAllStories(page, limit, true, showMature)
+}
+
+// GetAllStoriesByCursor - Lấy truyện đã publish theo cursor (Public)
+func (s *storyService) GetAllStoriesByCursor(cursor string, limit int, showMature bool) ([]models.Story, string, error) {
+	return s.storyRepo.GetAllStoriesByCursor(cursor, limit, true, showMature)
 }
 
 // GetAllStoriesAdmin - Lấy tất cả truyện (Admin)
 func (s *storyService) GetAllStoriesAdmin(page, limit int) ([]models.Story, int64, error) {
-	return s.storyRepo.GetAllStories(page, limit, false)
+	return s.storyRepo.GetAllStories(page, limit, false, true)
 }
 
 // SearchStoriesAdmin - Tìm kiếm truyện (Admin - includes drafts)
 func (s *storyService) SearchStoriesAdmin(query string, page, limit int) ([]models.Story, int64, error) {
 	if strings.TrimSpace(query) == "" {
-		return s.storyRepo.GetAllStories(page, limit, false)
+		return s.storyRepo.GetAllStories(page, limit, false, true)
 	}
 	return s.storyRepo.SearchStoriesAdmin(query, page, limit)
 }
 
 // GetStoriesByGenre - Lấy truyện theo thể loại (Public)
-func (s *storyService) GetStoriesByGenre(genreSlug string, page, limit int) ([]models.Story, int64, error) {
+func (s *storyService) GetStoriesByGenre(genreSlug string, page, limit int, showMature bool) ([]models.Story, int64, error) {
 	genre, err := s.genreRepo.FindGenreBySlug(genreSlug)
 	if err != nil {
 		return nil, 0, errors.New("thể loại không tồn tại")
 	}
-	return s.storyRepo.GetStoriesByGenre(genre.ID, page, limit)
+	return s.storyRepo.GetStoriesByGenre(genre.ID, page, limit, showMature)
 }
 
 // GetLatestStories - Lấy truyện mới cập nhật (Public)
-func (s *storyService) GetLatestStories(limit int) ([]models.Story, error) {
-	return s.storyRepo.GetStoriesLatest(limit)
+func (s *storyService) GetLatestStories(limit int, showMature bool) ([]models.Story, error) {
+	return s.storyRepo.GetStoriesLatest(limit, showMature)
 }
 
-// GetHotStories - Lấy truyện hot (Public)
-func (s *storyService) GetHotStories(limit int) ([]models.Story, error) {
-	return s.storyRepo.GetStoriesHot(limit)
+// GetHotStories - Lấy truyện hot theo điểm trending của window (Public)
+func (s *storyService) GetHotStories(window string, limit int, showMature bool) ([]models.Story, error) {
+	if !models.IsValidTrendingWindow(window) {
+		return nil, errors.New("window không hợp lệ (24h, 7d, 30d)")
+	}
+	return s.trendingRepo.GetTrendingStories(window, limit, showMature)
+}
+
+// RefreshTrendingScores - Tính lại điểm trending cho tất cả window (chạy định kỳ)
+func (s *storyService) RefreshTrendingScores() error {
+	now := time.Now()
+	for window, cfg := range trendingWindows {
+		if err := s.trendingRepo.RefreshScores(window, now.Add(-cfg.duration), cfg.halfLife, trendingWeights); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // SearchStories - Tìm kiếm truyện (Public)
-func (s *storyService) SearchStories(query string, page, limit int) ([]models.Story, int64, error) {
+func (s *storyService) SearchStories(query string, page, limit int, showMature bool) ([]models.Story, int64, error) {
 	if strings.TrimSpace(query) == "" {
 		return nil, 0, errors.New("từ khóa tìm kiếm không được để trống")
 	}
-	return s.storyRepo.SearchStories(query, page, limit)
+	return s.storyRepo.SearchStories(query, page, limit, showMature)
 }
 
 // AdvancedSearchStories - Tìm kiếm nâng cao với bộ lọc (Public)
@@ -277,8 +439,8 @@ func (s *storyService) AdvancedSearchStories(filters *repositories.SearchFilters
 }
 
 // GetRandomStory - Lấy truyện ngẫu nhiên (Public)
-func (s *storyService) GetRandomStory() (*models.Story, error) {
-	stories, _, err := s.storyRepo.GetAllStories(1, 100, true)
+func (s *storyService) GetRandomStory(showMature bool) (*models.Story, error) {
+	stories, _, err := s.storyRepo.GetAllStories(1, 100, true, showMature)
 	if err != nil || len(stories) == 0 {
 		return nil, errors.New("không có truyện nào")
 	}
@@ -294,16 +456,16 @@ func (s *storyService) GetAllGenres() ([]models.Genre, error) {
 }
 
 // Helper: Generate unique slug
-func (s *storyService) generateUniqueSlug(title string) string {
+func (s *storyService) generateUniqueSlug(title string, storyID uuid.UUID) string {
 	baseSlug := slug.Make(title)
 
 	// Remove special characters
 	reg := regexp.MustCompile("[^a-zA-Z0-9-]+")
 	baseSlug = reg.ReplaceAllString(baseSlug, "")
 
-	// Check if slug exists
-	_, err := s.storyRepo.FindStoryBySlug(baseSlug)
-	if err != nil {
+	// Check if slug exists (gồm cả truyện nháp và slug cũ của truyện khác)
+	taken, err := s.storyRepo.IsSlugTaken(baseSlug, storyID)
+	if err == nil && !taken {
 		// Slug doesn't exist, use it
 		return baseSlug
 	}
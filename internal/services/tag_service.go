@@ -0,0 +1,325 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"nekozanedex/internal/models"
+	"nekozanedex/internal/repositories"
+
+	"github.com/google/uuid"
+	"github.com/gosimple/slug"
+)
+
+var (
+	// ErrTagNameInvalid - Tên tag rỗng, quá dài hoặc không tạo được slug
+	ErrTagNameInvalid = errors.New("tên tag không hợp lệ")
+	// ErrTagNameTaken - Tên hoặc slug đã thuộc về tag khác (kể cả alias)
+	ErrTagNameTaken = errors.New("tên hoặc slug của tag đã được dùng bởi tag khác")
+)
+
+// StoryTags - Tag của truyện: đã hiển thị và đang chờ đủ phiếu (chỉ trả cho người đã đăng nhập)
+type StoryTags struct {
+	Tags    []repositories.StoryTagView `json:"tags"`
+	Pending []repositories.StoryTagView `json:"pending,omitempty"`
+}
+
+type TagService interface {
+	// Public
+	SearchTags(query string, limit int) ([]models.Tag, error)
+	GetStoryTags(storyID uuid.UUID, viewerID *uuid.UUID) (*StoryTags, error)
+
+	// Reader
+	ProposeTag(storyID, userID uuid.UUID, name string, showMature bool) (*models.Tag, error)
+	VoteTag(storyID, tagID, userID uuid.UUID, value int, showMature bool) error
+
+	// Admin
+	GetAllTags(query, status string, page, limit int) ([]models.Tag, int64, error)
+	CreateTag(name string, description *string) (*models.Tag, error)
+	UpdateTag(id uuid.UUID, name string, description *string) (*models.Tag, error)
+	AddAlias(id uuid.UUID, name string) (*models.Tag, error)
+	MergeTags(targetID, sourceID uuid.UUID) error
+	SetTagBanned(id uuid.UUID, banned bool) (*models.Tag, error)
+}
+
+type tagService struct {
+	tagRepo   repositories.TagRepository
+	storyRepo repositories.StoryRepository
+}
+
+func NewTagService(tagRepo repositories.TagRepository, storyRepo repositories.StoryRepository) TagService {
+	return &tagService{
+		tagRepo:   tagRepo,
+		storyRepo: storyRepo,
+	}
+}
+
+// SearchTags - Tìm tag đang hoạt động (autocomplete khi đề xuất / lọc)
+func (s *tagService) SearchTags(query string, limit int) ([]models.Tag, error) {
+	return s.tagRepo.SearchActiveTags(strings.TrimSpace(query), limit)
+}
+
+// GetStoryTags - Tag đã đủ phiếu của truyện, kèm tag đang chờ nếu có người xem đăng nhập
+func (s *tagService) GetStoryTags(storyID uuid.UUID, viewerID *uuid.UUID) (*StoryTags, error) {
+	viewer := uuid.Nil
+	if viewerID != nil {
+		viewer = *viewerID
+	}
+
+	threshold := models.TagVisibilityThreshold
+	if viewerID == nil {
+		tags, err := s.tagRepo.GetStoryTags(storyID, viewer, &threshold)
+		if err != nil {
+			return nil, err
+		}
+		return &StoryTags{Tags: tags}, nil
+	}
+
+	all, err := s.tagRepo.GetStoryTags(storyID, viewer, nil)
+	if err != nil {
+		return nil, err
+	}
+	result := &StoryTags{Tags: []repositories.StoryTagView{}, Pending: []repositories.StoryTagView{}}
+	for _, tag := range all {
+		if tag.Score >= threshold {
+			result.Tags = append(result.Tags, tag)
+		} else {
+			result.Pending = append(result.Pending, tag)
+		}
+	}
+	return result, nil
+}
+
+// ProposeTag - Đề xuất tag cho truyện (tạo tag mới nếu chưa có), tính là một upvote
+func (s *tagService) ProposeTag(storyID, userID uuid.UUID, name string, showMature bool) (*models.Tag, error) {
+	name, tagSlug, err := normalizeTagName(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkStoryAccess(storyID, showMature); err != nil {
+		return nil, err
+	}
+
+	// Tên khác nhưng cùng slug ("Nu chinh" / "Nữ chính") là cùng một tag: vote cho tag đó thay vì tạo mới
+	tag, err := s.tagRepo.FindTagByName(name)
+	if err != nil {
+		tag, err = s.tagRepo.FindTagBySlug(tagSlug)
+	}
+	if err != nil {
+		tag = &models.Tag{
+			Name:      name,
+			Slug:      tagSlug,
+			Status:    models.TagStatusActive,
+			CreatedBy: &userID,
+		}
+		if err := s.tagRepo.CreateTag(tag); err != nil {
+			// Người khác vừa tạo cùng slug
+			if tag, err = s.tagRepo.FindTagBySlug(tagSlug); err != nil {
+				return nil, ErrTagNameTaken
+			}
+		}
+	}
+
+	tag, err = s.resolveTag(tag)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.tagRepo.Vote(storyID, tag.ID, userID, 1); err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+// VoteTag - Upvote (1), downvote (-1) hoặc bỏ phiếu (0) cho tag trên truyện
+func (s *tagService) VoteTag(storyID, tagID, userID uuid.UUID, value int, showMature bool) error {
+	if value < -1 || value > 1 {
+		return errors.New("giá trị vote không hợp lệ")
+	}
+	if err := s.checkStoryAccess(storyID, showMature); err != nil {
+		return err
+	}
+
+	tag, err := s.tagRepo.FindTagByID(tagID)
+	if err != nil {
+		return errors.New("tag không tồn tại")
+	}
+	if tag, err = s.resolveTag(tag); err != nil {
+		return err
+	}
+
+	return s.tagRepo.Vote(storyID, tag.ID, userID, value)
+}
+
+// checkStoryAccess - Chỉ đề xuất/vote tag trên truyện đã publish, truyện mature cần người đọc đã bật hiển thị
+func (s *tagService) checkStoryAccess(storyID uuid.UUID, showMature bool) error {
+	story, err := s.storyRepo.FindStoryByID(storyID)
+	if err != nil || !story.IsPublished {
+		return errors.New("truyện không tồn tại")
+	}
+	return checkContentAccess(story, showMature)
+}
+
+// resolveTag - Quy alias về tag chính, chặn tag bị ban
+func (s *tagService) resolveTag(tag *models.Tag) (*models.Tag, error) {
+	if tag.AliasOfID != nil {
+		canonical, err := s.tagRepo.FindTagByID(*tag.AliasOfID)
+		if err != nil {
+			return nil, errors.New("tag không tồn tại")
+		}
+		tag = canonical
+	}
+	if tag.Status == models.TagStatusBanned {
+		return nil, errors.New("tag này đã bị cấm")
+	}
+	return tag, nil
+}
+
+// GetAllTags - Danh sách tag (Admin)
+func (s *tagService) GetAllTags(query, status string, page, limit int) ([]models.Tag, int64, error) {
+	return s.tagRepo.GetAllTags(query, status, page, limit)
+}
+
+// CreateTag - Tạo tag (Admin)
+func (s *tagService) CreateTag(name string, description *string) (*models.Tag, error) {
+	name, tagSlug, err := normalizeTagName(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkTagNameFree(name, tagSlug, uuid.Nil); err != nil {
+		return nil, err
+	}
+
+	tag := &models.Tag{
+		Name:        name,
+		Slug:        tagSlug,
+		Description: description,
+		Status:      models.TagStatusActive,
+	}
+	if err := s.tagRepo.CreateTag(tag); err != nil {
+		return nil, errors.New("không thể tạo tag")
+	}
+	return tag, nil
+}
+
+// UpdateTag - Đổi tên / mô tả tag (Admin)
+func (s *tagService) UpdateTag(id uuid.UUID, name string, description *string) (*models.Tag, error) {
+	tag, err := s.tagRepo.FindTagByID(id)
+	if err != nil {
+		return nil, errors.New("tag không tồn tại")
+	}
+
+	name, newSlug, err := normalizeTagName(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkTagNameFree(name, newSlug, tag.ID); err != nil {
+		return nil, err
+	}
+
+	tag.Name = name
+	tag.Slug = newSlug
+	if description != nil {
+		tag.Description = description
+	}
+
+	if err := s.tagRepo.UpdateTag(tag); err != nil {
+		return nil, errors.New("không thể cập nhật tag")
+	}
+	return tag, nil
+}
+
+// AddAlias - Thêm tên khác cho tag, người đọc đề xuất tên này sẽ được quy về tag chính
+func (s *tagService) AddAlias(id uuid.UUID, name string) (*models.Tag, error) {
+	tag, err := s.tagRepo.FindTagByID(id)
+	if err != nil {
+		return nil, errors.New("tag không tồn tại")
+	}
+	if tag.AliasOfID != nil {
+		return nil, errors.New("không thể thêm alias cho một alias")
+	}
+
+	name, aliasSlug, err := normalizeTagName(name)
+	if err != nil {
+		return nil, err
+	}
+	// Tên đã là một tag: gộp tag đó vào tag chính
+	if existing, err := s.tagRepo.FindTagByName(name); err == nil {
+		if err := s.MergeTags(tag.ID, existing.ID); err != nil {
+			return nil, err
+		}
+		return s.tagRepo.FindTagByID(existing.ID)
+	}
+	if err := s.checkTagNameFree(name, aliasSlug, uuid.Nil); err != nil {
+		return nil, err
+	}
+
+	alias := &models.Tag{
+		Name:      name,
+		Slug:      aliasSlug,
+		Status:    models.TagStatusActive,
+		AliasOfID: &tag.ID,
+	}
+	if err := s.tagRepo.CreateTag(alias); err != nil {
+		return nil, errors.New("không thể tạo alias")
+	}
+	return alias, nil
+}
+
+// normalizeTagName - Bỏ khoảng trắng thừa, kiểm tra độ dài và slug, trả về tên + slug
+func normalizeTagName(name string) (string, string, error) {
+	name = strings.TrimSpace(name)
+	tagSlug := slug.Make(name)
+	if name == "" || len([]rune(name)) > 50 || tagSlug == "" {
+		return "", "", ErrTagNameInvalid
+	}
+	return name, tagSlug, nil
+}
+
+// checkTagNameFree - Tên/slug chưa thuộc tag nào khác (kể cả alias), excludeID: tag đang được sửa
+func (s *tagService) checkTagNameFree(name, tagSlug string, excludeID uuid.UUID) error {
+	if existing, err := s.tagRepo.FindTagByName(name); err == nil && existing.ID != excludeID {
+		return ErrTagNameTaken
+	}
+	if existing, err := s.tagRepo.FindTagBySlug(tagSlug); err == nil && existing.ID != excludeID {
+		return ErrTagNameTaken
+	}
+	return nil
+}
+
+// MergeTags - Gộp source vào target (Admin)
+func (s *tagService) MergeTags(targetID, sourceID uuid.UUID) error {
+	if targetID == sourceID {
+		return errors.New("không thể gộp tag với chính nó")
+	}
+
+	target, err := s.tagRepo.FindTagByID(targetID)
+	if err != nil {
+		return errors.New("tag đích không tồn tại")
+	}
+	if target.AliasOfID != nil {
+		return errors.New("tag đích đang là alias, hãy gộp vào tag chính")
+	}
+	if _, err := s.tagRepo.FindTagByID(sourceID); err != nil {
+		return errors.New("tag nguồn không tồn tại")
+	}
+
+	return s.tagRepo.MergeTags(targetID, sourceID)
+}
+
+// SetTagBanned - Cấm / bỏ cấm tag (Admin)
+func (s *tagService) SetTagBanned(id uuid.UUID, banned bool) (*models.Tag, error) {
+	tag, err := s.tagRepo.FindTagByID(id)
+	if err != nil {
+		return nil, errors.New("tag không tồn tại")
+	}
+
+	tag.Status = models.TagStatusActive
+	if banned {
+		tag.Status = models.TagStatusBanned
+	}
+	if err := s.tagRepo.UpdateTag(tag); err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
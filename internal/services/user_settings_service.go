@@ -10,7 +10,8 @@ import (
 
 type UserSettingsService interface {
 	GetSettings(userID uuid.UUID) (*models.UserSettings, error)
-	UpdateSettings(userID uuid.UUID, settings *models.UserSettings) (*models.UserSettings, error)
+	UpdateSettings(userID uuid.UUID, settings *models.UserSettings, showMatureContent *bool) (*models.UserSettings, error)
+	ShowMatureContent(userID uuid.UUID) bool
 }
 
 type userSettingsService struct {
@@ -47,7 +48,8 @@ func (s *userSettingsService) GetSettings(userID uuid.UUID) (*models.UserSetting
 }
 
 // UpdateSettings - Cập nhật settings
-func (s *userSettingsService) UpdateSettings(userID uuid.UUID, updates *models.UserSettings) (*models.UserSettings, error) {
+// showMatureContent = nil để giữ nguyên (bool không phân biệt được "không gửi" với false)
+func (s *userSettingsService) UpdateSettings(userID uuid.UUID, updates *models.UserSettings, showMatureContent *bool) (*models.UserSettings, error) {
 	settings, err := s.GetSettings(userID)
 	if err != nil {
 		return nil, err
@@ -72,6 +74,9 @@ func (s *userSettingsService) UpdateSettings(userID uuid.UUID, updates *models.U
 	if updates.AutoScrollSpeed >= 0 {
 		settings.AutoScrollSpeed = updates.AutoScrollSpeed
 	}
+	if showMatureContent != nil {
+		settings.ShowMatureContent = *showMatureContent
+	}
 
 	if err := s.settingsRepo.Upsert(settings); err != nil {
 		return nil, err
@@ -79,3 +84,12 @@ func (s *userSettingsService) UpdateSettings(userID uuid.UUID, updates *models.U
 
 	return settings, nil
 }
+
+// ShowMatureContent - User đã bật hiển thị nội dung người lớn chưa (chưa có settings = tắt)
+func (s *userSettingsService) ShowMatureContent(userID uuid.UUID) bool {
+	settings, err := s.settingsRepo.FindByUserID(userID)
+	if err != nil {
+		return false
+	}
+	return settings.ShowMatureContent
+}
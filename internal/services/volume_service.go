@@ -0,0 +1,192 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"mime/multipart"
+	"strings"
+
+	"nekozanedex/internal/models"
+	"nekozanedex/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+// VolumeInput - Dữ liệu tạo/cập nhật tập
+type VolumeInput struct {
+	Number      int
+	Title       *string
+	Description *string
+	CoverURL    *string // URL lấy từ /admin/upload, rỗng để bỏ ảnh bìa
+}
+
+type VolumeService interface {
+	GetVolumes(storyID uuid.UUID) ([]models.Volume, error)
+	CreateVolume(storyID uuid.UUID, input VolumeInput) (*models.Volume, error)
+	UpdateVolume(id uuid.UUID, input VolumeInput) (*models.Volume, error)
+	DeleteVolume(id uuid.UUID) error
+	SetVolumeChapters(id uuid.UUID, chapterIDs []uuid.UUID) (int64, error) // Thay danh sách chapter của tập
+	UploadCover(id uuid.UUID, file multipart.File, filename string) (*models.Volume, error)
+}
+
+type volumeService struct {
+	volumeRepo    repositories.VolumeRepository
+	storyRepo     repositories.StoryRepository
+	uploadService UploadService // nil khi chưa cấu hình Cloudinary
+}
+
+func NewVolumeService(
+	volumeRepo repositories.VolumeRepository,
+	storyRepo repositories.StoryRepository,
+	uploadService UploadService,
+) VolumeService {
+	return &volumeService{
+		volumeRepo:    volumeRepo,
+		storyRepo:     storyRepo,
+		uploadService: uploadService,
+	}
+}
+
+// GetVolumes - Danh sách tập của truyện
+func (s *volumeService) GetVolumes(storyID uuid.UUID) ([]models.Volume, error) {
+	if _, err := s.storyRepo.FindStoryByID(storyID); err != nil {
+		return nil, errors.New("truyện không tồn tại")
+	}
+	return s.volumeRepo.GetVolumesByStory(storyID)
+}
+
+// CreateVolume - Tạo tập mới, số tập không trùng trong truyện
+func (s *volumeService) CreateVolume(storyID uuid.UUID, input VolumeInput) (*models.Volume, error) {
+	if _, err := s.storyRepo.FindStoryByID(storyID); err != nil {
+		return nil, errors.New("truyện không tồn tại")
+	}
+	if input.Number <= 0 {
+		return nil, errors.New("số tập phải lớn hơn 0")
+	}
+	if _, err := s.volumeRepo.FindVolumeByNumber(storyID, input.Number); err == nil {
+		return nil, errors.New("số tập đã tồn tại")
+	}
+
+	volume := &models.Volume{
+		StoryID:     storyID,
+		Number:      input.Number,
+		Title:       trimOptional(input.Title),
+		Description: trimOptional(input.Description),
+		CoverURL:    trimOptional(input.CoverURL),
+	}
+	if err := s.volumeRepo.CreateVolume(volume); err != nil {
+		return nil, err
+	}
+	return volume, nil
+}
+
+// UpdateVolume - Cập nhật tập, xóa ảnh bìa cũ khi đổi ảnh
+func (s *volumeService) UpdateVolume(id uuid.UUID, input VolumeInput) (*models.Volume, error) {
+	volume, err := s.volumeRepo.FindVolumeByID(id)
+	if err != nil {
+		return nil, errors.New("tập không tồn tại")
+	}
+
+	if input.Number != 0 && input.Number != volume.Number {
+		if input.Number < 0 {
+			return nil, errors.New("số tập phải lớn hơn 0")
+		}
+		if _, err := s.volumeRepo.FindVolumeByNumber(volume.StoryID, input.Number); err == nil {
+			return nil, errors.New("số tập đã tồn tại")
+		}
+		volume.Number = input.Number
+	}
+	if input.Title != nil {
+		volume.Title = trimOptional(input.Title)
+	}
+	if input.Description != nil {
+		volume.Description = trimOptional(input.Description)
+	}
+	if input.CoverURL != nil {
+		s.replaceCover(volume, trimOptional(input.CoverURL))
+	}
+
+	if err := s.volumeRepo.UpdateVolume(volume); err != nil {
+		return nil, err
+	}
+	return volume, nil
+}
+
+// DeleteVolume - Xóa tập, chapter giữ nguyên và trở về chưa phân tập
+func (s *volumeService) DeleteVolume(id uuid.UUID) error {
+	volume, err := s.volumeRepo.FindVolumeByID(id)
+	if err != nil {
+		return errors.New("tập không tồn tại")
+	}
+	if err := s.volumeRepo.DeleteVolume(id); err != nil {
+		return err
+	}
+	s.replaceCover(volume, nil)
+	return nil
+}
+
+// SetVolumeChapters - Gán danh sách chapter cho tập (chapter đang ở tập khác sẽ chuyển sang)
+func (s *volumeService) SetVolumeChapters(id uuid.UUID, chapterIDs []uuid.UUID) (int64, error) {
+	volume, err := s.volumeRepo.FindVolumeByID(id)
+	if err != nil {
+		return 0, errors.New("tập không tồn tại")
+	}
+
+	return s.volumeRepo.SetVolumeChapters(volume, chapterIDs) // ID không thuộc truyện bị bỏ qua
+}
+
+// UploadCover - Upload ảnh bìa tập qua UploadService (folder manga/<slug>/volumes)
+func (s *volumeService) UploadCover(id uuid.UUID, file multipart.File, filename string) (*models.Volume, error) {
+	if s.uploadService == nil {
+		return nil, errors.New("upload service chưa được cấu hình")
+	}
+	volume, err := s.volumeRepo.FindVolumeByID(id)
+	if err != nil {
+		return nil, errors.New("tập không tồn tại")
+	}
+	story, err := s.storyRepo.FindStoryByID(volume.StoryID)
+	if err != nil {
+		return nil, errors.New("truyện không tồn tại")
+	}
+
+	url, err := s.uploadService.UploadImage(file, filename, fmt.Sprintf("manga/%s/volumes", story.Slug))
+	if err != nil {
+		return nil, err
+	}
+	s.replaceCover(volume, &url)
+
+	if err := s.volumeRepo.UpdateVolume(volume); err != nil {
+		return nil, err
+	}
+	return volume, nil
+}
+
+// replaceCover - Đổi ảnh bìa, xóa ảnh cũ trên Cloudinary (không chặn request)
+func (s *volumeService) replaceCover(volume *models.Volume, cover *string) {
+	old := volume.CoverURL
+	volume.CoverURL = cover
+	if old == nil || *old == "" || (cover != nil && *cover == *old) || s.uploadService == nil {
+		return
+	}
+
+	if publicID := extractCloudinaryPublicID(*old); publicID != "" {
+		go func(publicID string) {
+			if err := s.uploadService.DeleteImage(publicID); err != nil {
+				log.Printf("[VolumeService] Failed to delete old cover %s: %v", publicID, err)
+			}
+		}(publicID)
+	}
+}
+
+// trimOptional - Trim chuỗi tùy chọn, chuỗi rỗng thành nil
+func trimOptional(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*value)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
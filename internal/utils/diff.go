@@ -0,0 +1,129 @@
+package utils
+
+import "strings"
+
+// Diff operations
+const (
+	DiffEqual  = "equal"
+	DiffInsert = "insert"
+	DiffDelete = "delete"
+)
+
+// maxDiffCells - Giới hạn bảng LCS (dòng cũ x dòng mới), vượt quá thì trả diff thô
+const maxDiffCells = 4_000_000
+
+// DiffLine - Một dòng trong kết quả diff, số dòng bắt đầu từ 1 (0 = không có ở phía đó)
+type DiffLine struct {
+	Op      string `json:"op"`
+	Text    string `json:"text"`
+	OldLine int    `json:"old_line,omitempty"`
+	NewLine int    `json:"new_line,omitempty"`
+}
+
+// DiffStats - Tổng số dòng thêm/xóa
+type DiffStats struct {
+	Added   int `json:"added"`
+	Removed int `json:"removed"`
+}
+
+// SplitLines - Tách text thành các dòng, chuẩn hóa \r\n
+func SplitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// DiffLines - Diff theo dòng dựa trên LCS (longest common subsequence)
+// Phần đầu/cuối giống nhau được bỏ qua trước khi dựng bảng để giảm bộ nhớ
+func DiffLines(oldLines, newLines []string) ([]DiffLine, DiffStats) {
+	var result []DiffLine
+	var stats DiffStats
+
+	prefix := 0
+	for prefix < len(oldLines) && prefix < len(newLines) && oldLines[prefix] == newLines[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(oldLines)-prefix && suffix < len(newLines)-prefix &&
+		oldLines[len(oldLines)-1-suffix] == newLines[len(newLines)-1-suffix] {
+		suffix++
+	}
+
+	for i := 0; i < prefix; i++ {
+		result = append(result, DiffLine{Op: DiffEqual, Text: oldLines[i], OldLine: i + 1, NewLine: i + 1})
+	}
+
+	a := oldLines[prefix : len(oldLines)-suffix]
+	b := newLines[prefix : len(newLines)-suffix]
+	oldNo, newNo := prefix, prefix
+
+	emitDelete := func(text string) {
+		oldNo++
+		stats.Removed++
+		result = append(result, DiffLine{Op: DiffDelete, Text: text, OldLine: oldNo})
+	}
+	emitInsert := func(text string) {
+		newNo++
+		stats.Added++
+		result = append(result, DiffLine{Op: DiffInsert, Text: text, NewLine: newNo})
+	}
+
+	if len(a)*len(b) > maxDiffCells {
+		// Quá lớn: coi như thay toàn bộ đoạn giữa
+		for _, line := range a {
+			emitDelete(line)
+		}
+		for _, line := range b {
+			emitInsert(line)
+		}
+	} else {
+		// lcs[i][j] = độ dài LCS của a[i:] và b[j:]
+		cols := len(b) + 1
+		lcs := make([]int32, (len(a)+1)*cols)
+		for i := len(a) - 1; i >= 0; i-- {
+			for j := len(b) - 1; j >= 0; j-- {
+				if a[i] == b[j] {
+					lcs[i*cols+j] = lcs[(i+1)*cols+j+1] + 1
+				} else if lcs[(i+1)*cols+j] >= lcs[i*cols+j+1] {
+					lcs[i*cols+j] = lcs[(i+1)*cols+j]
+				} else {
+					lcs[i*cols+j] = lcs[i*cols+j+1]
+				}
+			}
+		}
+
+		i, j := 0, 0
+		for i < len(a) && j < len(b) {
+			switch {
+			case a[i] == b[j]:
+				oldNo++
+				newNo++
+				result = append(result, DiffLine{Op: DiffEqual, Text: a[i], OldLine: oldNo, NewLine: newNo})
+				i++
+				j++
+			case lcs[(i+1)*cols+j] >= lcs[i*cols+j+1]:
+				emitDelete(a[i])
+				i++
+			default:
+				emitInsert(b[j])
+				j++
+			}
+		}
+		for ; i < len(a); i++ {
+			emitDelete(a[i])
+		}
+		for ; j < len(b); j++ {
+			emitInsert(b[j])
+		}
+	}
+
+	for k := 0; k < suffix; k++ {
+		oldNo++
+		newNo++
+		result = append(result, DiffLine{Op: DiffEqual, Text: oldLines[len(oldLines)-suffix+k], OldLine: oldNo, NewLine: newNo})
+	}
+
+	return result, stats
+}
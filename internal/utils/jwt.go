@@ -12,6 +12,7 @@ type JWTClaim struct {
 	UserID 		uuid.UUID		`json:"user_id"`
 	Username 	string			`json:"username"`
 	Role 		string			`json:"role"`
+	Permissions []string		`json:"permissions"` // Quyền của role tại thời điểm cấp token
 	jwt.RegisteredClaims
 }
 
@@ -20,11 +21,12 @@ type TokenPair struct {
 	RefreshToken string `json:"refresh_token"`
 }
 
-func GenerateAccessToken(userID uuid.UUID, username, role, secret string, expiresSeconds int) (string, error) {
+func GenerateAccessToken(userID uuid.UUID, username, role string, permissions []string, secret string, expiresSeconds int) (string, error) {
 	claims := JWTClaim{
-		UserID:   userID,
-		Username: username,
-		Role:     role,
+		UserID:      userID,
+		Username:    username,
+		Role:        role,
+		Permissions: permissions,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Second * time.Duration(expiresSeconds))),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// NaturalLess - So sánh tên file theo thứ tự tự nhiên: "page2" < "page10", không phân biệt hoa thường
+func NaturalLess(a, b string) bool {
+	a, b = strings.ToLower(a), strings.ToLower(b)
+	ra, rb := []rune(a), []rune(b)
+	i, j := 0, 0
+	for i < len(ra) && j < len(rb) {
+		if unicode.IsDigit(ra[i]) && unicode.IsDigit(rb[j]) {
+			si, sj := i, j
+			for i < len(ra) && unicode.IsDigit(ra[i]) {
+				i++
+			}
+			for j < len(rb) && unicode.IsDigit(rb[j]) {
+				j++
+			}
+			// So sánh giá trị số: bỏ số 0 đầu rồi so độ dài, sau đó so từng chữ số
+			na := strings.TrimLeft(string(ra[si:i]), "0")
+			nb := strings.TrimLeft(string(rb[sj:j]), "0")
+			if len(na) != len(nb) {
+				return len(na) < len(nb)
+			}
+			if na != nb {
+				return na < nb
+			}
+			// Cùng giá trị ("01" và "1"): ít số 0 đầu đứng trước
+			if i-si != j-sj {
+				return i-si < j-sj
+			}
+			continue
+		}
+		if ra[i] != rb[j] {
+			return ra[i] < rb[j]
+		}
+		i++
+		j++
+	}
+	if len(ra)-i != len(rb)-j {
+		return len(ra)-i < len(rb)-j
+	}
+	return a < b
+}
@@ -0,0 +1,83 @@
+package cbz
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+// ComicInfo - Metadata theo schema ComicInfo.xml (Anansi Project v2.1), đọc được bởi Komga, Kavita, Tachiyomi...
+type ComicInfo struct {
+	XMLName         xml.Name `xml:"ComicInfo"`
+	XMLNSXsi        string   `xml:"xmlns:xsi,attr"`
+	XMLNSXsd        string   `xml:"xmlns:xsd,attr"`
+	Title           string   `xml:"Title,omitempty"`
+	Series          string   `xml:"Series"`
+	Number          string   `xml:"Number,omitempty"`
+	Count           int      `xml:"Count,omitempty"` // Tổng số chapter khi truyện đã hoàn thành
+	Volume          int      `xml:"Volume,omitempty"`
+	AlternateSeries string   `xml:"AlternateSeries,omitempty"`
+	Summary         string   `xml:"Summary,omitempty"`
+	Year            int      `xml:"Year,omitempty"`
+	Month           int      `xml:"Month,omitempty"`
+	Day             int      `xml:"Day,omitempty"`
+	Writer          string   `xml:"Writer,omitempty"`
+	Penciller       string   `xml:"Penciller,omitempty"`
+	Translator      string   `xml:"Translator,omitempty"`
+	Publisher       string   `xml:"Publisher,omitempty"`
+	Genre           string   `xml:"Genre,omitempty"`
+	PageCount       int      `xml:"PageCount"`
+	LanguageISO     string   `xml:"LanguageISO,omitempty"`
+	Manga           string   `xml:"Manga,omitempty"` // Yes, YesAndRightToLeft, No
+	AgeRating       string   `xml:"AgeRating,omitempty"`
+}
+
+// Giá trị của trường Manga
+const (
+	MangaYes            = "Yes"
+	MangaYesRightToLeft = "YesAndRightToLeft"
+	MangaNo             = "No"
+)
+
+const (
+	comicInfoNamespaceXsi = "http://www.w3.org/2001/XMLSchema-instance"
+	comicInfoNamespaceXsd = "http://www.w3.org/2001/XMLSchema"
+)
+
+// Writer - Ghi CBZ dạng stream: ComicInfo.xml trước, rồi tới từng trang theo thứ tự
+// Ảnh đã nén sẵn nên lưu Store (không nén lại) cho nhanh
+type Writer struct {
+	zw *zip.Writer
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{zw: zip.NewWriter(w)}
+}
+
+// WriteComicInfo - Ghi ComicInfo.xml ở gốc archive
+func (w *Writer) WriteComicInfo(info *ComicInfo) error {
+	info.XMLNSXsi = comicInfoNamespaceXsi
+	info.XMLNSXsd = comicInfoNamespaceXsd
+
+	f, err := w.zw.CreateHeader(&zip.FileHeader{Name: "ComicInfo.xml", Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(f, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(f)
+	encoder.Indent("", "  ")
+	return encoder.Encode(info)
+}
+
+// CreateEntry - Tạo file mới trong archive (trang ảnh hoặc CBZ lồng), trả về writer cho nội dung
+func (w *Writer) CreateEntry(name string, modified time.Time) (io.Writer, error) {
+	return w.zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store, Modified: modified})
+}
+
+// Close - Ghi central directory, bắt buộc gọi để archive hợp lệ
+func (w *Writer) Close() error {
+	return w.zw.Close()
+}
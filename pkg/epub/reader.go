@@ -0,0 +1,568 @@
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+)
+
+const (
+	// maxEntrySize - Giới hạn mỗi file trong EPUB khi giải nén (chống zip bomb)
+	maxEntrySize = 20 * 1024 * 1024
+	// maxTotalSize - Tổng dung lượng giải nén của cả lần đọc (nhiều file nhỏ cộng lại)
+	maxTotalSize = 200 * 1024 * 1024
+	// maxSpineItems - Số mục spine tối đa
+	maxSpineItems = 5000
+)
+
+// archive - File trong EPUB và phần dung lượng giải nén còn được đọc
+type archive struct {
+	files     map[string]*zip.File
+	remaining int64
+}
+
+// Document - Nội dung đọc được từ một file EPUB
+type Document struct {
+	Metadata Metadata
+	Chapters []ParsedChapter // Theo thứ tự spine
+	Cover    *Image
+}
+
+// Metadata - Metadata Dublin Core của EPUB
+type Metadata struct {
+	Title       string
+	AltTitles   []string
+	Authors     []string
+	Translators []string
+	Subjects    []string
+	Description string
+	Language    string
+}
+
+// ParsedChapter - Một chapter lấy từ spine, Text là văn bản thuần (mỗi đoạn một dòng)
+type ParsedChapter struct {
+	Title string
+	Href  string   // Đường dẫn file XHTML trong EPUB
+	Files []string // Các file spine đã gộp vào chapter (file không có mục trong mục lục)
+	Text  string
+}
+
+type opfPackage struct {
+	Metadata struct {
+		Titles       []opfText    `xml:"title"`
+		Creators     []opfCreator `xml:"creator"`
+		Contributors []opfCreator `xml:"contributor"`
+		Subjects     []string     `xml:"subject"`
+		Description  string       `xml:"description"`
+		Languages    []string     `xml:"language"`
+		Metas        []opfMeta    `xml:"meta"`
+	} `xml:"metadata"`
+	Manifest struct {
+		Items []opfItem `xml:"item"`
+	} `xml:"manifest"`
+	Spine struct {
+		Toc      string `xml:"toc,attr"`
+		ItemRefs []struct {
+			IDRef  string `xml:"idref,attr"`
+			Linear string `xml:"linear,attr"`
+		} `xml:"itemref"`
+	} `xml:"spine"`
+}
+
+type opfText struct {
+	ID    string `xml:"id,attr"`
+	Value string `xml:",chardata"`
+}
+
+type opfCreator struct {
+	ID    string `xml:"id,attr"`
+	Role  string `xml:"role,attr"` // opf:role (EPUB 2)
+	Value string `xml:",chardata"`
+}
+
+type opfMeta struct {
+	Refines  string `xml:"refines,attr"`
+	Property string `xml:"property,attr"`
+	Name     string `xml:"name,attr"`
+	Content  string `xml:"content,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type opfItem struct {
+	ID         string `xml:"id,attr"`
+	Href       string `xml:"href,attr"`
+	MediaType  string `xml:"media-type,attr"`
+	Properties string `xml:"properties,attr"`
+}
+
+type ncxPoint struct {
+	Label   string `xml:"navLabel>text"`
+	Content struct {
+		Src string `xml:"src,attr"`
+	} `xml:"content"`
+	Points []ncxPoint `xml:"navPoint"`
+}
+
+// Read - Đọc EPUB: metadata từ OPF, chapter theo spine, tiêu đề từ nav (EPUB 3) hoặc toc.ncx
+func Read(r io.ReaderAt, size int64) (*Document, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, errors.New("epub: file không phải zip hợp lệ")
+	}
+	a := &archive{files: make(map[string]*zip.File, len(zr.File)), remaining: maxTotalSize}
+	for _, f := range zr.File {
+		a.files[f.Name] = f
+	}
+
+	var container struct {
+		Rootfiles []struct {
+			FullPath string `xml:"full-path,attr"`
+		} `xml:"rootfiles>rootfile"`
+	}
+	if err := a.decodeEntry("META-INF/container.xml", &container); err != nil {
+		return nil, err
+	}
+	if len(container.Rootfiles) == 0 {
+		return nil, errors.New("epub: container.xml không có rootfile")
+	}
+	opfPath := container.Rootfiles[0].FullPath
+
+	var pkg opfPackage
+	if err := a.decodeEntry(opfPath, &pkg); err != nil {
+		return nil, err
+	}
+	if len(pkg.Spine.ItemRefs) > maxSpineItems {
+		return nil, fmt.Errorf("epub: spine quá dài (tối đa %d mục)", maxSpineItems)
+	}
+	baseDir := path.Dir(opfPath)
+
+	items := make(map[string]opfItem, len(pkg.Manifest.Items))
+	for _, item := range pkg.Manifest.Items {
+		item.Href = resolveHref(baseDir, item.Href)
+		items[item.ID] = item
+	}
+
+	doc := &Document{Metadata: readMetadata(&pkg)}
+	doc.Cover = readCover(a, &pkg, items)
+
+	titles, navPath := readTOC(a, &pkg, items)
+	for _, ref := range pkg.Spine.ItemRefs {
+		item, ok := items[ref.IDRef]
+		if !ok || ref.Linear == "no" || item.Href == navPath || hasProperty(item.Properties, "nav") {
+			continue
+		}
+		if item.MediaType != "application/xhtml+xml" && item.MediaType != "text/html" {
+			continue
+		}
+
+		data, err := a.readEntry(item.Href)
+		if err != nil {
+			return nil, err
+		}
+		heading, text := ExtractText(data)
+		title, inTOC := titles[item.Href]
+
+		// File không có trong mục lục: chapter bị tách nhiều file -> gộp vào chapter trước,
+		// trước chapter đầu tiên thì là trang tên sách/bản quyền -> bỏ qua
+		if !inTOC && len(titles) > 0 {
+			if len(doc.Chapters) > 0 {
+				last := &doc.Chapters[len(doc.Chapters)-1]
+				last.Files = append(last.Files, item.Href)
+				if text != "" {
+					last.Text = strings.TrimSpace(last.Text + "\n" + text)
+				}
+			}
+			continue
+		}
+		if text == "" {
+			continue // Trang bìa, trang chỉ có ảnh
+		}
+		if title == "" {
+			title = heading
+		}
+		if title == "" {
+			title = fmt.Sprintf("Chương %d", len(doc.Chapters)+1)
+		}
+
+		doc.Chapters = append(doc.Chapters, ParsedChapter{
+			Title: title,
+			Href:  item.Href,
+			Files: []string{item.Href},
+			Text:  stripLeadingTitle(text, title, heading),
+		})
+	}
+
+	if len(doc.Chapters) == 0 {
+		return nil, errors.New("epub: không tìm thấy chapter có nội dung")
+	}
+	return doc, nil
+}
+
+// ExtractText - XHTML -> (tiêu đề đầu tiên, văn bản thuần), mỗi khối (p, div, h1...) một dòng
+func ExtractText(data []byte) (string, string) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+
+	var (
+		lines   []string
+		current strings.Builder
+		heading string
+		inBody  bool
+		skip    int // Đang trong script/style
+		inHead  int // Đang trong h1-h6
+		title   strings.Builder
+	)
+	flush := func() {
+		line := strings.Join(strings.Fields(current.String()), " ")
+		if line != "" {
+			lines = append(lines, line)
+		}
+		current.Reset()
+	}
+
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break // io.EOF hoặc XHTML lỗi: giữ phần đã đọc được
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			name := strings.ToLower(t.Name.Local)
+			switch {
+			case name == "body":
+				inBody = true
+			case name == "script" || name == "style" || name == "head":
+				skip++
+			case isHeading(name):
+				inHead++
+				title.Reset()
+			}
+			if isBlock(name) {
+				flush()
+			}
+		case xml.EndElement:
+			name := strings.ToLower(t.Name.Local)
+			switch {
+			case name == "script" || name == "style" || name == "head":
+				if skip > 0 {
+					skip--
+				}
+			case isHeading(name):
+				if inHead > 0 {
+					inHead--
+				}
+				if heading == "" {
+					heading = strings.Join(strings.Fields(title.String()), " ")
+				}
+			}
+			if isBlock(name) {
+				flush()
+			}
+		case xml.CharData:
+			if !inBody || skip > 0 {
+				continue
+			}
+			current.Write(t)
+			if inHead > 0 {
+				title.Write(t)
+			}
+		}
+	}
+	flush()
+	return heading, strings.Join(lines, "\n")
+}
+
+func isHeading(name string) bool {
+	return len(name) == 2 && name[0] == 'h' && name[1] >= '1' && name[1] <= '6'
+}
+
+func isBlock(name string) bool {
+	switch name {
+	case "p", "div", "br", "li", "tr", "blockquote", "section", "article", "pre", "hr", "dt", "dd", "figcaption":
+		return true
+	}
+	return isHeading(name)
+}
+
+// stripLeadingTitle - Bỏ dòng đầu nếu trùng tiêu đề (tránh lặp tiêu đề trong nội dung)
+func stripLeadingTitle(text string, titles ...string) string {
+	first, rest, _ := strings.Cut(text, "\n")
+	for _, title := range titles {
+		if title != "" && strings.EqualFold(strings.TrimSpace(first), strings.TrimSpace(title)) {
+			return strings.TrimSpace(rest)
+		}
+	}
+	return text
+}
+
+func readMetadata(pkg *opfPackage) Metadata {
+	meta := Metadata{}
+	roles := make(map[string]string) // id -> role (EPUB 3 refines)
+	for _, m := range pkg.Metadata.Metas {
+		if m.Property == "role" && strings.HasPrefix(m.Refines, "#") {
+			roles[strings.TrimPrefix(m.Refines, "#")] = strings.TrimSpace(m.Value)
+		}
+	}
+
+	for i, t := range pkg.Metadata.Titles {
+		value := cleanText(t.Value)
+		if value == "" {
+			continue
+		}
+		if i == 0 || meta.Title == "" {
+			meta.Title = value
+			continue
+		}
+		meta.AltTitles = append(meta.AltTitles, value)
+	}
+
+	people := append(append([]opfCreator{}, pkg.Metadata.Creators...), pkg.Metadata.Contributors...)
+	for i, person := range people {
+		name := cleanText(person.Value)
+		if name == "" {
+			continue
+		}
+		role := person.Role
+		if r, ok := roles[person.ID]; ok && person.ID != "" {
+			role = r
+		}
+		switch {
+		case role == "trl":
+			meta.Translators = append(meta.Translators, name)
+		case role == "aut" || (role == "" && i < len(pkg.Metadata.Creators)):
+			meta.Authors = append(meta.Authors, name)
+		}
+	}
+
+	for _, subject := range pkg.Metadata.Subjects {
+		if subject = cleanText(subject); subject != "" {
+			meta.Subjects = append(meta.Subjects, subject)
+		}
+	}
+	if pkg.Metadata.Description != "" {
+		// Mô tả thường chứa HTML đã escape
+		_, meta.Description = ExtractText([]byte("<body>" + pkg.Metadata.Description + "</body>"))
+	}
+	if len(pkg.Metadata.Languages) > 0 {
+		meta.Language = cleanText(pkg.Metadata.Languages[0])
+	}
+	return meta
+}
+
+func readCover(a *archive, pkg *opfPackage, items map[string]opfItem) *Image {
+	coverID := ""
+	for _, item := range pkg.Manifest.Items {
+		if hasProperty(item.Properties, "cover-image") {
+			coverID = item.ID
+			break
+		}
+	}
+	if coverID == "" {
+		for _, m := range pkg.Metadata.Metas {
+			if m.Name == "cover" {
+				coverID = m.Content
+				break
+			}
+		}
+	}
+	item, ok := items[coverID]
+	if !ok {
+		return nil
+	}
+	if _, supported := imageExtensions[item.MediaType]; !supported {
+		return nil
+	}
+	data, err := a.readEntry(item.Href)
+	if err != nil {
+		return nil
+	}
+	return &Image{Data: data, MediaType: item.MediaType}
+}
+
+// readTOC - Tiêu đề theo file (mục đầu tiên trỏ tới file), ưu tiên nav EPUB 3 rồi tới NCX
+func readTOC(a *archive, pkg *opfPackage, items map[string]opfItem) (map[string]string, string) {
+	titles := make(map[string]string)
+	for _, item := range items {
+		if !hasProperty(item.Properties, "nav") {
+			continue
+		}
+		data, err := a.readEntry(item.Href)
+		if err != nil {
+			break
+		}
+		readNav(data, path.Dir(item.Href), titles)
+		if len(titles) > 0 {
+			return titles, item.Href
+		}
+	}
+
+	ncxItem, ok := items[pkg.Spine.Toc]
+	if !ok {
+		for _, item := range items {
+			if item.MediaType == "application/x-dtbncx+xml" {
+				ncxItem, ok = item, true
+				break
+			}
+		}
+	}
+	if ok {
+		var ncx struct {
+			Points []ncxPoint `xml:"navMap>navPoint"`
+		}
+		if err := a.decodeEntry(ncxItem.Href, &ncx); err == nil {
+			addNCXPoints(ncx.Points, path.Dir(ncxItem.Href), titles)
+		}
+	}
+	return titles, ""
+}
+
+func addNCXPoints(points []ncxPoint, dir string, titles map[string]string) {
+	for _, point := range points {
+		href := resolveHref(dir, point.Content.Src)
+		if _, exists := titles[href]; !exists && href != "" {
+			titles[href] = cleanText(point.Label)
+		}
+		addNCXPoints(point.Points, dir, titles)
+	}
+}
+
+// readNav - Đọc <nav epub:type="toc">, lấy text của từng <a href>
+func readNav(data []byte, dir string, titles map[string]string) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+
+	navDepth := 0 // > 0 khi đang trong nav toc
+	href := ""
+	var text strings.Builder
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch strings.ToLower(t.Name.Local) {
+			case "nav":
+				if navDepth > 0 {
+					navDepth++
+				} else if hasProperty(attrValue(t, "type"), "toc") {
+					navDepth = 1
+				}
+			case "a":
+				if navDepth > 0 {
+					href = resolveHref(dir, attrValue(t, "href"))
+					text.Reset()
+				}
+			}
+		case xml.EndElement:
+			switch strings.ToLower(t.Name.Local) {
+			case "nav":
+				if navDepth > 0 {
+					navDepth--
+					if navDepth == 0 {
+						return
+					}
+				}
+			case "a":
+				if navDepth > 0 && href != "" {
+					if _, exists := titles[href]; !exists {
+						titles[href] = cleanText(text.String())
+					}
+					href = ""
+				}
+			}
+		case xml.CharData:
+			if href != "" {
+				text.Write(t)
+			}
+		}
+	}
+}
+
+func attrValue(element xml.StartElement, local string) string {
+	for _, attr := range element.Attr {
+		if attr.Name.Local == local {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+func hasProperty(properties, property string) bool {
+	for _, p := range strings.Fields(properties) {
+		if p == property {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveHref - href tương đối -> đường dẫn trong zip, bỏ #fragment
+func resolveHref(dir, href string) string {
+	href, _, _ = strings.Cut(href, "#")
+	if href == "" {
+		return ""
+	}
+	if unescaped, err := url.PathUnescape(href); err == nil {
+		href = unescaped
+	}
+	return path.Clean(path.Join(dir, href))
+}
+
+func cleanText(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// readEntry - Giải nén một file, trừ vào tổng dung lượng còn được đọc
+func (a *archive) readEntry(name string) ([]byte, error) {
+	f, ok := a.files[name]
+	if !ok {
+		return nil, fmt.Errorf("epub: thiếu file %s", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	limit := int64(maxEntrySize)
+	if a.remaining < limit {
+		limit = a.remaining
+	}
+	data, err := io.ReadAll(io.LimitReader(rc, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		if limit < maxEntrySize {
+			return nil, fmt.Errorf("epub: tổng dung lượng giải nén vượt quá %d MB", maxTotalSize/1024/1024)
+		}
+		return nil, fmt.Errorf("epub: file %s quá lớn", name)
+	}
+	a.remaining -= int64(len(data))
+	return data, nil
+}
+
+func (a *archive) decodeEntry(name string, v interface{}) error {
+	data, err := a.readEntry(name)
+	if err != nil {
+		return err
+	}
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("epub: không đọc được %s: %w", name, err)
+	}
+	return nil
+}
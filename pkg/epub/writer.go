@@ -0,0 +1,356 @@
+package epub
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+)
+
+// Book - Dữ liệu để dựng một file EPUB 3
+type Book struct {
+	Identifier  string // vd. urn:uuid:<story id>
+	Title       string
+	AltTitles   []string
+	Language    string // BCP 47, mặc định "vi"
+	Authors     []string
+	Translators []string
+	Subjects    []string // Thể loại
+	Description string
+	Publisher   string
+	Modified    time.Time
+	Cover       *Image // nil = không có ảnh bìa
+	Sections    []Section
+}
+
+// Image - Ảnh nhúng trong EPUB (jpeg, png, gif, webp)
+type Image struct {
+	Data      []byte
+	MediaType string
+}
+
+// Section - Nhóm chapter trong mục lục (vd. một tập), Title rỗng = không nhóm
+type Section struct {
+	Title    string
+	Chapters []Chapter
+}
+
+// Chapter - Một file XHTML trong spine
+type Chapter struct {
+	Title string
+	Body  string // Fragment XHTML hợp lệ, dùng TextToXHTML cho văn bản thuần
+}
+
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+const stylesheet = `body { font-family: serif; line-height: 1.6; margin: 0 5%; }
+h1 { font-size: 1.4em; text-align: center; margin: 1.5em 0 1em; }
+p { text-indent: 1.5em; margin: 0 0 0.6em; }
+.cover { text-align: center; margin: 0; padding: 0; }
+.cover img { max-width: 100%; max-height: 100%; }
+`
+
+// Write - Ghi book ra w dưới dạng EPUB 3 (kèm toc.ncx cho máy đọc EPUB 2)
+func Write(w io.Writer, book *Book) error {
+	if strings.TrimSpace(book.Title) == "" {
+		return errors.New("epub: thiếu tiêu đề")
+	}
+	if book.Identifier == "" {
+		return errors.New("epub: thiếu identifier")
+	}
+	chapterCount := 0
+	for _, section := range book.Sections {
+		chapterCount += len(section.Chapters)
+	}
+	if chapterCount == 0 {
+		return errors.New("epub: không có chapter nào")
+	}
+
+	coverFile := ""
+	if book.Cover != nil {
+		ext, ok := imageExtensions[book.Cover.MediaType]
+		if !ok {
+			return fmt.Errorf("epub: định dạng ảnh bìa không hỗ trợ: %s", book.Cover.MediaType)
+		}
+		coverFile = "images/cover" + ext
+	}
+
+	zw := zip.NewWriter(w)
+
+	// mimetype phải là file đầu tiên và không nén
+	mw, err := zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(mw, "application/epub+zip"); err != nil {
+		return err
+	}
+
+	type file struct {
+		name string
+		data []byte
+	}
+	files := []file{
+		{"META-INF/container.xml", []byte(containerXML)},
+		{"OEBPS/style.css", []byte(stylesheet)},
+		{"OEBPS/content.opf", []byte(buildPackage(book, coverFile))},
+		{"OEBPS/nav.xhtml", []byte(buildNav(book))},
+		{"OEBPS/toc.ncx", []byte(buildNCX(book))},
+	}
+	if coverFile != "" {
+		files = append(files,
+			file{"OEBPS/cover.xhtml", []byte(buildCoverPage(book, coverFile))},
+			file{"OEBPS/" + coverFile, book.Cover.Data},
+		)
+	}
+	for _, f := range files {
+		fw, err := zw.Create(f.name)
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(f.data); err != nil {
+			return err
+		}
+	}
+
+	index := 0
+	for _, section := range book.Sections {
+		for _, chapter := range section.Chapters {
+			index++
+			fw, err := zw.Create("OEBPS/" + chapterFile(index))
+			if err != nil {
+				return err
+			}
+			if _, err := io.WriteString(fw, buildChapter(book, chapter)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return zw.Close()
+}
+
+// TextToXHTML - Văn bản thuần -> các thẻ <p>, mỗi dòng không rỗng là một đoạn
+func TextToXHTML(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	var b strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		b.WriteString("<p>")
+		b.WriteString(Escape(line))
+		b.WriteString("</p>\n")
+	}
+	return b.String()
+}
+
+// Escape - Escape văn bản cho XML, bỏ các ký tự điều khiển không hợp lệ trong XML 1.0
+func Escape(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if r == '\t' || r == '\n' || r == '\r' {
+			return r
+		}
+		if r < 0x20 || r == 0xFFFE || r == 0xFFFF {
+			return -1
+		}
+		return r
+	}, s)
+	return html.EscapeString(s)
+}
+
+const containerXML = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`
+
+func chapterFile(index int) string {
+	return fmt.Sprintf("text/ch%04d.xhtml", index)
+}
+
+func language(book *Book) string {
+	if book.Language == "" {
+		return "vi"
+	}
+	return book.Language
+}
+
+func buildPackage(book *Book, coverFile string) string {
+	modified := book.Modified
+	if modified.IsZero() {
+		modified = time.Now()
+	}
+
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="bookid">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+`)
+	fmt.Fprintf(&b, "    <dc:identifier id=\"bookid\">%s</dc:identifier>\n", Escape(book.Identifier))
+	fmt.Fprintf(&b, "    <dc:title id=\"title\">%s</dc:title>\n", Escape(book.Title))
+	b.WriteString("    <meta refines=\"#title\" property=\"title-type\">main</meta>\n")
+	for i, alt := range book.AltTitles {
+		fmt.Fprintf(&b, "    <dc:title id=\"alt-title-%d\">%s</dc:title>\n", i+1, Escape(alt))
+	}
+	fmt.Fprintf(&b, "    <dc:language>%s</dc:language>\n", Escape(language(book)))
+	for i, author := range book.Authors {
+		fmt.Fprintf(&b, "    <dc:creator id=\"author-%d\">%s</dc:creator>\n", i+1, Escape(author))
+		fmt.Fprintf(&b, "    <meta refines=\"#author-%d\" property=\"role\" scheme=\"marc:relators\">aut</meta>\n", i+1)
+	}
+	for i, translator := range book.Translators {
+		fmt.Fprintf(&b, "    <dc:contributor id=\"translator-%d\">%s</dc:contributor>\n", i+1, Escape(translator))
+		fmt.Fprintf(&b, "    <meta refines=\"#translator-%d\" property=\"role\" scheme=\"marc:relators\">trl</meta>\n", i+1)
+	}
+	for _, subject := range book.Subjects {
+		fmt.Fprintf(&b, "    <dc:subject>%s</dc:subject>\n", Escape(subject))
+	}
+	if book.Description != "" {
+		fmt.Fprintf(&b, "    <dc:description>%s</dc:description>\n", Escape(book.Description))
+	}
+	if book.Publisher != "" {
+		fmt.Fprintf(&b, "    <dc:publisher>%s</dc:publisher>\n", Escape(book.Publisher))
+	}
+	fmt.Fprintf(&b, "    <meta property=\"dcterms:modified\">%s</meta>\n", modified.UTC().Format("2006-01-02T15:04:05Z"))
+	if coverFile != "" {
+		b.WriteString("    <meta name=\"cover\" content=\"cover-image\"/>\n")
+	}
+	b.WriteString("  </metadata>\n  <manifest>\n")
+	b.WriteString("    <item id=\"nav\" href=\"nav.xhtml\" media-type=\"application/xhtml+xml\" properties=\"nav\"/>\n")
+	b.WriteString("    <item id=\"ncx\" href=\"toc.ncx\" media-type=\"application/x-dtbncx+xml\"/>\n")
+	b.WriteString("    <item id=\"style\" href=\"style.css\" media-type=\"text/css\"/>\n")
+	if coverFile != "" {
+		fmt.Fprintf(&b, "    <item id=\"cover-image\" href=\"%s\" media-type=\"%s\" properties=\"cover-image\"/>\n", coverFile, book.Cover.MediaType)
+		b.WriteString("    <item id=\"cover\" href=\"cover.xhtml\" media-type=\"application/xhtml+xml\"/>\n")
+	}
+	count := 0
+	for _, section := range book.Sections {
+		for range section.Chapters {
+			count++
+			fmt.Fprintf(&b, "    <item id=\"ch%04d\" href=\"%s\" media-type=\"application/xhtml+xml\"/>\n", count, chapterFile(count))
+		}
+	}
+	b.WriteString("  </manifest>\n  <spine toc=\"ncx\">\n")
+	if coverFile != "" {
+		b.WriteString("    <itemref idref=\"cover\"/>\n")
+	}
+	b.WriteString("    <itemref idref=\"nav\"/>\n")
+	for i := 1; i <= count; i++ {
+		fmt.Fprintf(&b, "    <itemref idref=\"ch%04d\"/>\n", i)
+	}
+	b.WriteString("  </spine>\n</package>\n")
+	return b.String()
+}
+
+func xhtmlHead(book *Book, title string) string {
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" xml:lang="%[1]s" lang="%[1]s">
+<head>
+  <meta charset="UTF-8"/>
+  <title>%[2]s</title>
+`, Escape(language(book)), Escape(title))
+}
+
+// buildNav - Mục lục EPUB 3, chapter trong tập được lồng dưới tên tập
+func buildNav(book *Book) string {
+	var b strings.Builder
+	b.WriteString(xhtmlHead(book, book.Title))
+	b.WriteString("</head>\n<body>\n  <nav epub:type=\"toc\" id=\"toc\">\n    <h1>Mục lục</h1>\n    <ol>\n")
+	index := 0
+	for _, section := range book.Sections {
+		if len(section.Chapters) == 0 {
+			continue // <ol> rỗng không hợp lệ trong nav
+		}
+		if section.Title != "" {
+			fmt.Fprintf(&b, "      <li><span>%s</span>\n        <ol>\n", Escape(section.Title))
+		}
+		for _, chapter := range section.Chapters {
+			index++
+			indent := "      "
+			if section.Title != "" {
+				indent = "          "
+			}
+			fmt.Fprintf(&b, "%s<li><a href=\"%s\">%s</a></li>\n", indent, chapterFile(index), Escape(chapter.Title))
+		}
+		if section.Title != "" {
+			b.WriteString("        </ol>\n      </li>\n")
+		}
+	}
+	b.WriteString("    </ol>\n  </nav>\n</body>\n</html>\n")
+	return b.String()
+}
+
+// buildNCX - Mục lục EPUB 2 cho máy đọc cũ, tập trỏ tới chapter đầu tiên của tập
+func buildNCX(book *Book) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<ncx xmlns="http://www.daisy.org/z3986/2005/ncx/" version="2005-1">
+  <head>
+`)
+	fmt.Fprintf(&b, "    <meta name=\"dtb:uid\" content=\"%s\"/>\n", Escape(book.Identifier))
+	b.WriteString("    <meta name=\"dtb:depth\" content=\"2\"/>\n")
+	b.WriteString("    <meta name=\"dtb:totalPageCount\" content=\"0\"/>\n")
+	b.WriteString("    <meta name=\"dtb:maxPageNumber\" content=\"0\"/>\n  </head>\n")
+	fmt.Fprintf(&b, "  <docTitle><text>%s</text></docTitle>\n  <navMap>\n", Escape(book.Title))
+
+	index, playOrder := 0, 0
+	pointID := 0
+	navPoint := func(indent, label, src string, order int) {
+		pointID++
+		fmt.Fprintf(&b, "%s<navPoint id=\"nav-%d\" playOrder=\"%d\">\n", indent, pointID, order)
+		fmt.Fprintf(&b, "%s  <navLabel><text>%s</text></navLabel>\n", indent, Escape(label))
+		fmt.Fprintf(&b, "%s  <content src=\"%s\"/>\n", indent, src)
+	}
+	for _, section := range book.Sections {
+		if len(section.Chapters) == 0 {
+			continue
+		}
+		indent := "    "
+		if section.Title != "" {
+			navPoint(indent, section.Title, chapterFile(index+1), playOrder+1) // Cùng src với chapter đầu nên cùng playOrder
+			indent = "      "
+		}
+		for _, chapter := range section.Chapters {
+			index++
+			playOrder++
+			navPoint(indent, chapter.Title, chapterFile(index), playOrder)
+			fmt.Fprintf(&b, "%s</navPoint>\n", indent)
+		}
+		if section.Title != "" {
+			b.WriteString("    </navPoint>\n")
+		}
+	}
+	b.WriteString("  </navMap>\n</ncx>\n")
+	return b.String()
+}
+
+func buildCoverPage(book *Book, coverFile string) string {
+	var b strings.Builder
+	b.WriteString(xhtmlHead(book, book.Title))
+	b.WriteString("  <link rel=\"stylesheet\" type=\"text/css\" href=\"style.css\"/>\n</head>\n")
+	fmt.Fprintf(&b, "<body class=\"cover\">\n  <section epub:type=\"cover\">\n    <img src=\"%s\" alt=\"%s\"/>\n  </section>\n</body>\n</html>\n",
+		coverFile, Escape(book.Title))
+	return b.String()
+}
+
+func buildChapter(book *Book, chapter Chapter) string {
+	var b strings.Builder
+	b.WriteString(xhtmlHead(book, chapter.Title))
+	b.WriteString("  <link rel=\"stylesheet\" type=\"text/css\" href=\"../style.css\"/>\n</head>\n<body>\n  <section epub:type=\"chapter\">\n")
+	fmt.Fprintf(&b, "    <h1>%s</h1>\n", Escape(chapter.Title))
+	b.WriteString(chapter.Body)
+	b.WriteString("  </section>\n</body>\n</html>\n")
+	return b.String()
+}
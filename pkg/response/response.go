@@ -13,6 +13,7 @@ type Response struct {
 	Message 	string 			`json:"message,omitempty"`
 	Data 		interface{} 	`json:"data,omitempty"`
 	Error 		string 			`json:"error,omitempty"`
+	Code 		string 			`json:"code,omitempty"` // Mã máy đọc được cho các trường hợp đặc biệt (MOVED_PERMANENTLY...)
 }
 
 type Pagination struct {
@@ -21,6 +22,19 @@ type Pagination struct {
 	Meta 		Meta 			`json:"meta"`
 }
 
+// CursorPagination - Phân trang theo cursor (keyset), không có tổng số
+type CursorPagination struct {
+	Success 	bool 			`json:"success"`
+	Data 		interface{} 	`json:"data"`
+	Meta 		CursorMeta 		`json:"meta"`
+}
+
+type CursorMeta struct {
+	Limit		int				`json:"limit"`
+	NextCursor	string			`json:"next_cursor,omitempty"` // Truyền vào ?cursor= để lấy trang sau
+	HasMore		bool			`json:"has_more"`
+}
+
 type Meta struct {
 	Page		int				`json:"page"`
 	Limit		int				`json:"limit"`
@@ -44,6 +58,14 @@ func Created(c *gin.Context, data interface{}){
 	})
 }
 
+// Accepted - Đã nhận yêu cầu, xử lý nền (job import...)
+func Accepted(c *gin.Context, data interface{}){
+	c.JSON(http.StatusAccepted, Response{
+		Success: true,
+		Data: data,
+	})
+}
+
 //Phân Trang - Pagination
 func PaginatedResponse(c *gin.Context, data interface{},page,limit int, total int64){
 	totalPages := int(total)/limit
@@ -62,6 +84,30 @@ func PaginatedResponse(c *gin.Context, data interface{},page,limit int, total in
 	})
 }
 
+//Phân Trang Theo Cursor - Cursor Pagination
+func CursorPaginatedResponse(c *gin.Context, data interface{}, limit int, nextCursor string){
+	c.JSON(http.StatusOK, CursorPagination{
+		Success: true,
+		Data: data,
+		Meta: CursorMeta{
+			Limit: limit,
+			NextCursor: nextCursor,
+			HasMore: nextCursor != "",
+		},
+	})
+}
+
+//301 - Moved Permanently - Tài nguyên đã chuyển sang địa chỉ mới
+func MovedPermanently(c *gin.Context, location string, data interface{}){
+	c.Header("Location", location)
+	c.JSON(http.StatusMovedPermanently, Response{
+		Success: false,
+		Code: "MOVED_PERMANENTLY",
+		Data: data,
+		Error: "Tài nguyên đã chuyển sang địa chỉ mới",
+	})
+}
+
 //400 - Phản hồi Thất Bại (Response Error)
 func BadRequest(c *gin.Context, message string){
 	c.JSON(http.StatusBadRequest, Response{
@@ -86,6 +132,16 @@ func Forbidden(c *gin.Context, message string){
 	})
 }
 
+//403 - Content Warning - Nội dung người lớn, client hiện cảnh báo / hướng dẫn bật trong cài đặt
+func ContentWarning(c *gin.Context, message string, data interface{}){
+	c.JSON(http.StatusForbidden, Response{
+		Success: false,
+		Code: "CONTENT_WARNING",
+		Data: data,
+		Error: message,
+	})
+}
+
 //404 - Not Found - Không tìm thấy
 func NotFound(c *gin.Context, message string){
 	c.JSON(http.StatusNotFound, Response{
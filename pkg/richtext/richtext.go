@@ -0,0 +1,131 @@
+package richtext
+
+import (
+	"bytes"
+	"html"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	htmlrenderer "github.com/yuin/goldmark/renderer/html"
+	nethtml "golang.org/x/net/html"
+)
+
+var (
+	// policy - Allowlist cho nội dung chapter: định dạng văn bản, link, ảnh, bảng, ruby (furigana)
+	policy = newPolicy()
+
+	// markdown - GFM (bảng, gạch ngang, autolink), HTML thô trong Markdown bị bỏ
+	markdown = goldmark.New(
+		goldmark.WithExtensions(extension.Table, extension.Strikethrough, extension.Linkify),
+		goldmark.WithRendererOptions(htmlrenderer.WithHardWraps()),
+	)
+
+	// noteMarker - Chỗ gắn ghi chú trong nội dung: [^id]
+	noteMarker = regexp.MustCompile(`\[\^([A-Za-z0-9_-]{1,50})\]`)
+	// NoteIDPattern - Id hợp lệ của ghi chú (an toàn để chèn vào thuộc tính HTML)
+	NoteIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,50}$`)
+)
+
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowElements("ruby", "rt", "rp", "u", "mark")
+	p.AddTargetBlankToFullyQualifiedLinks(true)
+	return p
+}
+
+// Sanitize - Lọc HTML theo allowlist (bỏ script, style, on*, javascript:...)
+func Sanitize(content string) string {
+	return strings.TrimSpace(policy.Sanitize(content))
+}
+
+// MarkdownToHTML - Markdown -> HTML đã lọc
+func MarkdownToHTML(source string) (string, error) {
+	var buf bytes.Buffer
+	if err := markdown.Convert([]byte(source), &buf); err != nil {
+		return "", err
+	}
+	return Sanitize(buf.String()), nil
+}
+
+// PlainToHTML - Văn bản thuần -> mỗi dòng không rỗng một <p>
+func PlainToHTML(text string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		b.WriteString("<p>")
+		b.WriteString(html.EscapeString(line))
+		b.WriteString("</p>\n")
+	}
+	return b.String()
+}
+
+// ReplaceNoteMarkers - Thay [^id] trong phần chữ của HTML (bỏ qua thuộc tính, code, pre)
+// replace trả về HTML thay thế, false = giữ nguyên marker
+func ReplaceNoteMarkers(content string, replace func(id string) (string, bool)) string {
+	if !strings.Contains(content, "[^") {
+		return content
+	}
+
+	var b strings.Builder
+	tokenizer := nethtml.NewTokenizer(strings.NewReader(content))
+	inCode := 0
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == nethtml.ErrorToken {
+			if tokenizer.Err() != io.EOF {
+				return content
+			}
+			return b.String()
+		}
+		raw := tokenizer.Raw()
+
+		switch tokenType {
+		case nethtml.StartTagToken, nethtml.EndTagToken:
+			name, _ := tokenizer.TagName()
+			if tag := string(name); tag == "code" || tag == "pre" {
+				if tokenType == nethtml.StartTagToken {
+					inCode++
+				} else if inCode > 0 {
+					inCode--
+				}
+			}
+		case nethtml.TextToken:
+			if inCode == 0 {
+				raw = noteMarker.ReplaceAllFunc(raw, func(marker []byte) []byte {
+					id := string(noteMarker.FindSubmatch(marker)[1])
+					if replacement, ok := replace(id); ok {
+						return []byte(replacement)
+					}
+					return marker
+				})
+			}
+		}
+		b.Write(raw)
+	}
+}
+
+// LinkNotes - Thay [^id] bằng <sup> trỏ tới ghi chú (đánh số theo thứ tự ids) để client hiện popover
+func LinkNotes(content string, ids []string) string {
+	if len(ids) == 0 {
+		return content
+	}
+	index := make(map[string]int, len(ids))
+	for i, id := range ids {
+		index[id] = i + 1
+	}
+	return ReplaceNoteMarkers(content, func(id string) (string, bool) {
+		n, ok := index[id]
+		if !ok {
+			return "", false
+		}
+		return `<sup class="note-ref" data-note-id="` + id + `"><a href="#note-` + id + `">` + strconv.Itoa(n) + `</a></sup>`, true
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"image"
+	_ "image/gif" // Register GIF decoder
 	"image/jpeg"
 	_ "image/png" // Register PNG decoder
 	"io"
@@ -12,6 +13,7 @@ import (
 	"strings"
 
 	"github.com/disintegration/imaging"
+	_ "golang.org/x/image/webp" // Register WebP decoder (trang manga trong CBZ thường là webp)
 )
 
 // ImageConfig holds configuration for image processing
@@ -55,6 +57,11 @@ func ProcessImage(file multipart.File, header *multipart.FileHeader, config Imag
 		return nil, fmt.Errorf("không thể đọc file: %w", err)
 	}
 
+	return ProcessImageBytes(originalData, header.Filename, config)
+}
+
+// ProcessImageBytes - Như ProcessImage nhưng nhận dữ liệu đã đọc (vd. file trong ZIP/CBZ)
+func ProcessImageBytes(originalData []byte, filename string, config ImageConfig) (*ProcessedImage, error) {
 	// Decode image
 	img, format, err := image.Decode(bytes.NewReader(originalData))
 	if err != nil {
@@ -80,8 +87,8 @@ func ProcessImage(file multipart.File, header *multipart.FileHeader, config Imag
 	}
 
 	// Generate new filename
-	ext := filepath.Ext(header.Filename)
-	baseName := strings.TrimSuffix(header.Filename, ext)
+	ext := filepath.Ext(filename)
+	baseName := strings.TrimSuffix(filename, ext)
 	newFilename := baseName + ".jpg"
 
 	return &ProcessedImage{
@@ -105,6 +112,11 @@ func ProcessChapterImage(file multipart.File, header *multipart.FileHeader) (*Pr
 	return ProcessImage(file, header, ChapterImageConfig)
 }
 
+// ProcessChapterImageBytes processes a chapter image already in memory (archive import)
+func ProcessChapterImageBytes(data []byte, filename string) (*ProcessedImage, error) {
+	return ProcessImageBytes(data, filename, ChapterImageConfig)
+}
+
 // calculateDimensions calculates new dimensions while maintaining aspect ratio
 func calculateDimensions(origWidth, origHeight, maxWidth, maxHeight int) (int, int) {
 	// If image is smaller than max, keep original size